@@ -15,6 +15,8 @@ func TestErrorsInterface(t *testing.T) {
 	}{
 		{&repoNotFoundErr{}, errcode.IsNotFound},
 		{userNotFoundErr{}, errcode.IsNotFound},
+		{externalServiceNotFoundError{}, errcode.IsNotFound},
+		{externalServiceNotFoundError{displayName: "x"}, errcode.IsNotFound},
 	}
 	for _, c := range cases {
 		if !c.Predicate(c.Err) {
@@ -3,8 +3,12 @@ package db
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net"
+	"net/url"
 	"strings"
 	"sync"
 	"time"
@@ -25,106 +29,901 @@ type externalServices struct{}
 // ExternalServicesListOptions contains options for listing external services.
 type ExternalServicesListOptions struct {
 	Kind string
+
+	// IncludeDeleted includes soft-deleted external services. By default, they are excluded.
+	IncludeDeleted bool
+
+	// SiteWideOnly excludes external services owned by a user (those with a namespace_user_id), so
+	// that only site-wide external services are included. Code that syncs repositories for the whole
+	// site must set it, so that a user's external services are never synced globally.
+	SiteWideOnly bool
+
+	// CreatedAfter, if set, only includes external services whose created_at is strictly after this
+	// time.
+	CreatedAfter *time.Time
+
+	// UpdatedAfter, if set, only includes external services whose updated_at is strictly after
+	// this time. It is intended to be used as a cursor together with
+	// ExternalServicesOrderByUpdatedAtAsc by callers that process external services
+	// incrementally (e.g. the syncer).
+	//
+	// Many external services may share the same updated_at (they are updated in the same
+	// transaction, or the clock resolution is coarse), so a cursor consisting of only the last
+	// seen updated_at can skip rows when a page boundary falls within a group of equal
+	// timestamps. Callers should advance the cursor by the last (updated_at, id) pair seen and
+	// set UpdatedAfterID accordingly. Rows updated again after being seen will be returned
+	// again, so each update is processed at least once (and, barring clock skew, exactly once).
+	UpdatedAfter *time.Time
+
+	// UpdatedAfterID breaks ties between external services with an updated_at equal to
+	// UpdatedAfter: if non-zero, rows with updated_at equal to UpdatedAfter are included only if
+	// their id is greater than UpdatedAfterID. It is ignored if UpdatedAfter is nil.
+	UpdatedAfterID int64
+
+	// AfterID, if non-zero, only includes external services whose id is greater than AfterID. It
+	// is intended to be used as a cursor together with ExternalServicesOrderByIDAsc.
+	AfterID int64
+
+	// URLHost, if set, only includes external services whose config's "url" has this host
+	// (compared case-insensitively, and ignoring the port), such as "ghe.example.com". The host is
+	// stored in the url_host column when the config is written (see configURLHost).
+	URLHost string
+
+	// NeverSynced, if set, only includes external services that have never been synced (whose
+	// last_sync_at is NULL), such as newly created ones, so that they can be synced first.
+	NeverSynced bool
+
+	// OrderBy is the order in which external services are returned.
+	OrderBy ExternalServicesOrderBy
+
+	// Fields is which fields of the external services List and ListWithTotal read. By default, they
+	// read all of them. It is ignored by other methods.
+	Fields ExternalServiceFields
+
 	*LimitOffset
 }
 
 func (o ExternalServicesListOptions) sqlConditions() []*sqlf.Query {
-	conds := []*sqlf.Query{sqlf.Sprintf("deleted_at IS NULL")}
+	conds := []*sqlf.Query{excludeMigrationSentinel}
+	if !o.IncludeDeleted {
+		conds = append(conds, sqlf.Sprintf("deleted_at IS NULL"))
+	}
 	if o.Kind != "" {
 		conds = append(conds, sqlf.Sprintf("kind=%s", o.Kind))
 	}
+	if o.SiteWideOnly {
+		conds = append(conds, sqlf.Sprintf("namespace_user_id IS NULL"))
+	}
+	if o.CreatedAfter != nil {
+		conds = append(conds, sqlf.Sprintf("created_at > %s", *o.CreatedAfter))
+	}
+	if o.UpdatedAfter != nil {
+		if o.UpdatedAfterID != 0 {
+			conds = append(conds, sqlf.Sprintf("(updated_at, id) > (%s, %d)", *o.UpdatedAfter, o.UpdatedAfterID))
+		} else {
+			conds = append(conds, sqlf.Sprintf("updated_at > %s", *o.UpdatedAfter))
+		}
+	}
+	if o.AfterID != 0 {
+		conds = append(conds, sqlf.Sprintf("id > %d", o.AfterID))
+	}
+	if o.URLHost != "" {
+		conds = append(conds, sqlf.Sprintf("url_host=%s", strings.ToLower(o.URLHost)))
+	}
+	if o.NeverSynced {
+		conds = append(conds, sqlf.Sprintf("last_sync_at IS NULL"))
+	}
 	return conds
 }
 
-func validateConfig(config string) error {
-	// All configs must be valid JSON.
+// ExternalServicesOrderBy is the order in which external services are listed.
+type ExternalServicesOrderBy int
+
+const (
+	// ExternalServicesOrderByIDDesc lists the most recently created external services first. It
+	// is the default.
+	ExternalServicesOrderByIDDesc ExternalServicesOrderBy = iota
+
+	// ExternalServicesOrderByUpdatedAtAsc lists the least recently updated external services
+	// first, breaking ties by id. Use it with ExternalServicesListOptions.UpdatedAfter to page
+	// through external services in update order.
+	ExternalServicesOrderByUpdatedAtAsc
+
+	// ExternalServicesOrderByLastSyncAtDesc lists the most recently synced external services
+	// first. External services that have never been synced are listed last.
+	ExternalServicesOrderByLastSyncAtDesc
+
+	// ExternalServicesOrderByIDAsc lists the least recently created external services first. Use
+	// it with ExternalServicesListOptions.AfterID to page through all external services.
+	ExternalServicesOrderByIDAsc
+)
+
+func (o ExternalServicesOrderBy) sql() *sqlf.Query {
+	switch o {
+	case ExternalServicesOrderByUpdatedAtAsc:
+		return sqlf.Sprintf("ORDER BY updated_at ASC, id ASC")
+	case ExternalServicesOrderByLastSyncAtDesc:
+		return sqlf.Sprintf("ORDER BY last_sync_at DESC NULLS LAST, id DESC")
+	case ExternalServicesOrderByIDAsc:
+		return sqlf.Sprintf("ORDER BY id ASC")
+	default:
+		return sqlf.Sprintf("ORDER BY id DESC")
+	}
+}
+
+// ExternalServiceFields is which fields of external services are read from the database (see
+// ExternalServicesListOptions.Fields). Fields that are not read are left zero-valued.
+type ExternalServiceFields int
+
+const (
+	// ExternalServiceFieldsAll reads all fields. It is the default.
+	ExternalServiceFieldsAll ExternalServiceFields = iota
+
+	// ExternalServiceFieldsWithoutConfig reads all fields except Config, which can be large.
+	ExternalServiceFieldsWithoutConfig
+
+	// ExternalServiceFieldsBasic reads only ID, Kind, and DisplayName (e.g., for a list of external
+	// services to choose from).
+	ExternalServiceFieldsBasic
+)
+
+// columns returns the columns to read for the fields, and the destinations in h to scan them into.
+func (f ExternalServiceFields) columns(h *types.ExternalService) ([]*sqlf.Query, []interface{}) {
+	columns := []*sqlf.Query{sqlf.Sprintf("id"), sqlf.Sprintf("kind"), sqlf.Sprintf("display_name")}
+	dests := []interface{}{&h.ID, &h.Kind, &h.DisplayName}
+	if f == ExternalServiceFieldsBasic {
+		return columns, dests
+	}
+	if f != ExternalServiceFieldsWithoutConfig {
+		columns = append(columns, sqlf.Sprintf("config"))
+		dests = append(dests, &h.Config)
+	}
+	for _, c := range []struct {
+		column *sqlf.Query
+		dest   interface{}
+	}{
+		{sqlf.Sprintf("created_at"), &h.CreatedAt},
+		{sqlf.Sprintf("updated_at"), &h.UpdatedAt},
+		{sqlf.Sprintf("deleted_at"), &h.DeletedAt},
+		{sqlf.Sprintf("deletion_reason"), &h.DeletionReason},
+		{sqlf.Sprintf("disabled"), &h.Disabled},
+		{sqlf.Sprintf("health"), &h.Health},
+		{sqlf.Sprintf("last_sync_at"), &h.LastSyncAt},
+		{sqlf.Sprintf("last_sync_error"), &h.LastSyncError},
+		{sqlf.Sprintf("read_only"), &h.ReadOnly},
+	} {
+		columns = append(columns, c.column)
+		dests = append(dests, c.dest)
+	}
+	return columns, dests
+}
+
+// externalServiceKinds are the valid kinds of external services, in sorted order.
+var externalServiceKinds = []string{
+	"AWSCODECOMMIT",
+	"BITBUCKETSERVER",
+	"GITHUB",
+	"GITLAB",
+	"GITOLITE",
+	"PHABRICATOR",
+}
+
+// UnknownKindError is returned when an external service (or a config being validated) has a kind
+// that is not one of the supported kinds. Its message lists the supported kinds.
+type UnknownKindError struct {
+	Kind string
+}
+
+func (e UnknownKindError) Error() string {
+	return fmt.Sprintf("unknown external service kind %q (valid kinds are %s)", e.Kind, strings.Join(externalServiceKinds, ", "))
+}
+
+func (e UnknownKindError) BadRequest() bool { return true }
+
+// normalizeKind returns the canonical (uppercase) form of an external service kind, or an
+// UnknownKindError if it is not a valid kind.
+func normalizeKind(kind string) (string, error) {
+	normalized := strings.ToUpper(kind)
+	for _, k := range externalServiceKinds {
+		if k == normalized {
+			return k, nil
+		}
+	}
+	return "", UnknownKindError{Kind: kind}
+}
+
+// configValidationOptions control how an external service config is validated.
+type configValidationOptions struct {
+	// RequireSecrets requires all variables referenced (as ${NAME}) by the config to be defined
+	// in ConfigSecrets. Otherwise, only the well-formedness of the references is checked, and a
+	// warning is returned for each undefined variable.
+	RequireSecrets bool
+
+	// RejectUnknownFields rejects configs with properties that are not in the schema of the kind
+	// (such as a misspelled property name). By default, unknown properties are allowed, so that
+	// a config can use properties added to the schema in a newer version.
+	RejectUnknownFields bool
+}
+
+// validateConfig validates an external service config of the given kind, including the rules in
+// kindConfigValidators for the kind. It returns an error (an UnknownKindError if the kind is not
+// supported) if the config is invalid, and warnings about problems that don't prevent the config
+// from being saved.
+func validateConfig(kind, config string, opt configValidationOptions) (warnings []string, err error) {
+	kind, err = normalizeKind(kind)
+	if err != nil {
+		return nil, err
+	}
+
+	// All configs must be valid JSON (a single value, with nothing but whitespace and comments
+	// after it).
 	// If this requirement is ever changed, you will need to update
 	// serveExternalServiceConfigs to handle this case.
-	_, err := jsonc.Parse(config)
-	return err
+	normalized, err := jsonc.ParseValue(config)
+	if err != nil {
+		return nil, err
+	}
+
+	names, err := configTemplateVars(config)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		if _, ok := ConfigSecrets(name); !ok {
+			if opt.RequireSecrets {
+				return nil, fmt.Errorf("config references undefined variable %q", name)
+			}
+			warnings = append(warnings, fmt.Sprintf("config references undefined variable %q", name))
+		}
+	}
+
+	// Configs that aren't objects (such as empty configs) have nothing to lint.
+	var v map[string]interface{}
+	if err := json.Unmarshal(normalized, &v); err == nil {
+		if opt.RejectUnknownFields {
+			if err := checkUnknownFields(kind, normalized); err != nil {
+				return nil, err
+			}
+		}
+		if validate, ok := kindConfigValidators[kind]; ok {
+			kindWarnings, err := validate(v)
+			if err != nil {
+				return nil, err
+			}
+			warnings = append(warnings, kindWarnings...)
+		}
+		warnings = append(warnings, lintConfig(v)...)
+	}
+	return warnings, nil
 }
 
-// Create creates a external service.
+// ValidateConfig validates an external service config without saving it, returning warnings
+// about problems that don't prevent the config from being saved (such as references to
+// undefined variables).
+func (*externalServices) ValidateConfig(kind, config string) (warnings []string, err error) {
+	return validateConfig(kind, config, configValidationOptions{})
+}
+
+// Create creates a external service. Its kind is normalized to uppercase, and it is an error if
+// the kind is not one of the known external service kinds.
 //
 // 🚨 SECURITY: The caller must ensure that the actor is a site admin.
 func (c *externalServices) Create(ctx context.Context, externalService *types.ExternalService) error {
-	if err := validateConfig(externalService.Config); err != nil {
+	return c.CreateWithOptions(ctx, externalService, ExternalServiceCreateOptions{})
+}
+
+// ExternalServiceCreateOptions contains options for creating an external service.
+type ExternalServiceCreateOptions struct {
+	// ValidateCredentials makes CreateWithOptions check the credentials in the config with the code
+	// host (see TestConnection) and fail with a *CredentialValidationError (without creating the
+	// external service) if they are rejected.
+	ValidateCredentials bool
+
+	// RejectUnknownFields makes CreateWithOptions reject a config with properties that are not in
+	// the schema of its kind (see configValidationOptions).
+	RejectUnknownFields bool
+}
+
+// CreateWithOptions is like Create, except that it accepts options.
+//
+// 🚨 SECURITY: The caller must ensure that the actor is a site admin.
+func (c *externalServices) CreateWithOptions(ctx context.Context, externalService *types.ExternalService, opt ExternalServiceCreateOptions) error {
+	kind, err := normalizeKind(externalService.Kind)
+	if err != nil {
 		return err
 	}
+	externalService.Kind = kind
+
+	if _, err := validateConfig(externalService.Kind, externalService.Config, configValidationOptions{RejectUnknownFields: opt.RejectUnknownFields}); err != nil {
+		return err
+	}
+	if opt.ValidateCredentials {
+		if err := TestConnection(ctx, externalService.Kind, externalService.Config); err != nil {
+			return err
+		}
+	}
 
+	return dbutil.Transaction(ctx, dbconn.Global, func(tx *sql.Tx) error {
+		return insertExternalService(ctx, tx, externalService)
+	})
+}
+
+// insertExternalService inserts the (already validated) external service in the transaction and
+// records its config as the first version in its config history. It sets the ID, CreatedAt, and
+// UpdatedAt fields of externalService.
+func insertExternalService(ctx context.Context, tx *sql.Tx, externalService *types.ExternalService) error {
 	externalService.CreatedAt = time.Now()
 	externalService.UpdatedAt = externalService.CreatedAt
 
-	return dbconn.Global.QueryRowContext(
+	if err := tx.QueryRowContext(
 		ctx,
-		"INSERT INTO external_services(kind, display_name, config, created_at, updated_at) VALUES($1, $2, $3, $4, $5) RETURNING id",
-		externalService.Kind, externalService.DisplayName, externalService.Config, externalService.CreatedAt, externalService.UpdatedAt,
-	).Scan(&externalService.ID)
+		"INSERT INTO external_services(kind, display_name, config, url_host, created_at, updated_at) VALUES($1, $2, $3, $4, $5, $6) RETURNING id",
+		externalService.Kind, externalService.DisplayName, externalService.Config, configURLHost(externalService.Config), externalService.CreatedAt, externalService.UpdatedAt,
+	).Scan(&externalService.ID); err != nil {
+		return err
+	}
+	return recordExternalServiceConfigVersion(ctx, tx, externalService.ID, externalService.Config)
 }
 
 // ExternalServiceUpdate contains optional fields to update.
 type ExternalServiceUpdate struct {
 	DisplayName *string
 	Config      *string
+	Kind        *string // normalized to uppercase; it is an error if it is not a known kind
+
+	// ValidateCredentials makes Update check the credentials in the updated config with the code
+	// host (see TestConnection) and fail with a *CredentialValidationError (without updating the
+	// external service) if they are rejected. It has no effect if Config is nil.
+	ValidateCredentials bool
+
+	// RejectUnknownFields makes Update reject an updated config with properties that are not in
+	// the schema of its kind (see configValidationOptions). It has no effect if Config is nil.
+	RejectUnknownFields bool
 }
 
-// Update updates a external service.
+// Update updates a external service. It fails with a readOnlyExternalServiceError if the external
+// service is read-only.
 //
 // 🚨 SECURITY: The caller must ensure that the actor is a site admin.
 func (c *externalServices) Update(ctx context.Context, id int64, update *ExternalServiceUpdate) error {
+	var kind string
+	if update.Kind != nil {
+		var err error
+		if kind, err = normalizeKind(*update.Kind); err != nil {
+			return err
+		}
+	}
 	if update.Config != nil {
-		if err := validateConfig(*update.Config); err != nil {
+		if update.Kind == nil {
+			externalService, err := c.GetByID(ctx, id)
+			if err != nil {
+				return err
+			}
+			kind = externalService.Kind
+		}
+		if _, err := validateConfig(kind, *update.Config, configValidationOptions{RejectUnknownFields: update.RejectUnknownFields}); err != nil {
+			return err
+		}
+		if update.ValidateCredentials {
+			if err := TestConnection(ctx, kind, *update.Config); err != nil {
+				return err
+			}
+		}
+	}
+
+	execUpdate := func(ctx context.Context, tx *sql.Tx, update *sqlf.Query) error {
+		q := sqlf.Sprintf("UPDATE external_services SET %s, updated_at=now() WHERE id=%d AND deleted_at IS NULL", update, id)
+		res, err := tx.ExecContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...)
+		if err != nil {
+			return err
+		}
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return externalServiceNotFoundError{id: id}
+		}
+		return nil
+	}
+	return dbutil.Transaction(ctx, dbconn.Global, func(tx *sql.Tx) error {
+		if err := checkExternalServiceWritable(ctx, tx, id); err != nil {
+			return err
+		}
+		if update.DisplayName != nil {
+			if err := execUpdate(ctx, tx, sqlf.Sprintf("display_name=%s", update.DisplayName)); err != nil {
+				return err
+			}
+		}
+		if update.Kind != nil {
+			if err := execUpdate(ctx, tx, sqlf.Sprintf("kind=%s", kind)); err != nil {
+				return err
+			}
+		}
+		if update.Config != nil {
+			if err := execUpdate(ctx, tx, sqlf.Sprintf("config=%s, url_host=%s", update.Config, configURLHost(*update.Config))); err != nil {
+				return err
+			}
+			if err := recordExternalServiceConfigVersion(ctx, tx, id, *update.Config); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// BulkPatchConfig applies the RFC 7386 JSON merge patch to the config of every (non-deleted)
+// external service that matches the options (ignoring limit and offset), in a single transaction.
+// It returns the number of external services whose config was changed. If any patched config is
+// invalid, or any of the external services is read-only, no external services are updated.
+//
+// 🚨 SECURITY: The caller must ensure that the actor is a site admin.
+func (c *externalServices) BulkPatchConfig(ctx context.Context, opt ExternalServicesListOptions, patch []byte) (updated int, err error) {
+	if err := backfillURLHosts(ctx, opt); err != nil {
+		return 0, err
+	}
+	err = dbutil.Transaction(ctx, dbconn.Global, func(tx *sql.Tx) error {
+		conds := append(opt.sqlConditions(), sqlf.Sprintf("deleted_at IS NULL"))
+		q := sqlf.Sprintf("SELECT id, kind, config, read_only FROM external_services WHERE (%s) ORDER BY id FOR UPDATE", sqlf.Join(conds, ") AND ("))
+		rows, err := tx.QueryContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...)
+		if err != nil {
+			return err
+		}
+		kinds := map[int64]string{}
+		configs := map[int64]string{}
+		var ids []int64
+		for rows.Next() {
+			var id int64
+			var kind, config string
+			var readOnly bool
+			if err := rows.Scan(&id, &kind, &config, &readOnly); err != nil {
+				rows.Close()
+				return err
+			}
+			if readOnly {
+				rows.Close()
+				return readOnlyExternalServiceError{id: id}
+			}
+			kinds[id] = kind
+			configs[id] = config
+			ids = append(ids, id)
+		}
+		if err := rows.Close(); err != nil {
 			return err
 		}
+
+		for _, id := range ids {
+			newConfig, err := mergePatchConfig(configs[id], patch)
+			if err != nil {
+				return fmt.Errorf("patching config of external service %d: %s", id, err)
+			}
+			if newConfig == configs[id] {
+				continue
+			}
+			if _, err := validateConfig(kinds[id], newConfig, configValidationOptions{}); err != nil {
+				return fmt.Errorf("patched config of external service %d is invalid: %s", id, err)
+			}
+			q := sqlf.Sprintf("UPDATE external_services SET config=%s, url_host=%s, updated_at=now() WHERE id=%d", newConfig, configURLHost(newConfig), id)
+			if _, err := tx.ExecContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...); err != nil {
+				return err
+			}
+			if err := recordExternalServiceConfigVersion(ctx, tx, id, newConfig); err != nil {
+				return err
+			}
+			updated++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return updated, nil
+}
+
+// BulkRename adds the prefix and the suffix to the display name of every (non-deleted) external
+// service that matches the options (ignoring limit and offset), in a single transaction, and returns
+// the number of external services renamed. If any of them is read-only, none are renamed. Display
+// names need not be unique, so the new display names may be the same as other external services'.
+//
+// 🚨 SECURITY: The caller must ensure that the actor is a site admin.
+func (*externalServices) BulkRename(ctx context.Context, opt ExternalServicesListOptions, prefix, suffix string) (renamed int, err error) {
+	if prefix == "" && suffix == "" {
+		return 0, errors.New("a prefix or a suffix is required to rename external services")
+	}
+	if err := backfillURLHosts(ctx, opt); err != nil {
+		return 0, err
+	}
+	err = dbutil.Transaction(ctx, dbconn.Global, func(tx *sql.Tx) error {
+		conds := sqlf.Join(append(opt.sqlConditions(), sqlf.Sprintf("deleted_at IS NULL")), ") AND (")
+
+		q := sqlf.Sprintf("SELECT id FROM external_services WHERE (%s) AND read_only ORDER BY id LIMIT 1", conds)
+		var readOnlyID int64
+		err := tx.QueryRowContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...).Scan(&readOnlyID)
+		if err == nil {
+			return readOnlyExternalServiceError{id: readOnlyID}
+		} else if err != sql.ErrNoRows {
+			return err
+		}
+
+		q = sqlf.Sprintf("UPDATE external_services SET display_name=%s || display_name || %s, updated_at=now() WHERE (%s)", prefix, suffix, conds)
+		res, err := tx.ExecContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...)
+		if err != nil {
+			return err
+		}
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		renamed = int(affected)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return renamed, nil
+}
+
+// SetDisabledByKind disables (or enables) all non-deleted external services of the kind, except
+// read-only ones (which only the automation that manages them may change). It returns the number of
+// external services whose disabled flag changed.
+//
+// 🚨 SECURITY: The caller must ensure that the actor is a site admin.
+func (*externalServices) SetDisabledByKind(ctx context.Context, kind string, disabled bool) (int, error) {
+	q := sqlf.Sprintf("UPDATE external_services SET disabled=%s, updated_at=now() WHERE kind=%s AND disabled<>%s AND NOT read_only AND deleted_at IS NULL", disabled, kind, disabled)
+	res, err := dbconn.Global.ExecContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...)
+	if err != nil {
+		return 0, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(affected), nil
+}
+
+type externalServiceNotFoundError struct {
+	id          int64
+	displayName string
+}
+
+func (e externalServiceNotFoundError) Error() string {
+	if e.displayName != "" {
+		return fmt.Sprintf("external service not found: display name %q", e.displayName)
+	}
+	return fmt.Sprintf("external service not found: %v", e.id)
+}
+
+func (e externalServiceNotFoundError) NotFound() bool {
+	return true
+}
+
+// Delete deletes an external service (see DeleteWithReason).
+//
+// 🚨 SECURITY: The caller must ensure that the actor is a site admin.
+func (c *externalServices) Delete(ctx context.Context, id int64) error {
+	return c.DeleteWithReason(ctx, id, "")
+}
+
+// DeleteWithReason deletes an external service, recording the (optional) reason for its deletion
+// on the external service and in its audit log. It fails with a readOnlyExternalServiceError if the
+// external service is read-only.
+//
+// 🚨 SECURITY: The caller must ensure that the actor is a site admin.
+func (*externalServices) DeleteWithReason(ctx context.Context, id int64, reason string) error {
+	var dbReason *string
+	if reason != "" {
+		dbReason = &reason
+	}
+	return dbutil.Transaction(ctx, dbconn.Global, func(tx *sql.Tx) error {
+		if err := checkExternalServiceWritable(ctx, tx, id); err != nil {
+			return err
+		}
+		res, err := tx.ExecContext(ctx, "UPDATE external_services SET deleted_at=now(), deletion_reason=$1 WHERE id=$2 AND deleted_at IS NULL", dbReason, id)
+		if err != nil {
+			return err
+		}
+		nrows, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if nrows == 0 {
+			return externalServiceNotFoundError{id: id}
+		}
+		return recordExternalServiceAuditEvent(ctx, tx, id, ExternalServiceAuditActionDelete, reason)
+	})
+}
+
+// SetNamespace changes the namespace of an external service, recording the change in its audit
+// log. A nil namespaceUserID makes the external service site-wide; otherwise it is owned by the
+// user with that ID. It fails with a readOnlyExternalServiceError if the external service is
+// read-only.
+//
+// 🚨 SECURITY: The caller must ensure that the actor is a site admin.
+func (*externalServices) SetNamespace(ctx context.Context, id int64, namespaceUserID *int32) error {
+	return dbutil.Transaction(ctx, dbconn.Global, func(tx *sql.Tx) error {
+		if err := checkExternalServiceWritable(ctx, tx, id); err != nil {
+			return err
+		}
+
+		var old *int32
+		err := tx.QueryRowContext(ctx, "SELECT namespace_user_id FROM external_services WHERE id=$1 AND deleted_at IS NULL FOR UPDATE", id).Scan(&old)
+		if err == sql.ErrNoRows {
+			return externalServiceNotFoundError{id: id}
+		} else if err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, "UPDATE external_services SET namespace_user_id=$1, updated_at=now() WHERE id=$2", namespaceUserID, id); err != nil {
+			return err
+		}
+		details := fmt.Sprintf("from %s to %s", describeNamespace(old), describeNamespace(namespaceUserID))
+		return recordExternalServiceAuditEvent(ctx, tx, id, ExternalServiceAuditActionTransfer, details)
+	})
+}
+
+// describeNamespace describes an external service namespace for the audit log.
+func describeNamespace(namespaceUserID *int32) string {
+	if namespaceUserID == nil {
+		return "site"
+	}
+	return fmt.Sprintf("user %d", *namespaceUserID)
+}
+
+// GetByID returns the external service for id. Soft-deleted external services are not returned
+// (see GetByIDIncludingDeleted).
+//
+// 🚨 SECURITY: The caller must ensure that the actor is a site admin.
+func (c *externalServices) GetByID(ctx context.Context, id int64) (*types.ExternalService, error) {
+	return c.getByID(ctx, id, false)
+}
+
+// GetByIDIncludingDeleted is like GetByID, except that it also returns soft-deleted external
+// services, so that a deleted external service's config and history can be inspected.
+//
+// 🚨 SECURITY: The caller must ensure that the actor is a site admin.
+func (c *externalServices) GetByIDIncludingDeleted(ctx context.Context, id int64) (*types.ExternalService, error) {
+	return c.getByID(ctx, id, true)
+}
+
+func (c *externalServices) getByID(ctx context.Context, id int64, includeDeleted bool) (*types.ExternalService, error) {
+	conds := []*sqlf.Query{sqlf.Sprintf("id=%d", id)}
+	if !includeDeleted {
+		conds = append(conds, sqlf.Sprintf("deleted_at IS NULL"))
+	}
+	return c.getOne(ctx, conds, externalServiceNotFoundError{id: id})
+}
+
+// GetByDisplayName returns the external service with the display name. Display names need not be
+// unique, so if several (non-deleted) external services have the display name, the most recently
+// created one is returned.
+//
+// 🚨 SECURITY: The caller must ensure that the actor is a site admin.
+func (c *externalServices) GetByDisplayName(ctx context.Context, displayName string) (*types.ExternalService, error) {
+	conds := []*sqlf.Query{sqlf.Sprintf("display_name=%s", displayName), sqlf.Sprintf("deleted_at IS NULL")}
+	return c.getOne(ctx, conds, externalServiceNotFoundError{displayName: displayName})
+}
+
+// GetByIDs returns the external services with the IDs, in the same order, in a single query. If any
+// of them doesn't exist (or is soft-deleted), it returns a not-found error for the first such ID.
+//
+// 🚨 SECURITY: The caller must ensure that the actor is a site admin.
+func (c *externalServices) GetByIDs(ctx context.Context, ids ...int64) ([]*types.ExternalService, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	idQueries := make([]*sqlf.Query, len(ids))
+	for i, id := range ids {
+		idQueries[i] = sqlf.Sprintf("%d", id)
+	}
+	conds := []*sqlf.Query{sqlf.Sprintf("id IN (%s)", sqlf.Join(idQueries, ",")), sqlf.Sprintf("deleted_at IS NULL")}
+	externalServices, err := c.list(ctx, conds, ExternalServicesOrderByIDDesc, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[int64]*types.ExternalService, len(externalServices))
+	for _, es := range externalServices {
+		byID[es.ID] = es
+	}
+	results := make([]*types.ExternalService, len(ids))
+	for i, id := range ids {
+		es, ok := byID[id]
+		if !ok {
+			return nil, externalServiceNotFoundError{id: id}
+		}
+		results[i] = es
+	}
+	return results, nil
+}
+
+// getOne returns the first external service that satisfies the conditions, or notFound if there is
+// none. All methods that read a single external service should use it, so that absence is
+// uniformly reported as an externalServiceNotFoundError (which errcode.IsNotFound detects).
+func (c *externalServices) getOne(ctx context.Context, conds []*sqlf.Query, notFound externalServiceNotFoundError) (*types.ExternalService, error) {
+	externalServices, err := c.list(ctx, conds, ExternalServicesOrderByIDDesc, &LimitOffset{Limit: 1})
+	if err != nil {
+		return nil, err
+	}
+	if len(externalServices) == 0 {
+		return nil, notFound
+	}
+	return externalServices[0], nil
+}
+
+// List returns all external services. Only the fields in opt.Fields are populated (by default, all of
+// them): ExternalServiceFieldsWithoutConfig leaves Config empty, and ExternalServiceFieldsBasic
+// populates only ID, Kind, and DisplayName.
+//
+// 🚨 SECURITY: The caller must ensure that the actor is a site admin.
+func (c *externalServices) List(ctx context.Context, opt ExternalServicesListOptions) ([]*types.ExternalService, error) {
+	if Mocks.ExternalServices.List != nil {
+		return Mocks.ExternalServices.List(ctx, opt)
+	}
+	if err := backfillURLHosts(ctx, opt); err != nil {
+		return nil, err
+	}
+	return c.listFields(ctx, opt.sqlConditions(), opt.OrderBy, opt.LimitOffset, opt.Fields)
+}
+
+// ExternalServiceSummary is an external service without its config, for listings that don't need
+// the (possibly large) config.
+type ExternalServiceSummary struct {
+	ID          int64
+	Kind        string
+	DisplayName string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Enabled     bool
+	Health      string
+}
+
+// ListSummary is like List, but it returns summaries of the external services, without reading
+// their configs from the database.
+//
+// 🚨 SECURITY: The caller must ensure that the actor is a site admin.
+func (c *externalServices) ListSummary(ctx context.Context, opt ExternalServicesListOptions) ([]*ExternalServiceSummary, error) {
+	if Mocks.ExternalServices.ListSummary != nil {
+		return Mocks.ExternalServices.ListSummary(ctx, opt)
+	}
+	c.migrateJsonConfigToExternalServices(ctx)
+	if err := backfillURLHosts(ctx, opt); err != nil {
+		return nil, err
+	}
+	q := sqlf.Sprintf(`
+		SELECT id, kind, display_name, created_at, updated_at, NOT disabled, health
+		FROM external_services
+		WHERE (%s)
+		%s
+		%s`,
+		sqlf.Join(opt.sqlConditions(), ") AND ("),
+		opt.OrderBy.sql(),
+		opt.LimitOffset.SQL(),
+	)
+
+	rows, err := dbconn.Global.QueryContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []*ExternalServiceSummary
+	for rows.Next() {
+		var h ExternalServiceSummary
+		if err := rows.Scan(&h.ID, &h.Kind, &h.DisplayName, &h.CreatedAt, &h.UpdatedAt, &h.Enabled, &h.Health); err != nil {
+			return nil, err
+		}
+		results = append(results, &h)
+	}
+	return results, rows.Err()
+}
+
+// ListWithTotal returns the external services that satisfy the options, and the total number that
+// satisfy them (ignoring limit and offset). Unlike calling List and Count separately, the total is
+// computed in the same query, so it is consistent with the returned page even under concurrent
+// writes. (If the page is empty because the offset is beyond the last row, the total is counted
+// with a separate query.)
+//
+// 🚨 SECURITY: The caller must ensure that the actor is a site admin.
+func (c *externalServices) ListWithTotal(ctx context.Context, opt ExternalServicesListOptions) ([]*types.ExternalService, int, error) {
+	c.migrateJsonConfigToExternalServices(ctx)
+	if err := backfillURLHosts(ctx, opt); err != nil {
+		return nil, 0, err
+	}
+	columns, _ := opt.Fields.columns(&types.ExternalService{})
+	q := sqlf.Sprintf(`
+		SELECT %s, COUNT(*) OVER()
+		FROM external_services
+		WHERE (%s)
+		%s
+		%s`,
+		sqlf.Join(columns, ", "),
+		sqlf.Join(opt.sqlConditions(), ") AND ("),
+		opt.OrderBy.sql(),
+		opt.LimitOffset.SQL(),
+	)
+
+	rows, err := dbconn.Global.QueryContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var (
+		results []*types.ExternalService
+		total   int
+	)
+	for rows.Next() {
+		var h types.ExternalService
+		_, dests := opt.Fields.columns(&h)
+		if err := rows.Scan(append(dests, &total)...); err != nil {
+			return nil, 0, err
+		}
+		results = append(results, &h)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
 	}
 
-	execUpdate := func(ctx context.Context, tx *sql.Tx, update *sqlf.Query) error {
-		q := sqlf.Sprintf("UPDATE external_services SET %s, updated_at=now() WHERE id=%d AND deleted_at IS NULL", update, id)
-		res, err := tx.ExecContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...)
-		if err != nil {
-			return err
-		}
-		affected, err := res.RowsAffected()
+	// The window function is only evaluated over rows that exist, so an empty page past the end
+	// doesn't tell us the total.
+	if len(results) == 0 && opt.LimitOffset != nil && opt.LimitOffset.Offset > 0 {
+		total, err = c.Count(ctx, opt)
 		if err != nil {
-			return err
+			return nil, 0, err
 		}
-		if affected == 0 {
-			return externalServiceNotFoundError{id: id}
-		}
-		return nil
 	}
-	return dbutil.Transaction(ctx, dbconn.Global, func(tx *sql.Tx) error {
-		if update.DisplayName != nil {
-			if err := execUpdate(ctx, tx, sqlf.Sprintf("display_name=%s", update.DisplayName)); err != nil {
-				return err
-			}
-		}
-		if update.Config != nil {
-			if err := execUpdate(ctx, tx, sqlf.Sprintf("config=%s", update.Config)); err != nil {
-				return err
-			}
-		}
-		return nil
-	})
+	return results, total, nil
 }
 
-type externalServiceNotFoundError struct {
-	id int64
+// ListRecentlyFailed returns up to limit enabled external services whose most recent sync failed,
+// most recently failed first. The sync error is available in each result's LastSyncError.
+//
+// 🚨 SECURITY: The caller must ensure that the actor is a site admin.
+func (c *externalServices) ListRecentlyFailed(ctx context.Context, limit int) ([]*types.ExternalService, error) {
+	conds := []*sqlf.Query{
+		sqlf.Sprintf("deleted_at IS NULL"),
+		sqlf.Sprintf("NOT disabled"),
+		sqlf.Sprintf("last_sync_error IS NOT NULL"),
+	}
+	return c.list(ctx, conds, ExternalServicesOrderByLastSyncAtDesc, &LimitOffset{Limit: limit})
 }
 
-func (e externalServiceNotFoundError) Error() string {
-	return fmt.Sprintf("external service not found: %v", e.id)
+// ListChangedSince returns the external services that were created, updated, or soft-deleted
+// after since, least recently updated first, so that a caller mirroring external services can poll
+// for only what changed. Soft-deleted external services are included (with DeletedAt set) so that
+// their deletions can be propagated.
+//
+// 🚨 SECURITY: The caller must ensure that the actor is a site admin.
+func (c *externalServices) ListChangedSince(ctx context.Context, since time.Time) ([]*types.ExternalService, error) {
+	conds := []*sqlf.Query{
+		sqlf.Sprintf("(updated_at > %s OR deleted_at > %s)", since, since),
+		excludeMigrationSentinel,
+	}
+	return c.list(ctx, conds, ExternalServicesOrderByUpdatedAtAsc, nil)
 }
 
-func (e externalServiceNotFoundError) NotFound() bool {
-	return true
-}
+// Possible values of an external service's health.
+const (
+	ExternalServiceHealthUnknown = "unknown" // never synced
+	ExternalServiceHealthHealthy = "healthy" // most recent sync succeeded
+	ExternalServiceHealthFailing = "failing" // most recent sync failed
+)
 
-// Delete deletes an external service.
+// RecordSyncResult records the outcome of a sync of the external service. A nil syncErr
+// indicates that the sync succeeded.
 //
-// 🚨 SECURITY: The caller must ensure that the actor is a site admin.
-func (*externalServices) Delete(ctx context.Context, id int64) error {
-	res, err := dbconn.Global.ExecContext(ctx, "UPDATE external_services SET deleted_at=now() WHERE id=$1 AND deleted_at IS NULL", id)
+// It does not bump updated_at, because it does not change the external service's configuration.
+func (*externalServices) RecordSyncResult(ctx context.Context, id int64, syncErr error) error {
+	var (
+		health  = ExternalServiceHealthHealthy
+		errText *string
+	)
+	if syncErr != nil {
+		health = ExternalServiceHealthFailing
+		msg := syncErr.Error()
+		errText = &msg
+	}
+	res, err := dbconn.Global.ExecContext(ctx, "UPDATE external_services SET last_sync_at=now(), last_sync_error=$1, health=$2 WHERE id=$3 AND deleted_at IS NULL", errText, health, id)
 	if err != nil {
 		return err
 	}
@@ -138,39 +937,22 @@ func (*externalServices) Delete(ctx context.Context, id int64) error {
 	return nil
 }
 
-// GetByID returns the external service for id.
-//
-// 🚨 SECURITY: The caller must ensure that the actor is a site admin.
-func (c *externalServices) GetByID(ctx context.Context, id int64) (*types.ExternalService, error) {
-	conds := []*sqlf.Query{sqlf.Sprintf("id=%d", id)}
-	externalServices, err := c.list(ctx, conds, nil)
-	if err != nil {
-		return nil, err
-	}
-	if len(externalServices) == 0 {
-		return nil, fmt.Errorf("external service not found: id=%d", id)
-	}
-	return externalServices[0], nil
-}
-
-// List returns all external services.
-//
-// 🚨 SECURITY: The caller must ensure that the actor is a site admin.
-func (c *externalServices) List(ctx context.Context, opt ExternalServicesListOptions) ([]*types.ExternalService, error) {
-	return c.list(ctx, opt.sqlConditions(), opt.LimitOffset)
-}
-
-// listConfigs decodes the list configs into result.
+// listConfigs decodes the list configs of the site-wide external services of the kind into result.
 //
 // 🚨 SECURITY: The caller must ensure that the actor is a site admin.
 func (c *externalServices) listConfigs(ctx context.Context, kind string, result interface{}) error {
-	services, err := c.List(ctx, ExternalServicesListOptions{Kind: kind})
+	services, err := c.List(ctx, ExternalServicesListOptions{Kind: kind, SiteWideOnly: true})
 	if err != nil {
 		return err
 	}
 	var configs []json.RawMessage
 	for _, service := range services {
-		configs = append(configs, json.RawMessage(service.Config))
+		config, err := ExpandConfigTemplate(service.Config)
+		if err != nil {
+			log15.Error("ignoring external service config with unexpandable variable references", "id", service.ID, "displayName", service.DisplayName, "err", err)
+			continue
+		}
+		configs = append(configs, json.RawMessage(config))
 	}
 	buf, err := json.Marshal(configs)
 	if err != nil {
@@ -183,8 +965,9 @@ func (c *externalServices) listConfigs(ctx context.Context, kind string, result
 //
 // 🚨 SECURITY: The caller must ensure that the actor is a site admin.
 func (c *externalServices) ListGitHubConnections(ctx context.Context) ([]*schema.GitHubConnection, error) {
-	if !conf.ExternalServicesEnabled() {
-		return conf.Get().Github, nil
+	cfg := conf.Get()
+	if !conf.ExternalServicesEnabledIn(cfg) {
+		return cfg.Github, nil
 	}
 
 	var connections []*schema.GitHubConnection
@@ -198,8 +981,9 @@ func (c *externalServices) ListGitHubConnections(ctx context.Context) ([]*schema
 //
 // 🚨 SECURITY: The caller must ensure that the actor is a site admin.
 func (c *externalServices) ListGitLabConnections(ctx context.Context) ([]*schema.GitLabConnection, error) {
-	if !conf.ExternalServicesEnabled() {
-		return conf.Get().Gitlab, nil
+	cfg := conf.Get()
+	if !conf.ExternalServicesEnabledIn(cfg) {
+		return cfg.Gitlab, nil
 	}
 
 	var connections []*schema.GitLabConnection
@@ -213,8 +997,9 @@ func (c *externalServices) ListGitLabConnections(ctx context.Context) ([]*schema
 //
 // 🚨 SECURITY: The caller must ensure that the actor is a site admin.
 func (c *externalServices) ListPhabricatorConnections(ctx context.Context) ([]*schema.PhabricatorConnection, error) {
-	if !conf.ExternalServicesEnabled() {
-		return conf.Get().Phabricator, nil
+	cfg := conf.Get()
+	if !conf.ExternalServicesEnabledIn(cfg) {
+		return cfg.Phabricator, nil
 	}
 
 	var connections []*schema.PhabricatorConnection
@@ -224,9 +1009,31 @@ func (c *externalServices) ListPhabricatorConnections(ctx context.Context) ([]*s
 	return connections, nil
 }
 
-// migrateOnce ensures that the migration is only attempted
-// once per frontend instance (to avoid unnecessary queries).
-var migrateOnce sync.Once
+// migrated records whether the migration has completed (or another frontend instance completed
+// it), so that it is only attempted until then (to avoid unnecessary queries). Unlike a sync.Once,
+// it is not set when the migration fails with a retriable error, so that a failure (such as a
+// database outage at startup) doesn't skip the migration for the rest of the process's lifetime. It
+// is set when the migration fails with an error that isn't retriable, because every later attempt
+// would fail the same way.
+//
+// migrateMu is held during each attempt, but not while waiting to retry one.
+var (
+	migrateMu sync.Mutex
+	migrated  bool
+)
+
+// migrationSentinelKind is the kind of the soft-deleted row with id 0 that
+// migrateJsonConfigToExternalServices inserts to record that the migration has run.
+const migrationSentinelKind = "MIGRATION"
+
+// excludeMigrationSentinel is a condition that excludes the migration sentinel (see
+// migrationSentinelKind). Because the sentinel is soft-deleted, queries need it only if they can
+// match deleted external services.
+var excludeMigrationSentinel = sqlf.Sprintf("id<>0")
+
+// migrationRetryDelays are the delays before each retry of a migration attempt that failed with a
+// retriable error (see isRetriableMigrationError).
+var migrationRetryDelays = []time.Duration{100 * time.Millisecond, 500 * time.Millisecond, 2 * time.Second}
 
 // migrateJsonConfigToExternalServices performs a one time migration to populate
 // the new external_services database table with relavant entries in the site config.
@@ -240,101 +1047,285 @@ func (c *externalServices) migrateJsonConfigToExternalServices(ctx context.Conte
 		return
 	}
 
-	migrateOnce.Do(func() {
-		// Run in a transaction because we are racing with other frontend replicas.
-		err := dbutil.Transaction(ctx, dbconn.Global, func(tx *sql.Tx) error {
-			now := time.Now()
+	for i := 0; ; i++ {
+		done, err := migrateJsonConfigOnce(ctx)
+		if done {
+			return
+		}
+		if i == len(migrationRetryDelays) {
+			log15.Error("migrate transaction failed", "err", err, "attempts", i+1)
+			return
+		}
+		select {
+		case <-time.After(migrationRetryDelays[i]):
+		case <-ctx.Done():
+			log15.Error("migrate transaction failed", "err", err, "attempts", i+1)
+			return
+		}
+	}
+}
+
+// migrateJsonConfigOnce makes an attempt at the migration (unless it has already completed) while
+// holding migrateMu. It reports whether no more attempts should be made, because the migration
+// completed or failed with an error that isn't retriable. Otherwise, it returns the retriable error.
+func migrateJsonConfigOnce(ctx context.Context) (done bool, err error) {
+	migrateMu.Lock()
+	defer migrateMu.Unlock()
+	if migrated {
+		return true, nil
+	}
+	err = migrateJsonConfigAttempt(ctx)
+	switch {
+	case err == nil || isMigrationConflict(err):
+		migrated = true
+		return true, nil
+	case ctx.Err() != nil:
+		// The attempt was canceled (not failed), so a later call should try again.
+		return false, err
+	case !isRetriableMigrationError(err):
+		// Every later attempt would fail the same way, so don't make any.
+		log15.Error("migrate transaction failed permanently", "err", err)
+		migrated = true
+		return true, nil
+	}
+	return false, err
+}
+
+// migrateJsonConfigAttempt makes a single attempt at the migration (see
+// migrateJsonConfigToExternalServices). It is a variable so that tests can stub out the database.
+var migrateJsonConfigAttempt = func(ctx context.Context) error {
+	// Run in a transaction because we are racing with other frontend replicas.
+	return dbutil.Transaction(ctx, dbconn.Global, func(tx *sql.Tx) error {
+		now := time.Now()
+
+		// Attempt to insert a fake config into the DB with id 0.
+		// This will fail if the migration has already run.
+		if _, err := tx.ExecContext(
+			ctx,
+			"INSERT INTO external_services(id, kind, display_name, config, created_at, updated_at, deleted_at) VALUES($1, $2, $3, $4, $5, $6, $7)",
+			0, migrationSentinelKind, "", "{}", now, now, now,
+		); err != nil {
+			return err
+		}
+
+		migrate := func(config interface{}, name string) error {
+			// Marshaling and unmarshaling is a lazy way to get around
+			// Go's lack of covariance for slice types.
+			buf, err := json.Marshal(config)
+			if err != nil {
+				return err
+			}
+			var configs []interface{}
+			if err := json.Unmarshal(buf, &configs); err != nil {
+				return nil
+			}
 
-			// Attempt to insert a fake config into the DB with id 0.
-			// This will fail if the migration has already run.
-			if _, err := tx.ExecContext(
-				ctx,
-				"INSERT INTO external_services(id, kind, display_name, config, created_at, updated_at, deleted_at) VALUES($1, $2, $3, $4, $5, $6, $7)",
-				0, "migration", "", "{}", now, now, now,
-			); err != nil {
+			kind, err := normalizeKind(name)
+			if err != nil {
+				return err
+			}
+			existing, err := existingNormalizedConfigs(ctx, tx, kind)
+			if err != nil {
 				return err
 			}
 
-			migrate := func(config interface{}, name string) error {
-				// Marshaling and unmarshaling is a lazy way to get around
-				// Go's lack of covariance for slice types.
-				buf, err := json.Marshal(config)
+			for i, config := range configs {
+				jsonConfig, err := json.MarshalIndent(config, "", "  ")
 				if err != nil {
 					return err
 				}
-				var configs []interface{}
-				if err := json.Unmarshal(buf, &configs); err != nil {
-					return nil
+
+				// Skip configs that were already migrated (e.g., by an earlier run whose sentinel
+				// row was removed), so that re-running the migration doesn't create duplicates.
+				normalized, err := normalizeConfigForComparison(string(jsonConfig))
+				if err != nil {
+					return err
+				}
+				if existing[normalized] {
+					continue
 				}
+				existing[normalized] = true
 
-				for i, config := range configs {
-					jsonConfig, err := json.MarshalIndent(config, "", "  ")
-					if err != nil {
-						return err
-					}
-
-					kind := strings.ToUpper(name)
-					displayName := fmt.Sprintf("Migrated %s %d", name, i+1)
-					if _, err := tx.ExecContext(
-						ctx,
-						"INSERT INTO external_services(kind, display_name, config, created_at, updated_at) VALUES($1, $2, $3, $4, $5)",
-						kind, displayName, string(jsonConfig), now, now,
-					); err != nil {
-						return err
-					}
+				displayName := fmt.Sprintf("Migrated %s %d", name, i+1)
+				if _, err := tx.ExecContext(
+					ctx,
+					"INSERT INTO external_services(kind, display_name, config, url_host, created_at, updated_at) VALUES($1, $2, $3, $4, $5, $6)",
+					kind, displayName, string(jsonConfig), configURLHost(string(jsonConfig)), now, now,
+				); err != nil {
+					return err
 				}
-				return nil
 			}
+			return nil
+		}
 
-			if err := migrate(conf.Get().AwsCodeCommit, "AWSCodeCommit"); err != nil {
-				return err
-			}
+		if err := migrate(conf.Get().AwsCodeCommit, "AWSCodeCommit"); err != nil {
+			return err
+		}
 
-			if err := migrate(conf.Get().BitbucketServer, "BitbucketServer"); err != nil {
-				return err
-			}
+		if err := migrate(conf.Get().BitbucketServer, "BitbucketServer"); err != nil {
+			return err
+		}
 
-			if err := migrate(conf.Get().Github, "GitHub"); err != nil {
-				return err
-			}
+		if err := migrate(conf.Get().Github, "GitHub"); err != nil {
+			return err
+		}
 
-			if err := migrate(conf.Get().Gitlab, "GitLab"); err != nil {
-				return err
-			}
+		if err := migrate(conf.Get().Gitlab, "GitLab"); err != nil {
+			return err
+		}
 
-			if err := migrate(conf.Get().Gitolite, "Gitolite"); err != nil {
-				return err
-			}
+		if err := migrate(conf.Get().Gitolite, "Gitolite"); err != nil {
+			return err
+		}
 
-			if err := migrate(conf.Get().Phabricator, "Phabricator"); err != nil {
-				return err
-			}
+		if err := migrate(conf.Get().Phabricator, "Phabricator"); err != nil {
+			return err
+		}
 
-			return nil
-		})
+		return nil
+	})
+}
 
-		if err != nil {
-			if pqErr, ok := err.(*pq.Error); ok {
-				if pqErr.Constraint == "external_services_pkey" {
-					// This is expected when multiple frontend attempt to migrate concurrently.
-					// Only one will win.
-					return
-				}
-			}
-			log15.Error("migrate transaction failed", "err", err)
+// existingNormalizedConfigs returns the set of the normalized configs (see
+// normalizeConfigForComparison) of the external services of the kind, including deleted ones (so
+// that the migration doesn't recreate an external service that a site admin deleted). Configs that
+// can't be normalized are ignored.
+func existingNormalizedConfigs(ctx context.Context, tx *sql.Tx, kind string) (map[string]bool, error) {
+	q := sqlf.Sprintf("SELECT config FROM external_services WHERE kind=%s AND %s", kind, excludeMigrationSentinel)
+	rows, err := tx.QueryContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	configs := map[string]bool{}
+	for rows.Next() {
+		var config string
+		if err := rows.Scan(&config); err != nil {
+			return nil, err
 		}
-	})
+		if normalized, err := normalizeConfigForComparison(config); err == nil {
+			configs[normalized] = true
+		}
+	}
+	return configs, rows.Err()
+}
+
+// normalizeConfigForComparison returns the config (which may contain comments and trailing commas)
+// as compact JSON with sorted object keys, so that configs that differ only in formatting are
+// equal.
+func normalizeConfigForComparison(config string) (string, error) {
+	normalized, err := jsonc.Parse(config)
+	if err != nil {
+		return "", err
+	}
+	var v interface{}
+	if err := json.Unmarshal(normalized, &v); err != nil {
+		return "", err
+	}
+	buf, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(buf), nil
+}
+
+// isMigrationConflict reports whether err is from an attempt at the migration while another
+// frontend instance migrated concurrently (or had already migrated). It is expected when multiple
+// frontends attempt to migrate concurrently: only one will win.
+func isMigrationConflict(err error) bool {
+	pqErr, ok := err.(*pq.Error)
+	return ok && pqErr.Constraint == "external_services_pkey"
+}
+
+// isRetriableMigrationError reports whether err is possibly transient, so that retrying the
+// migration may succeed: a lost or refused connection, or a PostgreSQL error in the class of
+// connection exceptions (08), transaction rollbacks such as deadlocks (40), insufficient resources
+// (53), or operator intervention such as a server shutdown (57).
+func isRetriableMigrationError(err error) bool {
+	if err == driver.ErrBadConn {
+		return true
+	}
+	if _, ok := err.(net.Error); ok {
+		return true
+	}
+	if pqErr, ok := err.(*pq.Error); ok {
+		switch pqErr.Code.Class() {
+		case "08", "40", "53", "57":
+			return true
+		}
+	}
+	return false
+}
+
+// VerifyMigrationIntegrity checks that the migration of external services from the site
+// configuration (see migrateJsonConfigToExternalServices) has run and left the table in a
+// consistent state: there is exactly one sentinel row, it has id 0 and is soft-deleted, and no other
+// external service has id 0. It returns a descriptive error otherwise. It is intended as a preflight
+// check for upgrade tooling before the legacy site configuration is removed.
+//
+// 🚨 SECURITY: The caller must ensure that the actor is a site admin.
+func (*externalServices) VerifyMigrationIntegrity(ctx context.Context) error {
+	// Sentinels inserted before kinds were normalized to uppercase have the kind "migration".
+	rows, err := dbconn.Global.QueryContext(ctx, "SELECT id, kind, deleted_at IS NOT NULL FROM external_services WHERE id=0 OR upper(kind)=$1 ORDER BY id", migrationSentinelKind)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var (
+		foundSentinel bool
+		strayIDs      []int64
+	)
+	for rows.Next() {
+		var (
+			id      int64
+			kind    string
+			deleted bool
+		)
+		if err := rows.Scan(&id, &kind, &deleted); err != nil {
+			return err
+		}
+		switch {
+		case id != 0:
+			strayIDs = append(strayIDs, id)
+		case strings.ToUpper(kind) != migrationSentinelKind:
+			return fmt.Errorf("external service with id 0 is not the migration sentinel (its kind is %q)", kind)
+		case !deleted:
+			return errors.New("the external services migration sentinel (id 0) is not soft-deleted")
+		default:
+			foundSentinel = true
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if !foundSentinel {
+		return errors.New("the external services migration has not run (there is no sentinel row with id 0)")
+	}
+	if len(strayIDs) > 0 {
+		return fmt.Errorf("found %d unexpected external services migration sentinel rows (ids %v)", len(strayIDs), strayIDs)
+	}
+	return nil
 }
 
-func (c *externalServices) list(ctx context.Context, conds []*sqlf.Query, limitOffset *LimitOffset) ([]*types.ExternalService, error) {
+func (c *externalServices) list(ctx context.Context, conds []*sqlf.Query, orderBy ExternalServicesOrderBy, limitOffset *LimitOffset) ([]*types.ExternalService, error) {
+	return c.listFields(ctx, conds, orderBy, limitOffset, ExternalServiceFieldsAll)
+}
+
+// listFields is like list, but it only reads the fields of the external services.
+func (c *externalServices) listFields(ctx context.Context, conds []*sqlf.Query, orderBy ExternalServicesOrderBy, limitOffset *LimitOffset, fields ExternalServiceFields) ([]*types.ExternalService, error) {
 	c.migrateJsonConfigToExternalServices(ctx)
+	columns, _ := fields.columns(&types.ExternalService{})
 	q := sqlf.Sprintf(`
-		SELECT id, kind, display_name, config, created_at, updated_at
+		SELECT %s
 		FROM external_services
 		WHERE (%s)
-		ORDER BY id DESC
+		%s
 		%s`,
+		sqlf.Join(columns, ", "),
 		sqlf.Join(conds, ") AND ("),
+		orderBy.sql(),
 		limitOffset.SQL(),
 	)
 
@@ -347,7 +1338,8 @@ func (c *externalServices) list(ctx context.Context, conds []*sqlf.Query, limitO
 	var results []*types.ExternalService
 	for rows.Next() {
 		var h types.ExternalService
-		if err := rows.Scan(&h.ID, &h.Kind, &h.DisplayName, &h.Config, &h.CreatedAt, &h.UpdatedAt); err != nil {
+		_, dests := fields.columns(&h)
+		if err := rows.Scan(dests...); err != nil {
 			return nil, err
 		}
 		results = append(results, &h)
@@ -355,10 +1347,17 @@ func (c *externalServices) list(ctx context.Context, conds []*sqlf.Query, limitO
 	return results, nil
 }
 
-// Count counts all access tokens that satisfy the options (ignoring limit and offset).
+// Count counts all external services that satisfy the options (ignoring limit and offset).
+// Soft-deleted external services are only counted if opt.IncludeDeleted is set.
 //
 // 🚨 SECURITY: The caller must ensure that the actor is a site admin.
 func (c *externalServices) Count(ctx context.Context, opt ExternalServicesListOptions) (int, error) {
+	if Mocks.ExternalServices.Count != nil {
+		return Mocks.ExternalServices.Count(ctx, opt)
+	}
+	if err := backfillURLHosts(ctx, opt); err != nil {
+		return 0, err
+	}
 	q := sqlf.Sprintf("SELECT COUNT(*) FROM external_services WHERE (%s)", sqlf.Join(opt.sqlConditions(), ") AND ("))
 	var count int
 	if err := dbconn.Global.QueryRowContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...).Scan(&count); err != nil {
@@ -366,3 +1365,175 @@ func (c *externalServices) Count(ctx context.Context, opt ExternalServicesListOp
 	}
 	return count, nil
 }
+
+// configURLHost returns the host (lowercased, without the port) of the config's "url" property, or
+// the empty string if the config has no such URL. It is stored in the url_host column whenever a
+// config is written, so that external services can be filtered by host (see
+// ExternalServicesListOptions.URLHost) without parsing every config in the query. (Configs are
+// JSONC, so Postgres can't parse them.)
+func configURLHost(config string) string {
+	var c struct {
+		URL string `json:"url"`
+	}
+	if err := jsonc.Unmarshal(config, &c); err != nil {
+		return ""
+	}
+	u, err := url.Parse(c.URL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Hostname())
+}
+
+// backfillURLHosts sets the url_host column of the external services whose configs were written
+// without it (before the column was added, or by an older version during a rolling update), if the
+// options filter by URL host. It doesn't change the external services' updated_at.
+func backfillURLHosts(ctx context.Context, opt ExternalServicesListOptions) error {
+	if opt.URLHost == "" {
+		return nil
+	}
+	rows, err := dbconn.Global.QueryContext(ctx, "SELECT id, config FROM external_services WHERE url_host IS NULL")
+	if err != nil {
+		return err
+	}
+	configs := map[int64]string{}
+	for rows.Next() {
+		var id int64
+		var config string
+		if err := rows.Scan(&id, &config); err != nil {
+			rows.Close()
+			return err
+		}
+		configs[id] = config
+	}
+	if err := rows.Close(); err != nil {
+		return err
+	}
+
+	for id, config := range configs {
+		if _, err := dbconn.Global.ExecContext(ctx, "UPDATE external_services SET url_host=$1 WHERE id=$2 AND url_host IS NULL", configURLHost(config), id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ExternalServiceConfig is the config of an external service, converted from JSONC to JSON.
+type ExternalServiceConfig struct {
+	ID     int64
+	Kind   string
+	Config json.RawMessage
+}
+
+// ListAllConfigs returns the configs of all (non-deleted) external services of every kind, most
+// recently created first, in a single query. Unlike the List*Connections methods, it includes
+// external services owned by users, and it doesn't expand variable references in the configs (see
+// ExpandConfigTemplate). It is intended for diagnostics, which shouldn't need to know the kinds in
+// advance.
+//
+// 🚨 SECURITY: The caller must ensure that the actor is a site admin.
+func (c *externalServices) ListAllConfigs(ctx context.Context) ([]ExternalServiceConfig, error) {
+	c.migrateJsonConfigToExternalServices(ctx)
+	rows, err := dbconn.Global.QueryContext(ctx, "SELECT id, kind, config FROM external_services WHERE deleted_at IS NULL ORDER BY id DESC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []ExternalServiceConfig
+	for rows.Next() {
+		var (
+			es     ExternalServiceConfig
+			config string
+		)
+		if err := rows.Scan(&es.ID, &es.Kind, &config); err != nil {
+			return nil, err
+		}
+		data, err := jsonc.Parse(config)
+		if err != nil {
+			return nil, fmt.Errorf("parsing config of external service %d: %s", es.ID, err)
+		}
+		es.Config = data
+		results = append(results, es)
+	}
+	return results, rows.Err()
+}
+
+// ExternalServiceKindCount is the number of external services of a kind.
+type ExternalServiceKindCount struct {
+	Kind  string
+	Count int
+}
+
+// ListKinds returns each kind of external service that exists (excluding soft-deleted external
+// services) and the number of external services of that kind, ordered by count descending.
+//
+// 🚨 SECURITY: The caller must ensure that the actor is a site admin.
+func (c *externalServices) ListKinds(ctx context.Context) ([]ExternalServiceKindCount, error) {
+	q := sqlf.Sprintf(`
+		SELECT kind, COUNT(*)
+		FROM external_services
+		WHERE deleted_at IS NULL
+		GROUP BY kind
+		ORDER BY COUNT(*) DESC, kind ASC`)
+	rows, err := dbconn.Global.QueryContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []ExternalServiceKindCount
+	for rows.Next() {
+		var kc ExternalServiceKindCount
+		if err := rows.Scan(&kc.Kind, &kc.Count); err != nil {
+			return nil, err
+		}
+		results = append(results, kc)
+	}
+	return results, rows.Err()
+}
+
+// ExternalServiceKindStatus is the number of external services of a kind with each health (see
+// StatusByKind).
+type ExternalServiceKindStatus struct {
+	Healthy  int // enabled, and the most recent sync succeeded
+	Failing  int // enabled, and the most recent sync failed
+	Unknown  int // enabled, and never synced
+	Disabled int // disabled (regardless of health, because disabled external services aren't synced)
+}
+
+// StatusByKind returns the number of (non-deleted) external services of each kind with each health,
+// in a single query, keyed by kind. Kinds with no external services are omitted.
+//
+// 🚨 SECURITY: The caller must ensure that the actor is a site admin.
+func (*externalServices) StatusByKind(ctx context.Context) (map[string]ExternalServiceKindStatus, error) {
+	q := sqlf.Sprintf(`
+		SELECT kind,
+			COUNT(*) FILTER (WHERE NOT disabled AND health=%s),
+			COUNT(*) FILTER (WHERE NOT disabled AND health=%s),
+			COUNT(*) FILTER (WHERE NOT disabled AND health NOT IN (%s, %s)),
+			COUNT(*) FILTER (WHERE disabled)
+		FROM external_services
+		WHERE deleted_at IS NULL
+		GROUP BY kind`,
+		ExternalServiceHealthHealthy, ExternalServiceHealthFailing, ExternalServiceHealthHealthy, ExternalServiceHealthFailing,
+	)
+	rows, err := dbconn.Global.QueryContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	statuses := map[string]ExternalServiceKindStatus{}
+	for rows.Next() {
+		var (
+			kind   string
+			status ExternalServiceKindStatus
+		)
+		if err := rows.Scan(&kind, &status.Healthy, &status.Failing, &status.Unknown, &status.Disabled); err != nil {
+			return nil, err
+		}
+		statuses[kind] = status
+	}
+	return statuses, rows.Err()
+}
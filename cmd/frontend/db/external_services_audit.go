@@ -0,0 +1,63 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/actor"
+	"github.com/sourcegraph/sourcegraph/pkg/db/dbconn"
+)
+
+// Actions recorded in the external service audit log.
+const (
+	ExternalServiceAuditActionDelete   = "delete"
+	ExternalServiceAuditActionUndelete = "undelete"
+	ExternalServiceAuditActionTransfer = "transfer" // the namespace (owner) changed
+)
+
+// recordExternalServiceAuditEvent adds an entry to the audit log of the external service with the
+// given ID, attributing it to the actor in ctx.
+//
+// The provided dbh is used as the DB handle to execute the query, so that the audit event can be
+// recorded in the same transaction as the change it describes.
+func recordExternalServiceAuditEvent(ctx context.Context, dbh interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}, externalServiceID int64, action, details string) error {
+	var actorUserID *int32
+	if a := actor.FromContext(ctx); a.IsAuthenticated() {
+		actorUserID = &a.UID
+	}
+	_, err := dbh.ExecContext(
+		ctx,
+		"INSERT INTO external_service_audit_log(external_service_id, actor_user_id, action, details) VALUES($1, $2, $3, $4)",
+		externalServiceID, actorUserID, action, details,
+	)
+	return err
+}
+
+// ListAuditLog returns the audit log of the external service with the given ID, oldest first. It
+// includes the audit log of deleted external services.
+//
+// 🚨 SECURITY: The caller must ensure that the actor is a site admin.
+func (*externalServices) ListAuditLog(ctx context.Context, externalServiceID int64) ([]*types.ExternalServiceAuditEvent, error) {
+	rows, err := dbconn.Global.QueryContext(
+		ctx,
+		"SELECT id, external_service_id, actor_user_id, action, details, created_at FROM external_service_audit_log WHERE external_service_id=$1 ORDER BY id ASC",
+		externalServiceID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []*types.ExternalServiceAuditEvent
+	for rows.Next() {
+		var e types.ExternalServiceAuditEvent
+		if err := rows.Scan(&e.ID, &e.ExternalServiceID, &e.ActorUserID, &e.Action, &e.Details, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		events = append(events, &e)
+	}
+	return events, rows.Err()
+}
@@ -0,0 +1,123 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/pkg/conf"
+	"github.com/sourcegraph/sourcegraph/pkg/extsvc/github"
+	"github.com/sourcegraph/sourcegraph/pkg/extsvc/gitlab"
+	"github.com/sourcegraph/sourcegraph/pkg/jsonc"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+// CredentialValidationError is returned when the code host of an external service rejects the
+// credentials in its config (or can't be reached to check them).
+type CredentialValidationError struct {
+	Kind       string // the external service kind
+	StatusCode int    // the HTTP status code of the code host's response (0 if there was no response)
+	Response   string // the code host's response (or the error that prevented a response)
+
+	// Timeout is whether the code host didn't respond in time (see testConnectionTimeout), in
+	// which case the credentials were not checked. The host may be slow or unreachable.
+	Timeout bool
+}
+
+func (e *CredentialValidationError) Error() string {
+	if e.Timeout {
+		return fmt.Sprintf("unable to validate %s credentials: the code host did not respond in time: %s", e.Kind, e.Response)
+	}
+	if e.StatusCode == 0 {
+		return fmt.Sprintf("unable to validate %s credentials: %s", e.Kind, e.Response)
+	}
+	return fmt.Sprintf("%s credentials are invalid (HTTP %d): %s", e.Kind, e.StatusCode, e.Response)
+}
+
+// TestConnection makes an authenticated request to the code host of an external service (of the
+// given kind) with the credentials in its config. It returns a *CredentialValidationError if the
+// request fails, or if the config can't be used to make it (e.g., because it references an
+// undefined secret or has an invalid URL). Configs of kinds whose credentials can't be checked (currently all kinds other
+// than GITHUB and GITLAB) always pass.
+//
+// Secret references (${NAME}) in config are expanded before the request is made. Custom TLS
+// certificates in config are not used.
+//
+// The request is canceled after the kind's timeout (see testConnectionTimeout) or when ctx is done,
+// whichever is first. If it is canceled because of either deadline, the *CredentialValidationError
+// has Timeout set.
+//
+// It is a variable so that tests can mock it.
+var TestConnection = testConnection
+
+// defaultTestConnectionTimeout is how long TestConnection waits for a code host to respond, unless
+// the externalServices.testConnectionTimeoutSeconds site configuration property sets a timeout for
+// the kind.
+const defaultTestConnectionTimeout = 10 * time.Second
+
+// testConnectionTimeout returns how long TestConnection waits for the code host of an external
+// service of the kind to respond.
+func testConnectionTimeout(kind string) time.Duration {
+	if seconds := conf.Get().ExternalServicesTestConnectionTimeoutSeconds[kind]; seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	return defaultTestConnectionTimeout
+}
+
+// connectionError returns the error for a failed request to the code host of an external service.
+func connectionError(ctx context.Context, kind string, statusCode int, err error) *CredentialValidationError {
+	e := &CredentialValidationError{Kind: kind, StatusCode: statusCode, Response: err.Error()}
+	if ctx.Err() == context.DeadlineExceeded {
+		e.Timeout = true
+	} else if t, ok := err.(interface{ Timeout() bool }); ok && t.Timeout() {
+		e.Timeout = true
+	}
+	return e
+}
+
+// canTestConnection reports whether TestConnection checks the credentials of external services of the
+// kind (instead of always passing).
+func canTestConnection(kind string) bool {
+	return kind == "GITHUB" || kind == "GITLAB"
+}
+
+func testConnection(ctx context.Context, kind, config string) error {
+	config, err := ExpandConfigTemplate(config)
+	if err != nil {
+		return &CredentialValidationError{Kind: kind, Response: err.Error()}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, testConnectionTimeout(kind))
+	defer cancel()
+
+	switch kind {
+	case "GITHUB":
+		var c schema.GitHubConnection
+		if err := jsonc.Unmarshal(config, &c); err != nil {
+			return &CredentialValidationError{Kind: kind, Response: err.Error()}
+		}
+		baseURL, err := url.Parse(c.Url)
+		if err != nil {
+			return &CredentialValidationError{Kind: kind, Response: err.Error()}
+		}
+		apiURL, _ := github.APIRoot(baseURL)
+		if _, err := github.NewClient(apiURL, c.Token, nil).GetAuthenticatedUser(ctx); err != nil {
+			return connectionError(ctx, kind, github.HTTPErrorCode(err), err)
+		}
+
+	case "GITLAB":
+		var c schema.GitLabConnection
+		if err := jsonc.Unmarshal(config, &c); err != nil {
+			return &CredentialValidationError{Kind: kind, Response: err.Error()}
+		}
+		baseURL, err := url.Parse(c.Url)
+		if err != nil {
+			return &CredentialValidationError{Kind: kind, Response: err.Error()}
+		}
+		if _, err := gitlab.NewClient(baseURL, c.Token, "", nil).GetUser(ctx, ""); err != nil {
+			return connectionError(ctx, kind, gitlab.HTTPErrorCode(err), err)
+		}
+	}
+	return nil
+}
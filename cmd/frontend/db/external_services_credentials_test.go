@@ -0,0 +1,225 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/conf"
+	"github.com/sourcegraph/sourcegraph/pkg/db/dbconn"
+	"github.com/sourcegraph/sourcegraph/pkg/db/dbtesting"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+func TestExternalServices_ValidateCredentials(t *testing.T) {
+	ctx := dbtesting.TestContext(t)
+
+	orig := TestConnection
+	defer func() { TestConnection = orig }()
+	TestConnection = func(ctx context.Context, kind, config string) error {
+		if config == `{"token": "expired"}` {
+			return &CredentialValidationError{Kind: kind, StatusCode: http.StatusUnauthorized, Response: "Bad credentials"}
+		}
+		return nil
+	}
+
+	expired := &types.ExternalService{Kind: "GITHUB", DisplayName: "GitHub", Config: `{"token": "expired"}`}
+
+	// Credentials are only checked when requested.
+	if err := ExternalServices.Create(ctx, expired); err != nil {
+		t.Fatal(err)
+	}
+
+	err := ExternalServices.CreateWithOptions(ctx, &types.ExternalService{Kind: "GITHUB", DisplayName: "GitHub", Config: `{"token": "expired"}`}, ExternalServiceCreateOptions{ValidateCredentials: true})
+	if e, ok := err.(*CredentialValidationError); !ok || e.StatusCode != http.StatusUnauthorized {
+		t.Errorf("got error %v, want *CredentialValidationError with status 401", err)
+	}
+	if count, err := ExternalServices.Count(ctx, ExternalServicesListOptions{}); err != nil {
+		t.Fatal(err)
+	} else if count != 1 {
+		t.Errorf("got %d external services, want 1 (the one created without validation)", count)
+	}
+
+	valid := `{"token": "valid"}`
+	if err := ExternalServices.Update(ctx, expired.ID, &ExternalServiceUpdate{Config: &valid, ValidateCredentials: true}); err != nil {
+		t.Fatal(err)
+	}
+	invalid := `{"token": "expired"}`
+	err = ExternalServices.Update(ctx, expired.ID, &ExternalServiceUpdate{Config: &invalid, ValidateCredentials: true})
+	if _, ok := err.(*CredentialValidationError); !ok {
+		t.Errorf("got error %v, want *CredentialValidationError", err)
+	}
+	if es, err := ExternalServices.GetByID(ctx, expired.ID); err != nil {
+		t.Fatal(err)
+	} else if es.Config != valid {
+		t.Errorf("got config %q, want %q (unchanged by the rejected update)", es.Config, valid)
+	}
+}
+
+func TestTestConnection_Timeout(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "bearer slow" {
+			select {
+			case <-release:
+			case <-r.Context().Done():
+			}
+			return
+		}
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"message": "Bad credentials"}`))
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	err := testConnection(ctx, "GITHUB", `{"url": "`+srv.URL+`", "token": "slow"}`)
+	if e, ok := err.(*CredentialValidationError); !ok || !e.Timeout {
+		t.Errorf("slow host: got error %v, want *CredentialValidationError with Timeout", err)
+	}
+
+	err = testConnection(context.Background(), "GITHUB", `{"url": "`+srv.URL+`", "token": "expired"}`)
+	if e, ok := err.(*CredentialValidationError); !ok || e.Timeout || e.StatusCode != http.StatusUnauthorized {
+		t.Errorf("bad credentials: got error %v, want *CredentialValidationError with status 401 and no Timeout", err)
+	}
+}
+
+func TestTestConnection_InvalidConfig(t *testing.T) {
+	orig := ConfigSecrets
+	defer func() { ConfigSecrets = orig }()
+	ConfigSecrets = func(name string) (string, bool) { return "", false }
+
+	tests := map[string]struct {
+		kind, config string
+	}{
+		"undefined secret": {kind: "GITHUB", config: `{"url": "https://github.com", "token": "${SRC_EXTSVC_SECRET_MISSING}"}`},
+		"invalid config":   {kind: "GITHUB", config: `{"url": 1}`},
+		"invalid URL":      {kind: "GITLAB", config: `{"url": "://gitlab.example.com", "token": "t"}`},
+	}
+	for name, test := range tests {
+		err := testConnection(context.Background(), test.kind, test.config)
+		if e, ok := err.(*CredentialValidationError); !ok || e.Kind != test.kind || e.StatusCode != 0 || e.Timeout || e.Response == "" {
+			t.Errorf("%s: got error %v, want *CredentialValidationError with no status", name, err)
+		}
+	}
+}
+
+func TestTestConnectionTimeout(t *testing.T) {
+	conf.Mock(&schema.SiteConfiguration{ExternalServicesTestConnectionTimeoutSeconds: map[string]int{"GITLAB": 3}})
+	defer conf.Mock(nil)
+
+	if got, want := testConnectionTimeout("GITLAB"), 3*time.Second; got != want {
+		t.Errorf("GITLAB: got %s, want %s", got, want)
+	}
+	if got, want := testConnectionTimeout("GITHUB"), defaultTestConnectionTimeout; got != want {
+		t.Errorf("GITHUB: got %s, want %s", got, want)
+	}
+}
+
+func TestExternalServices_RevalidateAll(t *testing.T) {
+	ctx := dbtesting.TestContext(t)
+
+	var (
+		mu                sync.Mutex
+		active, maxActive int
+	)
+	orig := TestConnection
+	defer func() { TestConnection = orig }()
+	TestConnection = func(ctx context.Context, kind, config string) error {
+		mu.Lock()
+		active++
+		if active > maxActive {
+			maxActive = active
+		}
+		mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		mu.Lock()
+		active--
+		mu.Unlock()
+
+		if config == `{"token": "expired"}` {
+			return &CredentialValidationError{Kind: kind, StatusCode: http.StatusUnauthorized, Response: "Bad credentials"}
+		}
+		return nil
+	}
+
+	create := func(kind, displayName, config string, disabled bool) *types.ExternalService {
+		t.Helper()
+		es := &types.ExternalService{Kind: kind, DisplayName: displayName, Config: config}
+		if err := ExternalServices.Create(ctx, es); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := dbconn.Global.ExecContext(ctx, "UPDATE external_services SET disabled=$1 WHERE id=$2", disabled, es.ID); err != nil {
+			t.Fatal(err)
+		}
+		return es
+	}
+	expired := create("GITHUB", "expired", `{"token": "expired"}`, false)
+	disabled := create("GITHUB", "disabled", `{"token": "expired"}`, true)
+	var valid []*types.ExternalService
+	for i := 0; i < 2*revalidateConcurrency; i++ {
+		valid = append(valid, create("GITHUB", fmt.Sprintf("valid %d", i), `{"token": "valid"}`, false))
+	}
+	// TestConnection can't check Phabricator connections, so they must not be reported as healthy.
+	unchecked := create("PHABRICATOR", "unchecked", "{}", false)
+	if err := ExternalServices.RecordSyncResult(ctx, unchecked.ID, errors.New("sync failed")); err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := ExternalServices.RevalidateAll(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := 1 + len(valid); len(results) != want {
+		t.Fatalf("got %d results, want %d", len(results), want)
+	}
+	for _, h := range results {
+		if h.ID == disabled.ID || h.ID == unchecked.ID {
+			t.Errorf("got result for disabled or unchecked external service %d", h.ID)
+		}
+		wantHealth := ExternalServiceHealthHealthy
+		if h.ID == expired.ID {
+			wantHealth = ExternalServiceHealthFailing
+		}
+		if h.Health != wantHealth {
+			t.Errorf("external service %d: got health %q, want %q", h.ID, h.Health, wantHealth)
+		}
+	}
+	if maxActive > revalidateConcurrency {
+		t.Errorf("got %d concurrent checks, want at most %d", maxActive, revalidateConcurrency)
+	}
+
+	// The outcomes are recorded, without setting last_sync_at.
+	es, err := ExternalServices.GetByID(ctx, expired.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if es.Health != ExternalServiceHealthFailing || es.LastSyncError == nil || *es.LastSyncError == "" {
+		t.Errorf("got health %q and last sync error %v, want failing with an error", es.Health, es.LastSyncError)
+	}
+	if es.LastSyncAt != nil {
+		t.Errorf("got last sync at %v, want nil", es.LastSyncAt)
+	}
+	if es, err := ExternalServices.GetByID(ctx, valid[0].ID); err != nil {
+		t.Fatal(err)
+	} else if es.Health != ExternalServiceHealthHealthy || es.LastSyncError != nil {
+		t.Errorf("got health %q and last sync error %v, want healthy", es.Health, es.LastSyncError)
+	}
+	if es, err := ExternalServices.GetByID(ctx, disabled.ID); err != nil {
+		t.Fatal(err)
+	} else if es.Health != ExternalServiceHealthUnknown {
+		t.Errorf("disabled: got health %q, want %q", es.Health, ExternalServiceHealthUnknown)
+	}
+	if es, err := ExternalServices.GetByID(ctx, unchecked.ID); err != nil {
+		t.Fatal(err)
+	} else if es.Health != ExternalServiceHealthFailing || es.LastSyncError == nil || *es.LastSyncError != "sync failed" {
+		t.Errorf("unchecked: got health %q and last sync error %v, want them unchanged", es.Health, es.LastSyncError)
+	}
+}
@@ -0,0 +1,110 @@
+package db
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+// kindSchemaDefinitions are the names of the definitions (in the site configuration schema) of the
+// config of each kind of external service.
+var kindSchemaDefinitions = map[string]string{
+	"AWSCODECOMMIT":   "AWSCodeCommitConnection",
+	"BITBUCKETSERVER": "BitbucketServerConnection",
+	"GITHUB":          "GitHubConnection",
+	"GITLAB":          "GitLabConnection",
+	"GITOLITE":        "GitoliteConnection",
+	"PHABRICATOR":     "PhabricatorConnection",
+}
+
+// defaultConfigSkeleton is the default config for kinds without a schema definition.
+const defaultConfigSkeleton = "{\n}\n"
+
+type configSchemaProperty struct {
+	Description string
+	Type        interface{} // a string or a list of strings
+	Default     interface{}
+	Examples    []interface{}
+}
+
+// DefaultConfig returns a JSONC config for a new external service of the kind, to start editing from.
+// It has the properties that the kind's schema requires, each with a comment describing it and with
+// its default value (or, if it has none, its first example value or a placeholder such as "<token>").
+// It returns an UnknownKindError if the kind is unknown.
+func (*externalServices) DefaultConfig(kind string) (string, error) {
+	kind, err := normalizeKind(kind)
+	if err != nil {
+		return "", err
+	}
+	definition, ok := kindSchemaDefinitions[kind]
+	if !ok {
+		return defaultConfigSkeleton, nil
+	}
+
+	var siteSchema struct {
+		Definitions map[string]struct {
+			Required   []string
+			Properties map[string]configSchemaProperty
+		}
+	}
+	if err := json.Unmarshal([]byte(schema.SiteSchemaJSON), &siteSchema); err != nil {
+		return "", err
+	}
+	def, ok := siteSchema.Definitions[definition]
+	if !ok {
+		return "", fmt.Errorf("site configuration schema has no definition %q", definition)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("{\n")
+	for i, name := range def.Required {
+		property := def.Properties[name]
+		if i > 0 {
+			buf.WriteString("\n")
+		}
+		if property.Description != "" {
+			fmt.Fprintf(&buf, "  // %s\n", strings.SplitN(property.Description, "\n", 2)[0])
+		}
+		// Don't escape the angle brackets of placeholders.
+		var value bytes.Buffer
+		enc := json.NewEncoder(&value)
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(defaultConfigValue(name, property)); err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&buf, "  %q: %s", name, bytes.TrimSuffix(value.Bytes(), []byte("\n")))
+		if i < len(def.Required)-1 {
+			buf.WriteString(",")
+		}
+		buf.WriteString("\n")
+	}
+	buf.WriteString("}\n")
+	return buf.String(), nil
+}
+
+// defaultConfigValue returns the default value of the property, its first example value if it has no
+// default, or a placeholder of the property's type if it has neither.
+func defaultConfigValue(name string, property configSchemaProperty) interface{} {
+	if property.Default != nil {
+		return property.Default
+	}
+	if len(property.Examples) > 0 {
+		return property.Examples[0]
+	}
+	typ, _ := property.Type.(string)
+	switch typ {
+	case "array":
+		return []interface{}{}
+	case "boolean":
+		return false
+	case "integer", "number":
+		return 0
+	case "object":
+		return map[string]interface{}{}
+	default:
+		return "<" + name + ">"
+	}
+}
@@ -0,0 +1,51 @@
+package db
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/pkg/jsonc"
+)
+
+func TestExternalServices_DefaultConfig(t *testing.T) {
+	got, err := ExternalServices.DefaultConfig("github")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{
+  // URL of a GitHub instance, such as https://github.com or https://github-enterprise.example.com.
+  "url": "https://github.com",
+
+  // A GitHub personal access token with repo and org scope.
+  "token": "<token>"
+}
+`
+	if got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+
+	// Every kind has a default config that is valid JSONC.
+	for _, kind := range externalServiceKinds {
+		config, err := ExternalServices.DefaultConfig(kind)
+		if err != nil {
+			t.Errorf("%s: %s", kind, err)
+			continue
+		}
+		if _, err := jsonc.ParseValue(config); err != nil {
+			t.Errorf("%s: default config is not valid JSONC: %s\n%s", kind, err, config)
+		}
+	}
+
+	// Properties without a default get their first example value.
+	if got, err := ExternalServices.DefaultConfig("BITBUCKETSERVER"); err != nil {
+		t.Fatal(err)
+	} else if !strings.Contains(got, `"url": "https://bitbucket.example.com"`) {
+		t.Errorf("got %s, want the example url", got)
+	}
+
+	if _, err := ExternalServices.DefaultConfig("UNKNOWN"); err == nil {
+		t.Fatal("unknown kind: got nil error, want error")
+	} else if _, ok := err.(UnknownKindError); !ok {
+		t.Errorf("unknown kind: got error %v, want UnknownKindError", err)
+	}
+}
@@ -0,0 +1,81 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+)
+
+// ExportedExternalService is an external service in an export (see Export), with the fields that
+// are needed to recreate it (e.g., with Import on another instance).
+type ExportedExternalService struct {
+	Kind        string `json:"kind"`
+	DisplayName string `json:"displayName"`
+	Config      string `json:"config"`
+}
+
+func toExportedExternalService(es *types.ExternalService) ExportedExternalService {
+	return ExportedExternalService{Kind: es.Kind, DisplayName: es.DisplayName, Config: es.Config}
+}
+
+// exportPageSize is the number of external services that ExportStream reads at a time.
+var exportPageSize = 500
+
+// Export returns a JSON array of all non-deleted external services (as ExportedExternalService
+// values), least recently created first. Configs are exported as-is, including their secrets.
+//
+// The whole export is built in memory. Use ExportStream for instances with many external services.
+//
+// 🚨 SECURITY: The caller must ensure that the actor is a site admin.
+func (c *externalServices) Export(ctx context.Context) ([]byte, error) {
+	services, err := c.List(ctx, ExternalServicesListOptions{OrderBy: ExternalServicesOrderByIDAsc})
+	if err != nil {
+		return nil, err
+	}
+	exported := make([]ExportedExternalService, len(services))
+	for i, es := range services {
+		exported[i] = toExportedExternalService(es)
+	}
+	return json.Marshal(exported)
+}
+
+// ExportStream writes the same JSON array as Export to w, reading exportPageSize external services
+// at a time (and writing each as it is read), so that memory use is bounded regardless of the
+// number of external services. The output is byte-identical to Export's, unless external services
+// are created or deleted while it runs (it doesn't read a consistent snapshot).
+//
+// 🚨 SECURITY: The caller must ensure that the actor is a site admin.
+func (c *externalServices) ExportStream(ctx context.Context, w io.Writer) error {
+	if _, err := io.WriteString(w, "["); err != nil {
+		return err
+	}
+	opt := ExternalServicesListOptions{OrderBy: ExternalServicesOrderByIDAsc, LimitOffset: &LimitOffset{Limit: exportPageSize}}
+	first := true
+	for {
+		services, err := c.List(ctx, opt)
+		if err != nil {
+			return err
+		}
+		for _, es := range services {
+			b, err := json.Marshal(toExportedExternalService(es))
+			if err != nil {
+				return err
+			}
+			if !first {
+				b = append([]byte(","), b...)
+			}
+			first = false
+			if _, err := w.Write(b); err != nil {
+				return err
+			}
+		}
+		if len(services) < exportPageSize {
+			break
+		}
+		opt.AfterID = services[len(services)-1].ID
+	}
+	_, err := io.WriteString(w, "]")
+	return err
+}
@@ -0,0 +1,64 @@
+package db
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/db/dbtesting"
+)
+
+func TestExternalServices_Export(t *testing.T) {
+	ctx := dbtesting.TestContext(t)
+	defer func(orig int) { exportPageSize = orig }(exportPageSize)
+
+	check := func(want []ExportedExternalService) {
+		t.Helper()
+		exported, err := ExternalServices.Export(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []ExportedExternalService
+		if err := json.Unmarshal(exported, &got); err != nil {
+			t.Fatal(err)
+		}
+		if len(want) == 0 {
+			want = []ExportedExternalService{}
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got %+v, want %+v", got, want)
+		}
+
+		// ExportStream produces the same output regardless of how many pages it reads.
+		for _, pageSize := range []int{1, 2, 3, 500} {
+			exportPageSize = pageSize
+			var buf bytes.Buffer
+			if err := ExternalServices.ExportStream(ctx, &buf); err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(buf.Bytes(), exported) {
+				t.Errorf("page size %d: got stream %q, want %q", pageSize, buf.Bytes(), exported)
+			}
+		}
+	}
+
+	check(nil)
+
+	var want []ExportedExternalService
+	for _, displayName := range []string{"a", "b", "c", "deleted", "<d&e>"} {
+		es := &types.ExternalService{Kind: "GITHUB", DisplayName: displayName, Config: `{"url": "https://github.com"}`}
+		if err := ExternalServices.Create(ctx, es); err != nil {
+			t.Fatal(err)
+		}
+		if displayName == "deleted" {
+			if err := ExternalServices.Delete(ctx, es.ID); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		want = append(want, ExportedExternalService{Kind: es.Kind, DisplayName: es.DisplayName, Config: es.Config})
+	}
+	check(want)
+}
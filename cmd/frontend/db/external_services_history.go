@@ -0,0 +1,134 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/actor"
+	"github.com/sourcegraph/sourcegraph/pkg/db/dbconn"
+)
+
+// recordExternalServiceConfigVersion adds config as the newest version in the config history of
+// the external service with the given ID, attributing it to the actor in ctx. Secrets are redacted
+// from the recorded config (see redactConfig), so that the history doesn't store a second copy of
+// them.
+//
+// The provided dbh is used as the DB handle to execute the query, so that the version can be
+// recorded in the same transaction as the change that created it.
+func recordExternalServiceConfigVersion(ctx context.Context, dbh interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}, externalServiceID int64, config string) error {
+	config, err := redactConfig(config)
+	if err != nil {
+		return err
+	}
+	var actorUserID *int32
+	if a := actor.FromContext(ctx); a.IsAuthenticated() {
+		actorUserID = &a.UID
+	}
+	_, err = dbh.ExecContext(
+		ctx,
+		"INSERT INTO external_service_configs_history(external_service_id, config, actor_user_id) VALUES($1, $2, $3)",
+		externalServiceID, config, actorUserID,
+	)
+	return err
+}
+
+// RedactConfigHistory redacts the secrets (see redactConfig) from the versions in the config
+// history that were recorded without redaction: the first version of each external service, which
+// was copied from its config when the history was created, and versions recorded by older versions
+// of the frontend (e.g., during a rolling update). It is run at startup; versions that are already
+// redacted are unchanged.
+func (*externalServices) RedactConfigHistory(ctx context.Context) error {
+	rows, err := dbconn.Global.QueryContext(ctx, "SELECT id, config FROM external_service_configs_history")
+	if err != nil {
+		return err
+	}
+	configs := map[int64]string{}
+	for rows.Next() {
+		var id int64
+		var config string
+		if err := rows.Scan(&id, &config); err != nil {
+			rows.Close()
+			return err
+		}
+		redacted, err := redactConfig(config)
+		if err != nil {
+			rows.Close()
+			return fmt.Errorf("redacting config version %d: %s", id, err)
+		}
+		if redacted != config {
+			configs[id] = redacted
+		}
+	}
+	if err := rows.Close(); err != nil {
+		return err
+	}
+
+	for id, config := range configs {
+		if _, err := dbconn.Global.ExecContext(ctx, "UPDATE external_service_configs_history SET config=$1 WHERE id=$2", config, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetConfigHistory returns up to limit of the most recent versions of the config of the external
+// service with the given ID, newest (i.e., current) first. Secrets are redacted from the configs,
+// including versions that RedactConfigHistory hasn't redacted yet.
+//
+// 🚨 SECURITY: The caller must ensure that the actor is a site admin.
+func (*externalServices) GetConfigHistory(ctx context.Context, externalServiceID int64, limit int) ([]*types.ExternalServiceConfigVersion, error) {
+	rows, err := dbconn.Global.QueryContext(
+		ctx,
+		"SELECT id, external_service_id, config, actor_user_id, created_at FROM external_service_configs_history WHERE external_service_id=$1 ORDER BY id DESC LIMIT $2",
+		externalServiceID, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var versions []*types.ExternalServiceConfigVersion
+	for rows.Next() {
+		var v types.ExternalServiceConfigVersion
+		if err := rows.Scan(&v.ID, &v.ExternalServiceID, &v.Config, &v.ActorUserID, &v.CreatedAt); err != nil {
+			return nil, err
+		}
+		if v.Config, err = redactConfig(v.Config); err != nil {
+			return nil, err
+		}
+		versions = append(versions, &v)
+	}
+	return versions, rows.Err()
+}
+
+// RevertConfig sets the config of the external service with the given ID to the config of a
+// previous version (which is validated first, as with Update). The revert is itself recorded as a
+// new version. The secrets that were redacted from the previous version are taken from the current
+// config.
+//
+// 🚨 SECURITY: The caller must ensure that the actor is a site admin.
+func (c *externalServices) RevertConfig(ctx context.Context, externalServiceID, versionID int64) error {
+	var config string
+	err := dbconn.Global.QueryRowContext(
+		ctx,
+		"SELECT config FROM external_service_configs_history WHERE id=$1 AND external_service_id=$2",
+		versionID, externalServiceID,
+	).Scan(&config)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("config version %d of external service %d not found", versionID, externalServiceID)
+	} else if err != nil {
+		return err
+	}
+	current, err := c.GetByID(ctx, externalServiceID)
+	if err != nil {
+		return err
+	}
+	if config, err = unredactConfig(config, current.Config); err != nil {
+		return err
+	}
+	return c.Update(ctx, externalServiceID, &ExternalServiceUpdate{Config: &config})
+}
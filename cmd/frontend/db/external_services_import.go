@@ -0,0 +1,342 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/keegancsmith/sqlf"
+	"github.com/sergi/go-diff/diffmatchpatch"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/db/dbconn"
+	"github.com/sourcegraph/sourcegraph/pkg/db/dbutil"
+)
+
+// ImportMode is how Import handles imported external services that have the same display name as
+// an existing external service.
+type ImportMode int
+
+const (
+	// ImportModeInsert creates a new external service for each imported external service, even if
+	// one with the same display name already exists. It is the default.
+	ImportModeInsert ImportMode = iota
+
+	// ImportModeUpsert updates the kind and config of the existing external service with the same
+	// display name in place (preserving its ID and config history), and creates new external
+	// services only for display names that don't exist yet. A soft-deleted external service with
+	// the same display name is undeleted and updated (if there is no non-deleted one), but only if
+	// it could still be restored with Undelete (see ExternalServiceRestoreDeadline); otherwise, a new
+	// external service is created. It is an error if the existing external service is read-only (see
+	// Upsert).
+	ImportModeUpsert
+)
+
+// ImportResult describes what Import did with an imported external service.
+type ImportResult struct {
+	DisplayName string
+	ID          int64 // the ID of the created or updated external service
+	Created     bool  // whether a new external service was created (false if an existing one was updated)
+}
+
+// Import creates (or, depending on mode, updates) the external services, such as those in a backup
+// of another instance, in a single transaction. The kind and config of every external service are
+// validated (as with Create) before any changes are made. It returns a result for each external
+// service, in the same order.
+//
+// 🚨 SECURITY: The caller must ensure that the actor is a site admin.
+func (c *externalServices) Import(ctx context.Context, externalServices []*types.ExternalService, mode ImportMode) ([]ImportResult, error) {
+	for _, es := range externalServices {
+		kind, err := normalizeKind(es.Kind)
+		if err != nil {
+			return nil, err
+		}
+		es.Kind = kind
+		if _, err := validateConfig(es.Kind, es.Config, configValidationOptions{}); err != nil {
+			return nil, fmt.Errorf("invalid config for external service %q: %s", es.DisplayName, err)
+		}
+	}
+
+	results := make([]ImportResult, len(externalServices))
+	err := dbutil.Transaction(ctx, dbconn.Global, func(tx *sql.Tx) error {
+		for i, es := range externalServices {
+			results[i].DisplayName = es.DisplayName
+			if mode == ImportModeUpsert {
+				updated, err := upsertExternalServiceByDisplayName(ctx, tx, es, false)
+				if err != nil {
+					return err
+				}
+				if updated {
+					results[i].ID = es.ID
+					continue
+				}
+			}
+			if err := insertExternalService(ctx, tx, es); err != nil {
+				return err
+			}
+			results[i].ID = es.ID
+			results[i].Created = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// ImportData imports the external services in data, a JSON array of ExportedExternalService values
+// (such as the output of Export on another instance), with Import.
+//
+// 🚨 SECURITY: The caller must ensure that the actor is a site admin.
+func (c *externalServices) ImportData(ctx context.Context, data []byte, mode ImportMode) ([]ImportResult, error) {
+	externalServices, err := parseImportData(data)
+	if err != nil {
+		return nil, err
+	}
+	return c.Import(ctx, externalServices, mode)
+}
+
+// parseImportData parses data, a JSON array of ExportedExternalService values, into the external
+// services to import. It is used by both ImportData and ImportDryRun, so that a dry run reads the
+// data exactly as the import does.
+func parseImportData(data []byte) ([]*types.ExternalService, error) {
+	var exported []ExportedExternalService
+	if err := json.Unmarshal(data, &exported); err != nil {
+		return nil, fmt.Errorf("invalid external services import: %s", err)
+	}
+	externalServices := make([]*types.ExternalService, len(exported))
+	for i, e := range exported {
+		externalServices[i] = &types.ExternalService{Kind: e.Kind, DisplayName: e.DisplayName, Config: e.Config}
+	}
+	return externalServices, nil
+}
+
+// upsertExternalServiceByDisplayName updates the kind and config of the existing external service
+// with the same display name as externalService, undeleting it if necessary (see getImportTarget).
+// Non-deleted external services are preferred over soft-deleted ones, and more recently created ones
+// over older ones. It
+// reports whether an external service was updated (false if none has the display name), and sets the
+// ID field of externalService to the updated external service's ID. Unless overwriteReadOnly is set, it
+// fails with a readOnlyExternalServiceError if the existing external service is read-only.
+func upsertExternalServiceByDisplayName(ctx context.Context, tx *sql.Tx, externalService *types.ExternalService, overwriteReadOnly bool) (updated bool, err error) {
+	existing, err := getImportTarget(ctx, tx, externalService.DisplayName, true)
+	if err != nil || existing == nil {
+		return false, err
+	}
+	id, deleted := existing.id, existing.deleted
+	if existing.readOnly && !overwriteReadOnly {
+		return false, readOnlyExternalServiceError{id: id}
+	}
+
+	if _, err := tx.ExecContext(
+		ctx,
+		"UPDATE external_services SET kind=$1, config=$2, url_host=$3, deleted_at=NULL, deletion_reason=NULL, updated_at=now() WHERE id=$4",
+		externalService.Kind, externalService.Config, configURLHost(externalService.Config), id,
+	); err != nil {
+		return false, err
+	}
+	if err := recordExternalServiceConfigVersion(ctx, tx, id, externalService.Config); err != nil {
+		return false, err
+	}
+	if deleted {
+		if err := recordExternalServiceAuditEvent(ctx, tx, id, ExternalServiceAuditActionUndelete, "import"); err != nil {
+			return false, err
+		}
+	}
+	externalService.ID = id
+	return true, nil
+}
+
+// importTarget is the existing external service that an imported external service with the same
+// display name updates (see upsertExternalServiceByDisplayName).
+type importTarget struct {
+	id       int64
+	config   string
+	deleted  bool
+	readOnly bool
+}
+
+// getImportTarget returns the external service that an imported external service with the display
+// name updates in ImportModeUpsert, or nil if there is none. Soft-deleted external services are only
+// returned if they were deleted within the restore window, as Undelete requires. If forUpdate is set,
+// the row is locked.
+func getImportTarget(ctx context.Context, dbh interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}, displayName string, forUpdate bool) (*importTarget, error) {
+	restorableAfter := time.Now().Add(-externalServiceRestoreWindow())
+	q := sqlf.Sprintf("SELECT id, config, deleted_at IS NOT NULL, read_only FROM external_services WHERE display_name=%s AND %s AND (deleted_at IS NULL OR deleted_at > %s) ORDER BY deleted_at IS NOT NULL, id DESC LIMIT 1", displayName, excludeMigrationSentinel, restorableAfter)
+	if forUpdate {
+		q = sqlf.Sprintf("%s FOR UPDATE", q)
+	}
+	var t importTarget
+	err := dbh.QueryRowContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...).Scan(&t.id, &t.config, &t.deleted, &t.readOnly)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// ImportAction is what Import would do with an imported external service (see ImportDryRun). There
+// is no action for deletion, because Import never deletes external services: in ImportModeUpsert,
+// existing external services whose display names aren't in the import are left as they are.
+type ImportAction string
+
+const (
+	// ImportActionCreate is the creation of a new external service.
+	ImportActionCreate ImportAction = "create"
+
+	// ImportActionUpdate is the update of the kind and config of an existing external service.
+	ImportActionUpdate ImportAction = "update"
+
+	// ImportActionUndelete is the update of a soft-deleted external service, which is undeleted.
+	ImportActionUndelete ImportAction = "undelete"
+)
+
+// ImportPlanItem describes what Import would do with an imported external service.
+type ImportPlanItem struct {
+	DisplayName string
+	Kind        string // the normalized kind (or the kind as imported, if it is invalid)
+	Action      ImportAction
+
+	// ID is the ID of the external service that would be updated or undeleted. It is 0 if an external
+	// service would be created, or if an earlier external service in the same import would create
+	// the one that is updated.
+	ID int64
+
+	// ConfigDiff is a line diff from the existing config to the imported config (with each line
+	// prefixed by "-", "+", or " "), if the existing config would be changed. It is empty if
+	// Action is ImportActionCreate.
+	ConfigDiff string
+
+	// Error is why Import would fail for this external service (such as an invalid config), or
+	// empty if it can be imported.
+	Error string
+}
+
+// ImportPlan describes what Import would do with the external services (see ImportDryRun).
+type ImportPlan struct {
+	Items []ImportPlanItem // one for each imported external service, in the same order
+}
+
+// HasErrors reports whether any external service can't be imported, in which case Import would fail
+// without making any changes.
+func (p *ImportPlan) HasErrors() bool {
+	for _, item := range p.Items {
+		if item.Error != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// ImportDryRun returns what ImportData would do with the external services in data in the mode,
+// without changing anything: which would be created, updated (with the change to their configs), or
+// undeleted. No external services are ever deleted (see ImportAction). The data is parsed as
+// ImportData parses it, and kinds and configs are validated as Import validates them, but all of the
+// validation errors are reported (each in its item of the plan) instead of only the first. It is an
+// error if the data can't be parsed.
+//
+// The plan is only accurate if the external services are not changed before ImportData is called.
+//
+// 🚨 SECURITY: The caller must ensure that the actor is a site admin.
+func (c *externalServices) ImportDryRun(ctx context.Context, data []byte, mode ImportMode) (*ImportPlan, error) {
+	externalServices, err := parseImportData(data)
+	if err != nil {
+		return nil, err
+	}
+	return c.planImport(ctx, externalServices, mode)
+}
+
+// planImport returns what Import would do with the external services in the mode (see
+// ImportDryRun). The externalServices are not modified.
+func (c *externalServices) planImport(ctx context.Context, externalServices []*types.ExternalService, mode ImportMode) (*ImportPlan, error) {
+	plan := &ImportPlan{Items: make([]ImportPlanItem, len(externalServices))}
+
+	// planned is the state of the external services that earlier items of the import would create or
+	// update, keyed by display name, so that later items with the same display name update them (as
+	// they would in Import's transaction).
+	planned := map[string]*importTarget{}
+
+	for i, es := range externalServices {
+		item := &plan.Items[i]
+		item.DisplayName = es.DisplayName
+		item.Kind = es.Kind
+		item.Action = ImportActionCreate
+
+		kind, err := normalizeKind(es.Kind)
+		if err != nil {
+			item.Error = err.Error()
+			continue
+		}
+		item.Kind = kind
+		if _, err := validateConfig(kind, es.Config, configValidationOptions{}); err != nil {
+			item.Error = fmt.Sprintf("invalid config for external service %q: %s", es.DisplayName, err)
+			continue
+		}
+
+		if mode == ImportModeUpsert {
+			existing, ok := planned[es.DisplayName]
+			if !ok {
+				if existing, err = getImportTarget(ctx, dbconn.Global, es.DisplayName, false); err != nil {
+					return nil, err
+				}
+			}
+			if existing != nil {
+				if existing.readOnly {
+					item.Error = readOnlyExternalServiceError{id: existing.id}.Error()
+					continue
+				}
+				item.Action = ImportActionUpdate
+				if existing.deleted {
+					item.Action = ImportActionUndelete
+				}
+				item.ID = existing.id
+				item.ConfigDiff = configDiff(existing.config, es.Config)
+				planned[es.DisplayName] = &importTarget{id: existing.id, config: es.Config}
+				continue
+			}
+		}
+		planned[es.DisplayName] = &importTarget{config: es.Config}
+	}
+	return plan, nil
+}
+
+// configDiff returns a line diff from oldConfig to newConfig, with each line prefixed by "-" (if it
+// is removed), "+" (if it is added), or " " (if it is unchanged). It is empty if the configs are
+// equal.
+func configDiff(oldConfig, newConfig string) string {
+	if oldConfig == newConfig {
+		return ""
+	}
+	dmp := diffmatchpatch.New()
+	oldChars, newChars, lines := dmp.DiffLinesToChars(oldConfig, newConfig)
+	diffs := dmp.DiffCharsToLines(dmp.DiffMain(oldChars, newChars, false), lines)
+
+	var buf bytes.Buffer
+	for _, d := range diffs {
+		prefix := " "
+		switch d.Type {
+		case diffmatchpatch.DiffDelete:
+			prefix = "-"
+		case diffmatchpatch.DiffInsert:
+			prefix = "+"
+		}
+		for _, line := range strings.SplitAfter(d.Text, "\n") {
+			if line == "" {
+				continue
+			}
+			buf.WriteString(prefix)
+			buf.WriteString(line)
+			if !strings.HasSuffix(line, "\n") {
+				buf.WriteString("\n")
+			}
+		}
+	}
+	return buf.String()
+}
@@ -0,0 +1,144 @@
+package db
+
+import (
+	"fmt"
+	"regexp/syntax"
+	"sort"
+	"strings"
+)
+
+// configQueryListProperties are the config properties (of any external service kind) whose values
+// are lists of queries that select the repositories to sync.
+var configQueryListProperties = []string{"repositoryQuery", "projectQuery"}
+
+// configRegexpProperties are the config properties (of any external service kind) whose values are
+// regular expressions matched against repository names.
+var configRegexpProperties = []string{"blacklist"}
+
+// lintConfig returns warnings about parts of a (decoded) external service config that are valid
+// but can never match any repository, such as empty queries or regular expressions that are
+// anchored so that they can't match anything. The warnings are advisory: they don't prevent the
+// config from being saved.
+func lintConfig(config map[string]interface{}) (warnings []string) {
+	for _, property := range configQueryListProperties {
+		values, ok := config[property].([]interface{})
+		if !ok {
+			continue
+		}
+		for i, v := range values {
+			if s, ok := v.(string); ok && strings.TrimSpace(s) == "" {
+				warnings = append(warnings, fmt.Sprintf("%s[%d] is empty and never matches any repositories", property, i))
+			}
+		}
+	}
+
+	for _, property := range configRegexpProperties {
+		pattern, ok := config[property].(string)
+		if !ok || pattern == "" {
+			continue
+		}
+		re, err := syntax.Parse(pattern, syntax.Perl)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("%s is not a valid regular expression: %s", property, err))
+			continue
+		}
+		if neverMatches(re.Simplify()) {
+			warnings = append(warnings, fmt.Sprintf("%s %q is anchored so that it never matches any repositories", property, pattern))
+		}
+	}
+
+	sort.Strings(warnings)
+	return warnings
+}
+
+// neverMatches reports whether re is structurally unable to match any string, because it requires
+// text before the beginning of the text (e.g., "a^") or after the end of the text (e.g., "$a").
+func neverMatches(re *syntax.Regexp) bool {
+	switch re.Op {
+	case syntax.OpNoMatch:
+		return true
+	case syntax.OpConcat:
+		for i, sub := range re.Sub {
+			if neverMatches(sub) {
+				return true
+			}
+			if requiresAnchor(sub, syntax.OpBeginText) {
+				for _, before := range re.Sub[:i] {
+					if consumesText(before) {
+						return true
+					}
+				}
+			}
+			if requiresAnchor(sub, syntax.OpEndText) {
+				for _, after := range re.Sub[i+1:] {
+					if consumesText(after) {
+						return true
+					}
+				}
+			}
+		}
+	case syntax.OpAlternate:
+		for _, sub := range re.Sub {
+			if !neverMatches(sub) {
+				return false
+			}
+		}
+		return true
+	case syntax.OpCapture, syntax.OpPlus:
+		return neverMatches(re.Sub[0])
+	}
+	return false
+}
+
+// requiresAnchor reports whether every match of re includes the anchor (syntax.OpBeginText or
+// syntax.OpEndText).
+func requiresAnchor(re *syntax.Regexp, anchor syntax.Op) bool {
+	switch re.Op {
+	case anchor:
+		return true
+	case syntax.OpCapture, syntax.OpPlus:
+		return requiresAnchor(re.Sub[0], anchor)
+	case syntax.OpConcat:
+		for _, sub := range re.Sub {
+			if requiresAnchor(sub, anchor) {
+				return true
+			}
+		}
+	case syntax.OpAlternate:
+		for _, sub := range re.Sub {
+			if !requiresAnchor(sub, anchor) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// consumesText reports whether every match of re consumes at least one character.
+func consumesText(re *syntax.Regexp) bool {
+	switch re.Op {
+	case syntax.OpLiteral:
+		return len(re.Rune) > 0
+	case syntax.OpCharClass, syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		return true
+	case syntax.OpCapture, syntax.OpPlus:
+		return consumesText(re.Sub[0])
+	case syntax.OpRepeat:
+		return re.Min > 0 && consumesText(re.Sub[0])
+	case syntax.OpConcat:
+		for _, sub := range re.Sub {
+			if consumesText(sub) {
+				return true
+			}
+		}
+	case syntax.OpAlternate:
+		for _, sub := range re.Sub {
+			if !consumesText(sub) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
@@ -0,0 +1,66 @@
+package db
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLintConfig(t *testing.T) {
+	tests := map[string]struct {
+		config map[string]interface{}
+		want   []string
+	}{
+		"no warnings": {
+			config: map[string]interface{}{"repositoryQuery": []interface{}{"affiliated", "org:sourcegraph"}, "blacklist": "^foo/bar$"},
+		},
+		"empty query": {
+			config: map[string]interface{}{"repositoryQuery": []interface{}{"affiliated", " "}},
+			want:   []string{"repositoryQuery[1] is empty and never matches any repositories"},
+		},
+		"empty project query": {
+			config: map[string]interface{}{"projectQuery": []interface{}{""}},
+			want:   []string{"projectQuery[0] is empty and never matches any repositories"},
+		},
+		"invalid regexp": {
+			config: map[string]interface{}{"blacklist": "foo("},
+			want:   []string{"blacklist is not a valid regular expression: error parsing regexp: missing closing ): `foo(`"},
+		},
+		"text before ^": {
+			config: map[string]interface{}{"blacklist": "foo^bar"},
+			want:   []string{`blacklist "foo^bar" is anchored so that it never matches any repositories`},
+		},
+		"text after $": {
+			config: map[string]interface{}{"blacklist": "(foo$)bar"},
+			want:   []string{`blacklist "(foo$)bar" is anchored so that it never matches any repositories`},
+		},
+		"optional text before ^": {
+			config: map[string]interface{}{"blacklist": "a*^foo"},
+		},
+		"one alternative matches": {
+			config: map[string]interface{}{"blacklist": "a^|foo"},
+		},
+		"all alternatives never match": {
+			config: map[string]interface{}{"blacklist": "a^|$b"},
+			want:   []string{`blacklist "a^|$b" is anchored so that it never matches any repositories`},
+		},
+	}
+	for label, test := range tests {
+		if got := lintConfig(test.config); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %q, want %q", label, got, test.want)
+		}
+	}
+}
+
+func TestValidateConfig_Lint(t *testing.T) {
+	warnings, err := validateConfig("GITHUB", `{
+  // Comments are allowed.
+  "token": "t",
+  "repositoryQuery": ["affiliated", ""],
+}`, configValidationOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"repositoryQuery[1] is empty and never matches any repositories"}; !reflect.DeepEqual(warnings, want) {
+		t.Errorf("got warnings %q, want %q", warnings, want)
+	}
+}
@@ -0,0 +1,14 @@
+package db
+
+import (
+	"context"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+)
+
+type MockExternalServices struct {
+	List  func(ctx context.Context, opt ExternalServicesListOptions) ([]*types.ExternalService, error)
+	Count func(ctx context.Context, opt ExternalServicesListOptions) (int, error)
+
+	ListSummary func(ctx context.Context, opt ExternalServicesListOptions) ([]*ExternalServiceSummary, error)
+}
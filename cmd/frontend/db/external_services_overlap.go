@@ -0,0 +1,117 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/sourcegraph/sourcegraph/pkg/jsonc"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+// HostOverlap is a host that more than one external service syncs repositories from (see
+// FindOverlappingHosts).
+type HostOverlap struct {
+	Host               string
+	ExternalServiceIDs []int64 // most recently created first
+}
+
+// externalServiceHosts extract the host that an external service of each kind syncs repositories
+// from, from the config field:
+//
+//   - AWSCODECOMMIT: "region" (the host is git-codecommit.<region>.amazonaws.com)
+//   - BITBUCKETSERVER, GITHUB, GITLAB: "url"
+//   - GITOLITE: "host" (e.g., git@gitolite.example.com)
+//
+// PHABRICATOR external services are not included, because they don't sync repositories.
+var externalServiceHosts = map[string]func(config string) (string, error){
+	"AWSCODECOMMIT": func(config string) (string, error) {
+		var c schema.AWSCodeCommitConnection
+		if err := jsonc.Unmarshal(config, &c); err != nil || c.Region == "" {
+			return "", err
+		}
+		return "git-codecommit." + c.Region + ".amazonaws.com", nil
+	},
+	"BITBUCKETSERVER": func(config string) (string, error) {
+		var c schema.BitbucketServerConnection
+		if err := jsonc.Unmarshal(config, &c); err != nil {
+			return "", err
+		}
+		return hostOfURL(c.Url)
+	},
+	"GITHUB": func(config string) (string, error) {
+		var c schema.GitHubConnection
+		if err := jsonc.Unmarshal(config, &c); err != nil {
+			return "", err
+		}
+		return hostOfURL(c.Url)
+	},
+	"GITLAB": func(config string) (string, error) {
+		var c schema.GitLabConnection
+		if err := jsonc.Unmarshal(config, &c); err != nil {
+			return "", err
+		}
+		return hostOfURL(c.Url)
+	},
+	"GITOLITE": func(config string) (string, error) {
+		var c schema.GitoliteConnection
+		if err := jsonc.Unmarshal(config, &c); err != nil {
+			return "", err
+		}
+		return hostOfURL(c.Host)
+	},
+}
+
+// hostOfURL returns the (lowercase) host name of the URL, which may also be an SCP-style Git URL
+// (e.g., git@example.com or git@example.com:repo). It returns the empty string if rawurl is empty.
+func hostOfURL(rawurl string) (string, error) {
+	if rawurl == "" {
+		return "", nil
+	}
+	if !strings.Contains(rawurl, "://") {
+		rawurl = "ssh://" + strings.Replace(rawurl, ":", "/", 1)
+	}
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return "", err
+	}
+	return strings.ToLower(u.Hostname()), nil
+}
+
+// FindOverlappingHosts returns the hosts that more than one non-deleted external service (of any
+// kind) syncs repositories from, ordered by host. Such external services usually sync the same
+// repositories twice. The host of each external service is extracted from its config as described
+// in externalServiceHosts. It doesn't modify anything.
+//
+// 🚨 SECURITY: The caller must ensure that the actor is a site admin.
+func (c *externalServices) FindOverlappingHosts(ctx context.Context) ([]HostOverlap, error) {
+	configs, err := c.ListAllConfigs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byHost := map[string][]int64{}
+	for _, es := range configs {
+		hostOf, ok := externalServiceHosts[strings.ToUpper(es.Kind)]
+		if !ok {
+			continue
+		}
+		host, err := hostOf(string(es.Config))
+		if err != nil {
+			return nil, fmt.Errorf("extracting host of external service %d: %s", es.ID, err)
+		}
+		if host != "" {
+			byHost[host] = append(byHost[host], es.ID)
+		}
+	}
+
+	var overlaps []HostOverlap
+	for host, ids := range byHost {
+		if len(ids) > 1 {
+			overlaps = append(overlaps, HostOverlap{Host: host, ExternalServiceIDs: ids})
+		}
+	}
+	sort.Slice(overlaps, func(i, j int) bool { return overlaps[i].Host < overlaps[j].Host })
+	return overlaps, nil
+}
@@ -0,0 +1,59 @@
+package db
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/db/dbtesting"
+)
+
+func TestHostOfURL(t *testing.T) {
+	tests := map[string]string{
+		"":                                 "",
+		"https://GitHub.example.com":       "github.example.com",
+		"https://github.example.com:8443/": "github.example.com",
+		"git@gitolite.example.com":         "gitolite.example.com",
+		"git@gitolite.example.com:repo":    "gitolite.example.com",
+		"ssh://git@gitolite.example.com":   "gitolite.example.com",
+	}
+	for rawurl, want := range tests {
+		got, err := hostOfURL(rawurl)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("%q: got %q, want %q", rawurl, got, want)
+		}
+	}
+}
+
+func TestExternalServices_FindOverlappingHosts(t *testing.T) {
+	ctx := dbtesting.TestContext(t)
+
+	create := func(kind, config string) int64 {
+		t.Helper()
+		es := &types.ExternalService{Kind: kind, DisplayName: kind, Config: config}
+		if err := ExternalServices.Create(ctx, es); err != nil {
+			t.Fatal(err)
+		}
+		return es.ID
+	}
+	github := create("GITHUB", `{"url": "https://git.example.com"}`)
+	create("GITLAB", `{"url": "https://gitlab.example.com"}`)
+	gitolite := create("GITOLITE", `{"host": "git@git.example.com", "prefix": "git.example.com/"}`)
+	create("PHABRICATOR", `{"url": "https://git.example.com"}`)
+	deleted := create("BITBUCKETSERVER", `{"url": "https://gitlab.example.com"}`)
+	if err := ExternalServices.Delete(ctx, deleted); err != nil {
+		t.Fatal(err)
+	}
+
+	overlaps, err := ExternalServices.FindOverlappingHosts(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []HostOverlap{{Host: "git.example.com", ExternalServiceIDs: []int64{gitolite, github}}}
+	if !reflect.DeepEqual(overlaps, want) {
+		t.Errorf("got %+v, want %+v", overlaps, want)
+	}
+}
@@ -0,0 +1,106 @@
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/sourcegraph/jsonx"
+	"github.com/sourcegraph/sourcegraph/pkg/conf"
+	"github.com/sourcegraph/sourcegraph/pkg/jsonc"
+)
+
+// mergePatchConfig applies an RFC 7386 JSON merge patch to an external service config. The patch is
+// applied as a sequence of edits to the config's text (instead of re-marshaling the whole config),
+// so that comments and formatting in the parts of the config not changed by the patch are
+// preserved.
+func mergePatchConfig(config string, patch []byte) (string, error) {
+	var p interface{}
+	if err := json.Unmarshal(patch, &p); err != nil {
+		return "", fmt.Errorf("invalid merge patch: %s", err)
+	}
+
+	var target interface{}
+	if err := jsonc.Unmarshal(config, &target); err != nil {
+		return "", err
+	}
+
+	patchObj, ok := p.(map[string]interface{})
+	_, targetIsObj := target.(map[string]interface{})
+	if !ok || !targetIsObj {
+		// The patch replaces the whole config.
+		b, err := json.MarshalIndent(mergePatchValue(nil, p), "", "  ")
+		return string(b), err
+	}
+	return mergePatchObject(config, nil, target.(map[string]interface{}), patchObj)
+}
+
+// mergePatchObject applies the patch to the object target, which is at the path (a list of property
+// names) in config.
+func mergePatchObject(config string, path []interface{}, target, patch map[string]interface{}) (string, error) {
+	keys := make([]string, 0, len(patch))
+	for key := range patch {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		keyPath := append(append([]interface{}{}, path...), key)
+		value := patch[key]
+
+		var edits []jsonx.Edit
+		var err error
+		targetValue, exists := target[key]
+		switch valueObj, isObj := value.(map[string]interface{}); {
+		case value == nil:
+			if !exists {
+				continue
+			}
+			edits, _, err = jsonx.ComputePropertyRemoval(config, jsonx.MakePath(keyPath...), conf.FormatOptions)
+		case isObj && isJSONObject(targetValue):
+			config, err = mergePatchObject(config, keyPath, targetValue.(map[string]interface{}), valueObj)
+			if err != nil {
+				return "", err
+			}
+			continue
+		default:
+			edits, _, err = jsonx.ComputePropertyEdit(config, jsonx.MakePath(keyPath...), mergePatchValue(nil, value), nil, conf.FormatOptions)
+		}
+		if err != nil {
+			return "", err
+		}
+		if config, err = jsonx.ApplyEdits(config, edits...); err != nil {
+			return "", err
+		}
+	}
+	return config, nil
+}
+
+// mergePatchValue returns the result of applying the merge patch to the (already decoded) target.
+func mergePatchValue(target, patch interface{}) interface{} {
+	patchObj, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	targetObj, ok := target.(map[string]interface{})
+	if !ok {
+		targetObj = map[string]interface{}{}
+	}
+	result := make(map[string]interface{}, len(targetObj))
+	for key, value := range targetObj {
+		result[key] = value
+	}
+	for key, value := range patchObj {
+		if value == nil {
+			delete(result, key)
+		} else {
+			result[key] = mergePatchValue(result[key], value)
+		}
+	}
+	return result
+}
+
+func isJSONObject(v interface{}) bool {
+	_, ok := v.(map[string]interface{})
+	return ok
+}
@@ -0,0 +1,86 @@
+package db
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/pkg/jsonc"
+)
+
+func TestMergePatchConfig(t *testing.T) {
+	tests := map[string]struct {
+		config string
+		patch  string
+		want   string
+	}{
+		"add property": {
+			config: `{"url": "https://github.com"}`,
+			patch:  `{"token": "t"}`,
+			want:   `{"url": "https://github.com", "token": "t"}`,
+		},
+		"replace array": {
+			config: `{"exclude": [{"name": "a/b"}]}`,
+			patch:  `{"exclude": [{"name": "a/b"}, {"name": "c/d"}]}`,
+			want:   `{"exclude": [{"name": "a/b"}, {"name": "c/d"}]}`,
+		},
+		"remove property": {
+			config: `{"url": "https://github.com", "token": "t"}`,
+			patch:  `{"token": null}`,
+			want:   `{"url": "https://github.com"}`,
+		},
+		"remove missing property": {
+			config: `{"url": "https://github.com"}`,
+			patch:  `{"token": null}`,
+			want:   `{"url": "https://github.com"}`,
+		},
+		"nested object": {
+			config: `{"a": {"b": 1, "c": 2}}`,
+			patch:  `{"a": {"b": null, "d": {"e": null, "f": 3}}}`,
+			want:   `{"a": {"c": 2, "d": {"f": 3}}}`,
+		},
+		"replace non-object with object": {
+			config: `{"a": 1}`,
+			patch:  `{"a": {"b": 2}}`,
+			want:   `{"a": {"b": 2}}`,
+		},
+		"replace whole config": {
+			config: `{"a": 1}`,
+			patch:  `["x"]`,
+			want:   `["x"]`,
+		},
+	}
+	for label, test := range tests {
+		got, err := mergePatchConfig(test.config, []byte(test.patch))
+		if err != nil {
+			t.Errorf("%s: %s", label, err)
+			continue
+		}
+		var gotValue, wantValue interface{}
+		if err := jsonc.Unmarshal(got, &gotValue); err != nil {
+			t.Errorf("%s: patched config %q is invalid: %s", label, got, err)
+			continue
+		}
+		if err := jsonc.Unmarshal(test.want, &wantValue); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(gotValue, wantValue) {
+			t.Errorf("%s: got %s, want %s", label, got, test.want)
+		}
+	}
+}
+
+func TestMergePatchConfig_PreservesComments(t *testing.T) {
+	config := `{
+  // The GitHub instance.
+  "url": "https://github.com",
+  "token": "t"
+}`
+	got, err := mergePatchConfig(config, []byte(`{"token": "u"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(got, "// The GitHub instance.") {
+		t.Errorf("comment was not preserved in patched config %q", got)
+	}
+}
@@ -0,0 +1,72 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/keegancsmith/sqlf"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/db/dbconn"
+	"github.com/sourcegraph/sourcegraph/pkg/db/dbutil"
+)
+
+// ListDeletedBefore returns the external services that were deleted before t, least recently
+// deleted first. It is intended for a maintenance job that permanently removes them (with
+// HardDelete).
+//
+// 🚨 SECURITY: The caller must ensure that the actor is a site admin.
+func (c *externalServices) ListDeletedBefore(ctx context.Context, t time.Time) ([]*types.ExternalService, error) {
+	conds := []*sqlf.Query{
+		sqlf.Sprintf("deleted_at < %s", t),
+		excludeMigrationSentinel,
+	}
+	return c.list(ctx, conds, ExternalServicesOrderByIDAsc, nil)
+}
+
+// CountDeleted returns the number of deleted external services (those that can be restored, see
+// ListRestorable, and those waiting to be purged, see ListDeletedBefore).
+//
+// 🚨 SECURITY: The caller must ensure that the actor is a site admin.
+func (*externalServices) CountDeleted(ctx context.Context) (int, error) {
+	q := sqlf.Sprintf("SELECT COUNT(*) FROM external_services WHERE deleted_at IS NOT NULL AND %s", excludeMigrationSentinel)
+	var count int
+	if err := dbconn.Global.QueryRowContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...).Scan(&count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// HardDelete permanently removes a deleted external service, along with its config history and
+// audit log. It is an error if the external service is not deleted, so that external services are
+// always soft-deleted (with Delete or DeleteWithReason) first and can be restored until they are
+// purged.
+//
+// 🚨 SECURITY: The caller must ensure that the actor is a site admin.
+func (*externalServices) HardDelete(ctx context.Context, id int64) error {
+	return dbutil.Transaction(ctx, dbconn.Global, func(tx *sql.Tx) error {
+		q := sqlf.Sprintf("SELECT deleted_at IS NOT NULL FROM external_services WHERE id=%s AND %s FOR UPDATE", id, excludeMigrationSentinel)
+		var deleted bool
+		err := tx.QueryRowContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...).Scan(&deleted)
+		if err == sql.ErrNoRows {
+			return externalServiceNotFoundError{id: id}
+		} else if err != nil {
+			return err
+		}
+		if !deleted {
+			return fmt.Errorf("external service %d is not deleted", id)
+		}
+
+		for _, q := range []string{
+			"DELETE FROM external_service_configs_history WHERE external_service_id=$1",
+			"DELETE FROM external_service_audit_log WHERE external_service_id=$1",
+			"DELETE FROM external_services WHERE id=$1",
+		} {
+			if _, err := tx.ExecContext(ctx, q, id); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,85 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/db/dbconn"
+	"github.com/sourcegraph/sourcegraph/pkg/db/dbutil"
+)
+
+// readOnlyExternalServiceError is returned when a read-only external service (one that is managed by
+// automation) is updated or deleted.
+type readOnlyExternalServiceError struct {
+	id int64
+}
+
+func (e readOnlyExternalServiceError) Error() string {
+	return fmt.Sprintf("external service %d is read-only because it is managed by automation", e.id)
+}
+
+// checkExternalServiceWritable locks the row of the (non-deleted) external service in the
+// transaction and returns a readOnlyExternalServiceError if it is read-only.
+func checkExternalServiceWritable(ctx context.Context, tx *sql.Tx, id int64) error {
+	var readOnly bool
+	err := tx.QueryRowContext(ctx, "SELECT read_only FROM external_services WHERE id=$1 AND deleted_at IS NULL FOR UPDATE", id).Scan(&readOnly)
+	if err == sql.ErrNoRows {
+		return externalServiceNotFoundError{id: id}
+	} else if err != nil {
+		return err
+	}
+	if readOnly {
+		return readOnlyExternalServiceError{id: id}
+	}
+	return nil
+}
+
+// ExternalServiceUpsertOptions contains options for upserting an external service.
+type ExternalServiceUpsertOptions struct {
+	// ReadOnly makes the created or updated external service read-only, so that it can't be updated
+	// or deleted except with Upsert. If it is false, the external service is made writable.
+	ReadOnly bool
+}
+
+// Upsert updates the kind and config of the existing external service with the same display name as
+// externalService (undeleting it if necessary, as with ImportModeUpsert), or creates it if there is
+// none, and sets its read-only flag (see ExternalServiceUpsertOptions). It reports whether the
+// external service was created, and sets the ID field of externalService.
+//
+// It is intended for automation that manages external services. Unlike Update and Delete, it updates
+// read-only external services, and it is the only way to set the read-only flag, so that it isn't
+// possible to make an external service read-only from the UI.
+//
+// 🚨 SECURITY: The caller must ensure that the actor is a site admin.
+func (*externalServices) Upsert(ctx context.Context, externalService *types.ExternalService, opt ExternalServiceUpsertOptions) (created bool, err error) {
+	kind, err := normalizeKind(externalService.Kind)
+	if err != nil {
+		return false, err
+	}
+	externalService.Kind = kind
+	if _, err := validateConfig(externalService.Kind, externalService.Config, configValidationOptions{}); err != nil {
+		return false, err
+	}
+
+	err = dbutil.Transaction(ctx, dbconn.Global, func(tx *sql.Tx) error {
+		updated, err := upsertExternalServiceByDisplayName(ctx, tx, externalService, true)
+		if err != nil {
+			return err
+		}
+		if !updated {
+			if err := insertExternalService(ctx, tx, externalService); err != nil {
+				return err
+			}
+			created = true
+		}
+		_, err = tx.ExecContext(ctx, "UPDATE external_services SET read_only=$1 WHERE id=$2", opt.ReadOnly, externalService.ID)
+		return err
+	})
+	if err != nil {
+		return false, err
+	}
+	externalService.ReadOnly = opt.ReadOnly
+	return created, nil
+}
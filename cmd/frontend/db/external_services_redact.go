@@ -0,0 +1,89 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sourcegraph/jsonx"
+	"github.com/sourcegraph/sourcegraph/pkg/conf"
+	"github.com/sourcegraph/sourcegraph/pkg/jsonc"
+)
+
+// redactedSecret replaces the values of secret fields in redacted configs.
+const redactedSecret = "REDACTED"
+
+// configSecretFields are the top-level config properties (of any external service kind) whose
+// values are secrets: the "token" of GitHub, GitLab, Bitbucket Server, and Phabricator
+// connections, the "password" of Bitbucket Server connections, and the "secretAccessKey" of AWS
+// CodeCommit connections.
+var configSecretFields = []string{"token", "password", "secretAccessKey"}
+
+// redactConfig returns the config with the values of its secret fields (see configSecretFields)
+// replaced by redactedSecret. Values that are only a reference to a secret that configs can
+// reference (${NAME}, where NAME has ConfigSecretPrefix) aren't secrets themselves, so they are
+// kept. The rest of the config (including comments and formatting)
+// is unchanged, so that versions of a redacted config can still be compared to show which
+// non-secret fields changed.
+func redactConfig(config string) (string, error) {
+	var v map[string]interface{}
+	if err := jsonc.Unmarshal(config, &v); err != nil {
+		// Configs that aren't objects (such as empty configs) have no secret fields.
+		return config, nil
+	}
+	for _, field := range configSecretFields {
+		s, ok := v[field].(string)
+		if !ok || s == redactedSecret || isConfigSecretRef(s) {
+			continue
+		}
+		var err error
+		if config, err = setConfigProperty(config, field, redactedSecret); err != nil {
+			return "", err
+		}
+	}
+	return config, nil
+}
+
+// isConfigSecretRef reports whether s is only a reference (${NAME}) to a variable that configs can
+// reference (see ConfigSecrets). Other values that look like references, such as "${}" or
+// "${DATABASE_PASSWORD}", are never expanded, so they may be literal secrets.
+func isConfigSecretRef(s string) bool {
+	m := configTemplateRefPattern.FindStringSubmatch(s)
+	return m != nil && m[0] == s && configTemplateNamePattern.MatchString(m[1]) && strings.HasPrefix(m[1], ConfigSecretPrefix)
+}
+
+// unredactConfig returns the redacted config with the values of its redacted secret fields taken
+// from current (the current config of the same external service). It returns an error if current
+// doesn't have a value for a redacted secret field.
+func unredactConfig(redacted, current string) (string, error) {
+	var v, currentValues map[string]interface{}
+	if err := jsonc.Unmarshal(redacted, &v); err != nil {
+		return redacted, nil
+	}
+	if err := jsonc.Unmarshal(current, &currentValues); err != nil {
+		currentValues = nil
+	}
+	for _, field := range configSecretFields {
+		if s, _ := v[field].(string); s != redactedSecret {
+			continue
+		}
+		value, ok := currentValues[field].(string)
+		if !ok {
+			return "", fmt.Errorf("the value of %q was redacted from the config history and the current config has no value to use instead", field)
+		}
+		var err error
+		if redacted, err = setConfigProperty(redacted, field, value); err != nil {
+			return "", err
+		}
+	}
+	return redacted, nil
+}
+
+// setConfigProperty sets the value of the top-level property of config, preserving the rest of
+// its text.
+func setConfigProperty(config, property, value string) (string, error) {
+	edits, _, err := jsonx.ComputePropertyEdit(config, jsonx.MakePath(property), value, nil, conf.FormatOptions)
+	if err != nil {
+		return "", err
+	}
+	return jsonx.ApplyEdits(config, edits...)
+}
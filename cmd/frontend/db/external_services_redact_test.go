@@ -0,0 +1,143 @@
+package db
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/db/dbconn"
+	"github.com/sourcegraph/sourcegraph/pkg/db/dbtesting"
+)
+
+func TestRedactConfig(t *testing.T) {
+	tests := map[string]string{
+		`{"url": "https://github.com", "token": "abc"}`:                        `{"url": "https://github.com", "token": "REDACTED"}`,
+		`{"password": "p", /* comment */ "token": "t", "username": "u"}`:       `{"password": "REDACTED", /* comment */ "token": "REDACTED", "username": "u"}`,
+		`{"region": "us-east-1", "accessKeyID": "id", "secretAccessKey": "k"}`: `{"region": "us-east-1", "accessKeyID": "id", "secretAccessKey": "REDACTED"}`,
+		`{"token": "${SRC_EXTSVC_SECRET_GITHUB_TOKEN}"}`:                       `{"token": "${SRC_EXTSVC_SECRET_GITHUB_TOKEN}"}`,
+		`{"token": "${GITHUB_TOKEN}"}`:                                         `{"token": "REDACTED"}`,
+		`{"password": "${DATABASE_PASSWORD}"}`:                                 `{"password": "REDACTED"}`,
+		`{"url": "https://github.com"}`:                                        `{"url": "https://github.com"}`,
+		`{}`:                                                                   `{}`,
+		``:                                                                     ``,
+	}
+	for config, want := range tests {
+		got, err := redactConfig(config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("%q: got %q, want %q", config, got, want)
+		}
+	}
+}
+
+func TestUnredactConfig(t *testing.T) {
+	got, err := unredactConfig(`{"url": "https://old.example.com", "token": "REDACTED"}`, `{"url": "https://new.example.com", "token": "abc"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"url": "https://old.example.com", "token": "abc"}`; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	if _, err := unredactConfig(`{"token": "REDACTED"}`, `{}`); err == nil {
+		t.Error("got nil error when the current config has no value for a redacted secret, want error")
+	}
+}
+
+func TestExternalServices_ConfigHistoryRedactsSecrets(t *testing.T) {
+	ctx := dbtesting.TestContext(t)
+
+	secrets := []string{"s3cr3t-token-1", "s3cr3t-token-2", "s3cr3t-password"}
+	es := &types.ExternalService{Kind: "BITBUCKETSERVER", DisplayName: "Bitbucket Server", Config: `{"url": "https://bitbucket.example.com", "token": "s3cr3t-token-1"}`}
+	if err := ExternalServices.Create(ctx, es); err != nil {
+		t.Fatal(err)
+	}
+	config := `{"url": "https://bitbucket2.example.com", "token": "s3cr3t-token-2", "username": "u", "password": "s3cr3t-password"}`
+	if err := ExternalServices.Update(ctx, es.ID, &ExternalServiceUpdate{Config: &config}); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := dbconn.Global.QueryContext(ctx, "SELECT config FROM external_service_configs_history WHERE external_service_id=$1", es.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var n int
+	for rows.Next() {
+		var config string
+		if err := rows.Scan(&config); err != nil {
+			t.Fatal(err)
+		}
+		n++
+		for _, secret := range secrets {
+			if strings.Contains(config, secret) {
+				t.Errorf("config history row %q contains secret %q", config, secret)
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("got %d config history rows, want 2", n)
+	}
+
+	// Reverting to a redacted version uses the current secrets.
+	versions, err := ExternalServices.GetConfigHistory(ctx, es.ID, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ExternalServices.RevertConfig(ctx, es.ID, versions[1].ID); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := ExternalServices.GetByID(ctx, es.ID); err != nil {
+		t.Fatal(err)
+	} else if want := `{"url": "https://bitbucket.example.com", "token": "s3cr3t-token-2"}`; got.Config != want {
+		t.Errorf("got config %q after revert, want %q", got.Config, want)
+	}
+}
+
+func TestExternalServices_RedactConfigHistory(t *testing.T) {
+	ctx := dbtesting.TestContext(t)
+
+	es := &types.ExternalService{Kind: "GITHUB", DisplayName: "GitHub", Config: `{"url": "https://github.com", "token": "${SRC_EXTSVC_SECRET_GITHUB_TOKEN}"}`}
+	if err := ExternalServices.Create(ctx, es); err != nil {
+		t.Fatal(err)
+	}
+	// Seed a version recorded without redaction, as by the migration that created the history.
+	const secret = "s3cr3t-token"
+	if _, err := dbconn.Global.ExecContext(ctx, "INSERT INTO external_service_configs_history(external_service_id, config) VALUES($1, $2)", es.ID, `{"url": "https://github.com", "token": "`+secret+`"}`); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ExternalServices.RedactConfigHistory(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := dbconn.Global.QueryContext(ctx, "SELECT config FROM external_service_configs_history WHERE external_service_id=$1 ORDER BY id", es.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var configs []string
+	for rows.Next() {
+		var config string
+		if err := rows.Scan(&config); err != nil {
+			t.Fatal(err)
+		}
+		configs = append(configs, config)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		`{"url": "https://github.com", "token": "${SRC_EXTSVC_SECRET_GITHUB_TOKEN}"}`,
+		`{"url": "https://github.com", "token": "REDACTED"}`,
+	}
+	if !reflect.DeepEqual(configs, want) {
+		t.Errorf("got config history %q, want %q", configs, want)
+	}
+}
@@ -0,0 +1,106 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/sourcegraph/sourcegraph/pkg/db/dbconn"
+	"github.com/sourcegraph/sourcegraph/pkg/jsonc"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+// ReferentialIssue is a reference in the config of an external service to something that doesn't
+// exist (see ValidateReferentialIntegrity).
+type ReferentialIssue struct {
+	ExternalServiceID int64
+	Message           string // describes the dangling reference and where it is in the config
+}
+
+// referenceRules are the cross-kind rules that ValidateReferentialIntegrity checks, keyed by the
+// kind of external service whose config holds the references. Each returns a message for each
+// dangling reference in a config of that kind. To check a new kind of reference, add a rule here.
+//
+// The rules are:
+//
+//   - PHABRICATOR: the path of each entry of "repos" must be the name of a repository (i.e., one
+//     that another external service or the repos.list site configuration property provides).
+var referenceRules = map[string]func(ctx context.Context, config string) ([]string, error){
+	"PHABRICATOR": danglingPhabricatorRepos,
+}
+
+// ValidateReferentialIntegrity checks the configs of all non-deleted external services for
+// references to things that don't exist (according to referenceRules) and returns the issues found,
+// ordered by external service (most recently created first). It doesn't modify anything.
+//
+// 🚨 SECURITY: The caller must ensure that the actor is a site admin.
+func (c *externalServices) ValidateReferentialIntegrity(ctx context.Context) ([]ReferentialIssue, error) {
+	services, err := c.List(ctx, ExternalServicesListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	var issues []ReferentialIssue
+	for _, es := range services {
+		rule, ok := referenceRules[strings.ToUpper(es.Kind)]
+		if !ok {
+			continue
+		}
+		messages, err := rule(ctx, es.Config)
+		if err != nil {
+			return nil, fmt.Errorf("checking references of external service %d: %s", es.ID, err)
+		}
+		for _, message := range messages {
+			issues = append(issues, ReferentialIssue{ExternalServiceID: es.ID, Message: message})
+		}
+	}
+	return issues, nil
+}
+
+// danglingPhabricatorRepos returns a message for each entry of the "repos" list of the Phabricator
+// config whose path is not the name of a repository.
+func danglingPhabricatorRepos(ctx context.Context, config string) ([]string, error) {
+	var c schema.PhabricatorConnection
+	if err := jsonc.Unmarshal(config, &c); err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(c.Repos))
+	for _, repo := range c.Repos {
+		if repo != nil {
+			names = append(names, repo.Path)
+		}
+	}
+	existing, err := existingRepoNames(ctx, names)
+	if err != nil {
+		return nil, err
+	}
+
+	var messages []string
+	for i, repo := range c.Repos {
+		if repo != nil && !existing[repo.Path] {
+			messages = append(messages, fmt.Sprintf("repos[%d].path %q is not the name of any repository", i, repo.Path))
+		}
+	}
+	return messages, nil
+}
+
+// existingRepoNames returns the set of names (among names) of repositories that exist.
+func existingRepoNames(ctx context.Context, names []string) (map[string]bool, error) {
+	existing := make(map[string]bool, len(names))
+	if len(names) == 0 {
+		return existing, nil
+	}
+	rows, err := dbconn.Global.QueryContext(ctx, "SELECT name FROM repo WHERE name = ANY($1)", pq.Array(names))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		existing[name] = true
+	}
+	return existing, rows.Err()
+}
@@ -0,0 +1,44 @@
+package db
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/db/dbtesting"
+)
+
+func TestExternalServices_ValidateReferentialIntegrity(t *testing.T) {
+	ctx := dbtesting.TestContext(t)
+
+	if err := Repos.Upsert(ctx, api.InsertRepoOp{Name: "github.com/foo/bar", Enabled: true}); err != nil {
+		t.Fatal(err)
+	}
+	create := func(kind, config string) int64 {
+		t.Helper()
+		es := &types.ExternalService{Kind: kind, DisplayName: kind, Config: config}
+		if err := ExternalServices.Create(ctx, es); err != nil {
+			t.Fatal(err)
+		}
+		return es.ID
+	}
+	create("GITHUB", `{"url": "https://github.com"}`)
+	phabricator := create("PHABRICATOR", `{
+		// The first repository exists, but the second doesn't.
+		"repos": [{"path": "github.com/foo/bar", "callsign": "BAR"}, {"path": "github.com/foo/baz", "callsign": "BAZ"}],
+	}`)
+	deleted := create("PHABRICATOR", `{"repos": [{"path": "github.com/foo/qux", "callsign": "QUX"}]}`)
+	if err := ExternalServices.Delete(ctx, deleted); err != nil {
+		t.Fatal(err)
+	}
+
+	issues, err := ExternalServices.ValidateReferentialIntegrity(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []ReferentialIssue{{ExternalServiceID: phabricator, Message: `repos[1].path "github.com/foo/baz" is not the name of any repository`}}
+	if !reflect.DeepEqual(issues, want) {
+		t.Errorf("got %+v, want %+v", issues, want)
+	}
+}
@@ -0,0 +1,83 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/keegancsmith/sqlf"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/conf"
+	"github.com/sourcegraph/sourcegraph/pkg/db/dbconn"
+	"github.com/sourcegraph/sourcegraph/pkg/db/dbutil"
+)
+
+// externalServiceRestoreWindow is how long after an external service is deleted it can be restored
+// (with Undelete). It is the externalServices.restoreWindowDays site configuration property.
+func externalServiceRestoreWindow() time.Duration {
+	days := conf.Get().ExternalServicesRestoreWindowDays
+	if days <= 0 {
+		days = 30
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// ExternalServiceRestoreDeadline returns the time until which an external service that was deleted
+// at deletedAt can be restored.
+func ExternalServiceRestoreDeadline(deletedAt time.Time) time.Time {
+	return deletedAt.Add(externalServiceRestoreWindow())
+}
+
+type externalServiceRestoreWindowExpiredError struct {
+	id       int64
+	deadline time.Time
+}
+
+func (e externalServiceRestoreWindowExpiredError) Error() string {
+	return fmt.Sprintf("external service %d can no longer be restored (it was restorable until %s)", e.id, e.deadline.Format(time.RFC3339))
+}
+
+func (e externalServiceRestoreWindowExpiredError) BadRequest() bool { return true }
+
+// ListRestorable returns the deleted external services that can still be restored (because they
+// were deleted within the restore window). The time remaining to restore each is given by
+// ExternalServiceRestoreDeadline(*DeletedAt).
+//
+// 🚨 SECURITY: The caller must ensure that the actor is a site admin.
+func (c *externalServices) ListRestorable(ctx context.Context) ([]*types.ExternalService, error) {
+	conds := []*sqlf.Query{
+		sqlf.Sprintf("deleted_at > %s", time.Now().Add(-externalServiceRestoreWindow())),
+		excludeMigrationSentinel,
+	}
+	return c.list(ctx, conds, ExternalServicesOrderByIDDesc, nil)
+}
+
+// Undelete restores a deleted external service, recording it in the external service's audit
+// log. It is an error if the external service is not deleted or if it was deleted before the
+// restore window.
+//
+// 🚨 SECURITY: The caller must ensure that the actor is a site admin.
+func (*externalServices) Undelete(ctx context.Context, id int64) error {
+	return dbutil.Transaction(ctx, dbconn.Global, func(tx *sql.Tx) error {
+		q := sqlf.Sprintf("SELECT deleted_at FROM external_services WHERE id=%s AND %s FOR UPDATE", id, excludeMigrationSentinel)
+		var deletedAt *time.Time
+		err := tx.QueryRowContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...).Scan(&deletedAt)
+		if err == sql.ErrNoRows {
+			return externalServiceNotFoundError{id: id}
+		} else if err != nil {
+			return err
+		}
+		if deletedAt == nil {
+			return fmt.Errorf("external service %d is not deleted", id)
+		}
+		if deadline := ExternalServiceRestoreDeadline(*deletedAt); time.Now().After(deadline) {
+			return externalServiceRestoreWindowExpiredError{id: id, deadline: deadline}
+		}
+
+		if _, err := tx.ExecContext(ctx, "UPDATE external_services SET deleted_at=NULL, deletion_reason=NULL, updated_at=now() WHERE id=$1", id); err != nil {
+			return err
+		}
+		return recordExternalServiceAuditEvent(ctx, tx, id, ExternalServiceAuditActionUndelete, "")
+	})
+}
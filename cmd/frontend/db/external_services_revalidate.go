@@ -0,0 +1,87 @@
+package db
+
+import (
+	"context"
+	"sync"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/db/dbconn"
+)
+
+// ExternalServiceHealth is the outcome of checking the connection of an external service to its
+// code host (see RevalidateAll).
+type ExternalServiceHealth struct {
+	ID          int64
+	Kind        string
+	DisplayName string
+	Health      string // ExternalServiceHealthHealthy or ExternalServiceHealthFailing
+	Error       string // the error from TestConnection, or empty if the connection is healthy
+}
+
+// revalidateConcurrency is the maximum number of connections that RevalidateAll checks at once, so
+// that it doesn't make many simultaneous requests to the same code host.
+const revalidateConcurrency = 4
+
+// RevalidateAll checks the connection (with TestConnection) of every enabled site-wide external
+// service, records the outcome in each external service's health and last_sync_error, and returns
+// the outcomes in the order of List. External services of kinds whose connection TestConnection
+// can't check (see canTestConnection) are skipped and left untouched, so that they aren't reported
+// as healthy without having been checked. It is intended to be run periodically, so that external services
+// whose credentials have been revoked or have expired are flagged before the next sync fails.
+//
+// At most revalidateConcurrency connections are checked at once, and each check is bounded by the
+// kind's timeout (see testConnectionTimeout). Like RecordSyncResult, it does not bump updated_at;
+// unlike it, it does not set last_sync_at, because no sync was performed.
+//
+// 🚨 SECURITY: The caller must ensure that the actor is a site admin.
+func (c *externalServices) RevalidateAll(ctx context.Context) ([]ExternalServiceHealth, error) {
+	services, err := c.List(ctx, ExternalServicesListOptions{SiteWideOnly: true})
+	if err != nil {
+		return nil, err
+	}
+
+	var enabled []*types.ExternalService
+	for _, es := range services {
+		if !es.Disabled && canTestConnection(es.Kind) {
+			enabled = append(enabled, es)
+		}
+	}
+
+	var (
+		results = make([]ExternalServiceHealth, len(enabled))
+		wg      sync.WaitGroup
+		sem     = make(chan struct{}, revalidateConcurrency)
+	)
+	for i, es := range enabled {
+		results[i] = ExternalServiceHealth{ID: es.ID, Kind: es.Kind, DisplayName: es.DisplayName}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(h *ExternalServiceHealth, config string) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			if err := TestConnection(ctx, h.Kind, config); err != nil {
+				h.Health = ExternalServiceHealthFailing
+				h.Error = err.Error()
+			} else {
+				h.Health = ExternalServiceHealthHealthy
+			}
+		}(&results[i], es.Config)
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, h := range results {
+		var errText *string
+		if h.Error != "" {
+			errText = &h.Error
+		}
+		if _, err := dbconn.Global.ExecContext(ctx, "UPDATE external_services SET last_sync_error=$1, health=$2 WHERE id=$3 AND deleted_at IS NULL", errText, h.Health, h.ID); err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
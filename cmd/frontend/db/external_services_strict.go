@@ -0,0 +1,92 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/sourcegraph/sourcegraph/pkg/jsonc"
+)
+
+// ConfigDecodeProblem is a problem with the config of an external service that listConfigs ignores,
+// so that part of the config has no effect (see ListConfigsStrict).
+type ConfigDecodeProblem struct {
+	ExternalServiceID int64
+	DisplayName       string
+	Problem           string // such as `unknown field "tokens"`
+}
+
+// ListConfigsStrict decodes the configs of the site-wide external services of the kind as
+// listConfigs does, and returns the problems that listConfigs would ignore: configs that are skipped
+// (because their variable references can't be expanded, or they are not valid JSONC), and the
+// properties of each config that are not in the schema of the kind or have the wrong type. Each
+// property is decoded separately, so that all of a config's problems are reported (not just the
+// first). It returns no problems if all of the configs are decoded completely.
+//
+// 🚨 SECURITY: The caller must ensure that the actor is a site admin.
+func (c *externalServices) ListConfigsStrict(ctx context.Context, kind string) ([]ConfigDecodeProblem, error) {
+	kind, err := normalizeKind(kind)
+	if err != nil {
+		return nil, err
+	}
+	newConfig, ok := kindConfigTypes[kind]
+	if !ok {
+		return nil, fmt.Errorf("external service kind %s has no config type", kind)
+	}
+
+	services, err := c.List(ctx, ExternalServicesListOptions{Kind: kind, SiteWideOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	var problems []ConfigDecodeProblem
+	for _, service := range services {
+		for _, problem := range configDecodeProblems(service.Config, newConfig) {
+			problems = append(problems, ConfigDecodeProblem{
+				ExternalServiceID: service.ID,
+				DisplayName:       service.DisplayName,
+				Problem:           problem,
+			})
+		}
+	}
+	return problems, nil
+}
+
+// configDecodeProblems returns the problems decoding the (JSONC) config into a new value of the
+// config type, in the order of the config's properties' names.
+func configDecodeProblems(config string, newConfig func() interface{}) []string {
+	config, err := ExpandConfigTemplate(config)
+	if err != nil {
+		return []string{fmt.Sprintf("config is ignored because its variable references can't be expanded: %s", err)}
+	}
+	normalized, err := jsonc.Parse(config)
+	if err != nil {
+		return []string{fmt.Sprintf("config is ignored because it is not valid JSON: %s", err)}
+	}
+	var properties map[string]json.RawMessage
+	if err := json.Unmarshal(normalized, &properties); err != nil {
+		return []string{fmt.Sprintf("config is ignored because it is not a JSON object: %s", strings.TrimPrefix(err.Error(), "json: "))}
+	}
+
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var problems []string
+	for _, name := range names {
+		property, err := json.Marshal(map[string]json.RawMessage{name: properties[name]})
+		if err != nil {
+			return append(problems, err.Error())
+		}
+		dec := json.NewDecoder(bytes.NewReader(property))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(newConfig()); err != nil {
+			problems = append(problems, strings.TrimPrefix(err.Error(), "json: "))
+		}
+	}
+	return problems
+}
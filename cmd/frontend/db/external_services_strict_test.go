@@ -0,0 +1,66 @@
+package db
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/db/dbtesting"
+)
+
+func TestConfigDecodeProblems(t *testing.T) {
+	tests := map[string]struct {
+		config string
+		want   []string // substrings of each problem, in order
+	}{
+		"valid":         {config: `{"url": "https://github.com", "token": "t", "repos": ["foo/bar"]}`},
+		"comments":      {config: "{\n  // The token.\n  \"token\": \"t\",\n}"},
+		"unknown field": {config: `{"url": "https://github.com", "tokens": "t"}`, want: []string{`unknown field "tokens"`}},
+		"wrong type":    {config: `{"url": 1, "token": "t"}`, want: []string{"cannot unmarshal number"}},
+		"all problems": {
+			config: `{"url": 1, "tokens": "t", "repositoryQuery": "affiliated"}`,
+			want:   []string{"cannot unmarshal string", `unknown field "tokens"`, "cannot unmarshal number"},
+		},
+		"not an object": {config: `["https://github.com"]`, want: []string{"not a JSON object"}},
+		"invalid JSONC": {config: `{"url": `, want: []string{"not valid JSON"}},
+	}
+	for name, test := range tests {
+		got := configDecodeProblems(test.config, kindConfigTypes["GITHUB"])
+		if len(got) != len(test.want) {
+			t.Errorf("%s: got problems %q, want %d problems", name, got, len(test.want))
+			continue
+		}
+		for i, want := range test.want {
+			if !strings.Contains(got[i], want) {
+				t.Errorf("%s: got problem %q, want it to contain %q", name, got[i], want)
+			}
+		}
+	}
+}
+
+func TestExternalServices_ListConfigsStrict(t *testing.T) {
+	ctx := dbtesting.TestContext(t)
+
+	valid := &types.ExternalService{Kind: "GITHUB", DisplayName: "valid", Config: `{"url": "https://github.com", "token": "t"}`}
+	renamed := &types.ExternalService{Kind: "GITHUB", DisplayName: "renamed", Config: `{"url": "https://github.com", "token": "t", "repositoryQueries": ["affiliated"]}`}
+	other := &types.ExternalService{Kind: "GITLAB", DisplayName: "other kind", Config: `{"urls": "https://gitlab.com"}`}
+	for _, es := range []*types.ExternalService{valid, renamed, other} {
+		if err := ExternalServices.Create(ctx, es); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	problems, err := ExternalServices.ListConfigsStrict(ctx, "github")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []ConfigDecodeProblem{{ExternalServiceID: renamed.ID, DisplayName: "renamed", Problem: `unknown field "repositoryQueries"`}}
+	if !reflect.DeepEqual(problems, want) {
+		t.Errorf("got %+v, want %+v", problems, want)
+	}
+
+	if _, err := ExternalServices.ListConfigsStrict(ctx, "OTHER"); err == nil {
+		t.Error("got nil error for an unknown kind, want error")
+	}
+}
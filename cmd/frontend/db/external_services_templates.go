@@ -0,0 +1,125 @@
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// ConfigSecretPrefix is the prefix of the names of the variables that external service configs can
+// reference (as ${NAME}). Only environment variables with this prefix can be referenced, so that a
+// config can't expand (and send to a code host) other secrets in the environment of the frontend,
+// such as database credentials.
+const ConfigSecretPrefix = "SRC_EXTSVC_SECRET_"
+
+// ConfigSecrets looks up the value of a variable referenced as ${NAME} in an external service
+// config (e.g., "token": "${SRC_EXTSVC_SECRET_GITHUB_TOKEN}"), so that secrets need not be stored
+// in the config itself. It reads from the process environment by default, and never returns the
+// value of a variable whose name doesn't start with ConfigSecretPrefix.
+//
+// Configs are stored with their references unexpanded; they are only expanded when the configs
+// are read for use (see listConfigs), so expanded secrets are never persisted.
+var ConfigSecrets = func(name string) (string, bool) {
+	if !strings.HasPrefix(name, ConfigSecretPrefix) {
+		return "", false
+	}
+	return os.LookupEnv(name)
+}
+
+var (
+	configTemplateRefPattern  = regexp.MustCompile(`\$\{([^}]*)\}`)
+	configTemplateNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+// configStringLiterals returns the offsets of the contents (between the quotes) of the string
+// literals in the JSONC config, in order. Comments are skipped, so that text in a comment that
+// looks like a variable reference is ignored.
+func configStringLiterals(config string) [][2]int {
+	var spans [][2]int
+	for i := 0; i < len(config); i++ {
+		switch {
+		case config[i] == '"':
+			start := i + 1
+			for i = start; i < len(config) && config[i] != '"'; i++ {
+				if config[i] == '\\' {
+					i++ // skip the escaped character
+				}
+			}
+			if i > len(config) {
+				i = len(config)
+			}
+			spans = append(spans, [2]int{start, i})
+		case strings.HasPrefix(config[i:], "//"):
+			for i < len(config) && config[i] != '\n' {
+				i++
+			}
+		case strings.HasPrefix(config[i:], "/*"):
+			if end := strings.Index(config[i+2:], "*/"); end >= 0 {
+				i += 2 + end + 1
+			} else {
+				i = len(config)
+			}
+		}
+	}
+	return spans
+}
+
+// configTemplateVars returns the names of the variables referenced by the string values of config.
+// It returns an error if config contains a malformed reference, or a reference to a variable whose
+// name doesn't start with ConfigSecretPrefix.
+func configTemplateVars(config string) ([]string, error) {
+	var names []string
+	for _, span := range configStringLiterals(config) {
+		s := config[span[0]:span[1]]
+		for _, m := range configTemplateRefPattern.FindAllStringSubmatch(s, -1) {
+			if !configTemplateNamePattern.MatchString(m[1]) {
+				return nil, fmt.Errorf("invalid variable reference %q in config (variable names must match %s)", m[0], configTemplateNamePattern)
+			}
+			if !strings.HasPrefix(m[1], ConfigSecretPrefix) {
+				return nil, fmt.Errorf("invalid variable reference %q in config (variable names must start with %s)", m[0], ConfigSecretPrefix)
+			}
+			names = append(names, m[1])
+		}
+		if rest := configTemplateRefPattern.ReplaceAllString(s, ""); strings.Contains(rest, "${") {
+			return nil, fmt.Errorf("unterminated variable reference in config (expected ${NAME})")
+		}
+	}
+	return names, nil
+}
+
+// ExpandConfigTemplate replaces each ${NAME} variable reference in the string values of config with
+// the value of NAME from ConfigSecrets. Values are escaped for JSON strings, and comments are left
+// as-is. It returns an error if any referenced variable is undefined (or can't be referenced; see
+// configTemplateVars).
+func ExpandConfigTemplate(config string) (string, error) {
+	if _, err := configTemplateVars(config); err != nil {
+		return "", err
+	}
+
+	var (
+		buf       strings.Builder
+		last      int
+		undefined []string
+	)
+	for _, span := range configStringLiterals(config) {
+		buf.WriteString(config[last:span[0]])
+		buf.WriteString(configTemplateRefPattern.ReplaceAllStringFunc(config[span[0]:span[1]], func(ref string) string {
+			name := ref[len("${") : len(ref)-len("}")]
+			value, ok := ConfigSecrets(name)
+			if !ok {
+				undefined = append(undefined, name)
+				return ref
+			}
+			b, _ := json.Marshal(value)
+			return string(b[1 : len(b)-1]) // strip quotes
+		}))
+		last = span[1]
+	}
+	buf.WriteString(config[last:])
+	if len(undefined) > 0 {
+		return "", fmt.Errorf("config references undefined variables: %s", strings.Join(undefined, ", "))
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,114 @@
+package db
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func mockConfigSecrets(secrets map[string]string) (restore func()) {
+	orig := ConfigSecrets
+	ConfigSecrets = func(name string) (string, bool) {
+		v, ok := secrets[name]
+		return v, ok
+	}
+	return func() { ConfigSecrets = orig }
+}
+
+func TestExpandConfigTemplate(t *testing.T) {
+	defer mockConfigSecrets(map[string]string{
+		"SRC_EXTSVC_SECRET_GITHUB_TOKEN": "s3cr3t",
+		"SRC_EXTSVC_SECRET_QUOTED":       `a"b\c`,
+		"PGPASSWORD":                     "db",
+	})()
+
+	tests := []struct {
+		config  string
+		want    string
+		wantErr bool
+	}{
+		{config: `{"token": "abc"}`, want: `{"token": "abc"}`},
+		{config: `{"token": "${SRC_EXTSVC_SECRET_GITHUB_TOKEN}"}`, want: `{"token": "s3cr3t"}`},
+		{config: `{"token": "x-${SRC_EXTSVC_SECRET_GITHUB_TOKEN}-${SRC_EXTSVC_SECRET_GITHUB_TOKEN}"}`, want: `{"token": "x-s3cr3t-s3cr3t"}`},
+		{config: `{"token": "${SRC_EXTSVC_SECRET_QUOTED}"}`, want: `{"token": "a\"b\\c"}`},
+		{config: `{"token": "a\"${SRC_EXTSVC_SECRET_GITHUB_TOKEN}"}`, want: `{"token": "a\"s3cr3t"}`},
+		{config: `{"token": "${SRC_EXTSVC_SECRET_MISSING}"}`, wantErr: true},
+		{config: `{"token": "${NOT-A-NAME}"}`, wantErr: true},
+		{config: `{"token": "${SRC_EXTSVC_SECRET_GITHUB_TOKEN"}`, wantErr: true},
+
+		// Only variables with ConfigSecretPrefix can be referenced, even if others are defined.
+		{config: `{"token": "${PGPASSWORD}"}`, wantErr: true},
+
+		// References in comments are ignored (and not expanded).
+		{
+			config: "{\n  // Set the token to ${SRC_EXTSVC_SECRET_MISSING} or ${\n  \"token\": \"${SRC_EXTSVC_SECRET_GITHUB_TOKEN}\" /* ${ */\n}",
+			want:   "{\n  // Set the token to ${SRC_EXTSVC_SECRET_MISSING} or ${\n  \"token\": \"s3cr3t\" /* ${ */\n}",
+		},
+	}
+	for _, test := range tests {
+		got, err := ExpandConfigTemplate(test.config)
+		if (err != nil) != test.wantErr {
+			t.Errorf("%s: got error %v, want error %v", test.config, err, test.wantErr)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("%s: got %q, want %q", test.config, got, test.want)
+		}
+	}
+}
+
+func TestValidateConfig_Templates(t *testing.T) {
+	defer mockConfigSecrets(map[string]string{"SRC_EXTSVC_SECRET_GITHUB_TOKEN": "s3cr3t"})()
+
+	// Defined variables produce no warnings.
+	warnings, err := validateConfig("GITHUB", `{"token": "${SRC_EXTSVC_SECRET_GITHUB_TOKEN}"}`, configValidationOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(warnings) != 0 {
+		t.Errorf("got warnings %q, want none", warnings)
+	}
+
+	// Undefined variables are valid on save, but flagged.
+	warnings, err = validateConfig("GITHUB", `{"token": "${SRC_EXTSVC_SECRET_MISSING}"}`, configValidationOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{`config references undefined variable "SRC_EXTSVC_SECRET_MISSING"`}; !reflect.DeepEqual(warnings, want) {
+		t.Errorf("got warnings %q, want %q", warnings, want)
+	}
+
+	// Undefined variables are an error when secrets are required.
+	if _, err := validateConfig("GITHUB", `{"token": "${SRC_EXTSVC_SECRET_MISSING}"}`, configValidationOptions{RequireSecrets: true}); err == nil {
+		t.Error("got nil error for undefined variable with RequireSecrets")
+	}
+
+	// Malformed references are always an error.
+	if _, err := validateConfig("GITHUB", `{"token": "${SRC_EXTSVC_SECRET_MISSING"}`, configValidationOptions{}); err == nil {
+		t.Error("got nil error for malformed variable reference")
+	}
+
+	// References to variables without ConfigSecretPrefix are an error.
+	if _, err := validateConfig("GITHUB", `{"token": "${PGPASSWORD}"}`, configValidationOptions{}); err == nil {
+		t.Error("got nil error for a reference to a variable without ConfigSecretPrefix")
+	}
+
+	// References in comments are ignored.
+	if _, err := validateConfig("GITHUB", "{\n  // e.g. ${ or ${PGPASSWORD}\n  \"token\": \"t\"\n}", configValidationOptions{}); err != nil {
+		t.Errorf("got error %v for a reference in a comment, want nil", err)
+	}
+}
+
+func TestConfigSecrets_Prefix(t *testing.T) {
+	os.Setenv("SRC_EXTSVC_SECRET_TEST_TOKEN", "s3cr3t")
+	os.Setenv("TEST_NOT_A_CONFIG_SECRET", "db")
+	defer os.Unsetenv("SRC_EXTSVC_SECRET_TEST_TOKEN")
+	defer os.Unsetenv("TEST_NOT_A_CONFIG_SECRET")
+
+	if v, ok := ConfigSecrets("SRC_EXTSVC_SECRET_TEST_TOKEN"); !ok || v != "s3cr3t" {
+		t.Errorf("got %q, %v, want %q, true", v, ok, "s3cr3t")
+	}
+	if v, ok := ConfigSecrets("TEST_NOT_A_CONFIG_SECRET"); ok {
+		t.Errorf("got %q for a variable without ConfigSecretPrefix, want none", v)
+	}
+}
@@ -0,0 +1,1873 @@
+package db
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/migrations"
+	"github.com/sourcegraph/sourcegraph/pkg/conf"
+	"github.com/sourcegraph/sourcegraph/pkg/db/dbconn"
+	"github.com/sourcegraph/sourcegraph/pkg/db/dbtesting"
+	"github.com/sourcegraph/sourcegraph/pkg/errcode"
+	"github.com/sourcegraph/sourcegraph/pkg/jsonc"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+func TestExternalServices_ListUpdatedAfter(t *testing.T) {
+	ctx := dbtesting.TestContext(t)
+
+	// Create services and give them deterministic updated_at values. Services 2 and 3 share the
+	// same updated_at to exercise the (updated_at, id) cursor.
+	base := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	updatedAts := []time.Time{base.Add(3 * time.Hour), base.Add(1 * time.Hour), base.Add(2 * time.Hour), base.Add(2 * time.Hour)}
+	var ids []int64
+	for _, updatedAt := range updatedAts {
+		es := &types.ExternalService{Kind: "GITHUB", DisplayName: "GitHub", Config: "{}"}
+		if err := ExternalServices.Create(ctx, es); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := dbconn.Global.ExecContext(ctx, "UPDATE external_services SET updated_at=$1 WHERE id=$2", updatedAt, es.ID); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, es.ID)
+	}
+
+	listIDs := func(opt ExternalServicesListOptions) []int64 {
+		t.Helper()
+		services, err := ExternalServices.List(ctx, opt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []int64
+		for _, s := range services {
+			got = append(got, s.ID)
+		}
+		return got
+	}
+
+	t.Run("ascending order", func(t *testing.T) {
+		got := listIDs(ExternalServicesListOptions{OrderBy: ExternalServicesOrderByUpdatedAtAsc})
+		if want := []int64{ids[1], ids[2], ids[3], ids[0]}; !reflect.DeepEqual(got, want) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	})
+
+	t.Run("updated after", func(t *testing.T) {
+		cursor := base.Add(1 * time.Hour)
+		got := listIDs(ExternalServicesListOptions{UpdatedAfter: &cursor, OrderBy: ExternalServicesOrderByUpdatedAtAsc})
+		if want := []int64{ids[2], ids[3], ids[0]}; !reflect.DeepEqual(got, want) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	})
+
+	t.Run("updated after with id tie-breaker", func(t *testing.T) {
+		cursor := base.Add(2 * time.Hour)
+		got := listIDs(ExternalServicesListOptions{UpdatedAfter: &cursor, UpdatedAfterID: ids[2], OrderBy: ExternalServicesOrderByUpdatedAtAsc})
+		if want := []int64{ids[3], ids[0]}; !reflect.DeepEqual(got, want) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	})
+}
+
+func TestExternalServices_ListCreatedAfter(t *testing.T) {
+	ctx := dbtesting.TestContext(t)
+
+	base := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	var ids []int64
+	for i := 0; i < 3; i++ {
+		es := &types.ExternalService{Kind: "GITHUB", DisplayName: "GitHub", Config: "{}"}
+		if err := ExternalServices.Create(ctx, es); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := dbconn.Global.ExecContext(ctx, "UPDATE external_services SET created_at=$1 WHERE id=$2", base.Add(time.Duration(i)*time.Hour), es.ID); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, es.ID)
+	}
+
+	opt := ExternalServicesListOptions{CreatedAfter: &base}
+	services, err := ExternalServices.List(ctx, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []int64
+	for _, s := range services {
+		got = append(got, s.ID)
+	}
+	if want := []int64{ids[2], ids[1]}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	count, err := ExternalServices.Count(ctx, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := 2; count != want {
+		t.Errorf("got count %d, want %d", count, want)
+	}
+}
+
+func TestExternalServices_ListChangedSince(t *testing.T) {
+	ctx := dbtesting.TestContext(t)
+
+	since := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	create := func(displayName string, updatedAt time.Time, deletedAt *time.Time) int64 {
+		t.Helper()
+		es := &types.ExternalService{Kind: "GITHUB", DisplayName: displayName, Config: "{}"}
+		if err := ExternalServices.Create(ctx, es); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := dbconn.Global.ExecContext(ctx, "UPDATE external_services SET updated_at=$1, deleted_at=$2 WHERE id=$3", updatedAt, deletedAt, es.ID); err != nil {
+			t.Fatal(err)
+		}
+		return es.ID
+	}
+	before, after := since.Add(-time.Hour), since.Add(time.Hour)
+	create("unchanged", before, nil)
+	create("deleted before", before, &before)
+	updated := create("updated", since.Add(2*time.Hour), nil)
+	deleted := create("deleted after", before, &after)
+	if _, err := dbconn.Global.ExecContext(ctx, "INSERT INTO external_services(id, kind, display_name, config, updated_at, deleted_at) VALUES(0, 'MIGRATION', '', '{}', $1, $1)", after); err != nil {
+		t.Fatal(err)
+	}
+
+	services, err := ExternalServices.ListChangedSince(ctx, since)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []int64
+	for _, s := range services {
+		got = append(got, s.ID)
+	}
+	if want := []int64{deleted, updated}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestExternalServices_ListConnectionsSiteWideOnly(t *testing.T) {
+	ctx := dbtesting.TestContext(t)
+	conf.Mock(&schema.SiteConfiguration{ExperimentalFeatures: &schema.ExperimentalFeatures{ExternalServices: "enabled"}})
+	defer conf.Mock(nil)
+
+	user, err := Users.Create(ctx, NewUser{Username: "u"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ExternalServices.Create(ctx, &types.ExternalService{Kind: "GITHUB", DisplayName: "site", Config: `{"url": "https://site.github.example.com"}`}); err != nil {
+		t.Fatal(err)
+	}
+	userOwned := &types.ExternalService{Kind: "GITHUB", DisplayName: "user", Config: `{"url": "https://user.github.example.com"}`}
+	if err := ExternalServices.Create(ctx, userOwned); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dbconn.Global.ExecContext(ctx, "UPDATE external_services SET namespace_user_id=$1 WHERE id=$2", user.ID, userOwned.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	connections, err := ExternalServices.ListGitHubConnections(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, c := range connections {
+		got = append(got, c.Url)
+	}
+	if want := []string{"https://site.github.example.com"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	// Other listings include user-owned external services unless SiteWideOnly is set.
+	if count, err := ExternalServices.Count(ctx, ExternalServicesListOptions{}); err != nil {
+		t.Fatal(err)
+	} else if want := 2; count != want {
+		t.Errorf("got count %d, want %d", count, want)
+	}
+}
+
+func TestExternalServices_ListConnectionsToggle(t *testing.T) {
+	ctx := dbtesting.TestContext(t)
+	defer conf.Mock(nil)
+	// Skip the migration, so that the connections in the site configuration are not copied to the DB.
+	migrated = true
+	defer func() { migrated = false }()
+
+	if err := ExternalServices.Create(ctx, &types.ExternalService{Kind: "GITHUB", DisplayName: "db", Config: `{"url": "https://db.github.example.com"}`}); err != nil {
+		t.Fatal(err)
+	}
+
+	listURLs := func(enabled string) []string {
+		conf.Mock(&schema.SiteConfiguration{
+			ExperimentalFeatures: &schema.ExperimentalFeatures{ExternalServices: enabled},
+			Github:               []*schema.GitHubConnection{{Url: "https://conf.github.example.com"}},
+		})
+		connections, err := ExternalServices.ListGitHubConnections(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var urls []string
+		for _, c := range connections {
+			urls = append(urls, c.Url)
+		}
+		return urls
+	}
+	for _, test := range []struct {
+		enabled string
+		want    []string
+	}{
+		{enabled: "disabled", want: []string{"https://conf.github.example.com"}},
+		{enabled: "enabled", want: []string{"https://db.github.example.com"}},
+		{enabled: "disabled", want: []string{"https://conf.github.example.com"}},
+	} {
+		if got := listURLs(test.enabled); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("externalServices %s: got %v, want %v", test.enabled, got, test.want)
+		}
+	}
+}
+
+func TestExternalServices_CountIncludeDeleted(t *testing.T) {
+	ctx := dbtesting.TestContext(t)
+
+	// The migration sentinel is deleted, but it isn't counted as a deleted external service.
+	if _, err := dbconn.Global.ExecContext(ctx, "INSERT INTO external_services(id, kind, display_name, config, deleted_at) VALUES(0, 'MIGRATION', '', '{}', now())"); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := ExternalServices.Create(ctx, &types.ExternalService{Kind: "GITHUB", DisplayName: "GitHub", Config: "{}"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	deleted := &types.ExternalService{Kind: "GITLAB", DisplayName: "GitLab", Config: "{}"}
+	if err := ExternalServices.Create(ctx, deleted); err != nil {
+		t.Fatal(err)
+	}
+	if err := ExternalServices.Delete(ctx, deleted.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	count, err := ExternalServices.Count(ctx, ExternalServicesListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := 3; count != want {
+		t.Errorf("got count %d, want %d", count, want)
+	}
+
+	count, err = ExternalServices.Count(ctx, ExternalServicesListOptions{IncludeDeleted: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := 4; count != want {
+		t.Errorf("got count %d, want %d", count, want)
+	}
+}
+
+func TestExternalServices_ListWithTotal(t *testing.T) {
+	ctx := dbtesting.TestContext(t)
+
+	var ids []int64
+	for i := 0; i < 5; i++ {
+		es := &types.ExternalService{Kind: "GITHUB", DisplayName: "GitHub", Config: "{}"}
+		if err := ExternalServices.Create(ctx, es); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, es.ID)
+	}
+	if err := ExternalServices.Create(ctx, &types.ExternalService{Kind: "GITLAB", DisplayName: "GitLab", Config: "{}"}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]struct {
+		opt       ExternalServicesListOptions
+		wantIDs   []int64
+		wantTotal int
+	}{
+		"first page": {
+			opt:       ExternalServicesListOptions{Kind: "GITHUB", LimitOffset: &LimitOffset{Limit: 2}},
+			wantIDs:   []int64{ids[4], ids[3]},
+			wantTotal: 5,
+		},
+		"last page": {
+			opt:       ExternalServicesListOptions{Kind: "GITHUB", LimitOffset: &LimitOffset{Limit: 2, Offset: 4}},
+			wantIDs:   []int64{ids[0]},
+			wantTotal: 5,
+		},
+		"past the end": {
+			opt:       ExternalServicesListOptions{Kind: "GITHUB", LimitOffset: &LimitOffset{Limit: 2, Offset: 10}},
+			wantTotal: 5,
+		},
+		"no matches": {
+			opt:       ExternalServicesListOptions{Kind: "PHABRICATOR"},
+			wantTotal: 0,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			services, total, err := ExternalServices.ListWithTotal(ctx, test.opt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var gotIDs []int64
+			for _, s := range services {
+				gotIDs = append(gotIDs, s.ID)
+			}
+			if !reflect.DeepEqual(gotIDs, test.wantIDs) {
+				t.Errorf("got IDs %v, want %v", gotIDs, test.wantIDs)
+			}
+			if total != test.wantTotal {
+				t.Errorf("got total %d, want %d", total, test.wantTotal)
+			}
+		})
+	}
+}
+
+func TestExternalServices_ListRecentlyFailed(t *testing.T) {
+	ctx := dbtesting.TestContext(t)
+
+	create := func(displayName string) *types.ExternalService {
+		t.Helper()
+		es := &types.ExternalService{Kind: "GITHUB", DisplayName: displayName, Config: "{}"}
+		if err := ExternalServices.Create(ctx, es); err != nil {
+			t.Fatal(err)
+		}
+		return es
+	}
+	healthy := create("healthy")
+	failedFirst := create("failed first")
+	failedSecond := create("failed second")
+	disabled := create("disabled")
+	deleted := create("deleted")
+	create("never synced")
+
+	if err := ExternalServices.RecordSyncResult(ctx, healthy.ID, nil); err != nil {
+		t.Fatal(err)
+	}
+	for _, es := range []*types.ExternalService{failedFirst, failedSecond, disabled, deleted} {
+		if err := ExternalServices.RecordSyncResult(ctx, es.ID, errors.New("sync failed: "+es.DisplayName)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := dbconn.Global.ExecContext(ctx, "UPDATE external_services SET disabled=true WHERE id=$1", disabled.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := ExternalServices.Delete(ctx, deleted.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	failed, err := ExternalServices.ListRecentlyFailed(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, es := range failed {
+		if es.LastSyncError == nil {
+			t.Errorf("%q: got nil LastSyncError", es.DisplayName)
+			continue
+		}
+		if es.Health != ExternalServiceHealthFailing {
+			t.Errorf("%q: got health %q, want %q", es.DisplayName, es.Health, ExternalServiceHealthFailing)
+		}
+		got = append(got, *es.LastSyncError)
+	}
+	if want := []string{"sync failed: failed second", "sync failed: failed first"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestExternalServices_ListSummary(t *testing.T) {
+	ctx := dbtesting.TestContext(t)
+
+	github := &types.ExternalService{Kind: "GITHUB", DisplayName: "GitHub", Config: `{"token": "` + strings.Repeat("x", 1<<16) + `"}`}
+	gitlab := &types.ExternalService{Kind: "GITLAB", DisplayName: "GitLab", Config: "{}"}
+	for _, es := range []*types.ExternalService{github, gitlab} {
+		if err := ExternalServices.Create(ctx, es); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := ExternalServices.RecordSyncResult(ctx, github.ID, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dbconn.Global.ExecContext(ctx, "UPDATE external_services SET disabled=true WHERE id=$1", gitlab.ID); err != nil {
+		t.Fatal(err)
+	}
+	deleted := &types.ExternalService{Kind: "GITHUB", DisplayName: "deleted", Config: "{}"}
+	if err := ExternalServices.Create(ctx, deleted); err != nil {
+		t.Fatal(err)
+	}
+	if err := ExternalServices.Delete(ctx, deleted.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	summaries, err := ExternalServices.ListSummary(ctx, ExternalServicesListOptions{OrderBy: ExternalServicesOrderByIDAsc})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []ExternalServiceSummary
+	for _, s := range summaries {
+		if s.CreatedAt.IsZero() || s.UpdatedAt.IsZero() {
+			t.Errorf("%q: got zero timestamps", s.DisplayName)
+		}
+		s.CreatedAt, s.UpdatedAt = time.Time{}, time.Time{}
+		got = append(got, *s)
+	}
+	want := []ExternalServiceSummary{
+		{ID: github.ID, Kind: "GITHUB", DisplayName: "GitHub", Enabled: true, Health: ExternalServiceHealthHealthy},
+		{ID: gitlab.ID, Kind: "GITLAB", DisplayName: "GitLab", Enabled: false, Health: ExternalServiceHealthUnknown},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestExternalServices_ListURLHost(t *testing.T) {
+	ctx := dbtesting.TestContext(t)
+
+	configs := map[string]string{
+		"ghe 1":   `{"url": "https://ghe.internal.example.com", "token": "t"}`,
+		"ghe 2":   "// GHE with a port\n{\"url\": \"https://GHE.internal.example.com:8443/\", \"token\": \"t\"}",
+		"other":   `{"url": "https://other.example.com", "token": "t"}`,
+		"no url":  `{"token": "t"}`,
+		"ghe old": `{"url": "https://ghe.internal.example.com", "token": "t"}`,
+	}
+	ids := map[string]int64{}
+	for _, displayName := range []string{"ghe 1", "ghe 2", "other", "no url", "ghe old"} {
+		es := &types.ExternalService{Kind: "GITHUB", DisplayName: displayName, Config: configs[displayName]}
+		if err := ExternalServices.Create(ctx, es); err != nil {
+			t.Fatal(err)
+		}
+		ids[displayName] = es.ID
+	}
+	// Simulate an external service written before the url_host column existed.
+	if _, err := dbconn.Global.ExecContext(ctx, "UPDATE external_services SET url_host=NULL WHERE id=$1", ids["ghe old"]); err != nil {
+		t.Fatal(err)
+	}
+
+	opt := ExternalServicesListOptions{URLHost: "ghe.internal.example.com", OrderBy: ExternalServicesOrderByIDAsc}
+	ess, err := ExternalServices.List(ctx, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, es := range ess {
+		got = append(got, es.DisplayName)
+	}
+	if want := []string{"ghe 1", "ghe 2", "ghe old"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if count, err := ExternalServices.Count(ctx, opt); err != nil {
+		t.Fatal(err)
+	} else if count != 3 {
+		t.Errorf("got count %d, want 3", count)
+	}
+
+	// Updating the config updates the host.
+	newConfig := `{"url": "https://other.example.com", "token": "t"}`
+	if err := ExternalServices.Update(ctx, ids["ghe 1"], &ExternalServiceUpdate{Config: &newConfig}); err != nil {
+		t.Fatal(err)
+	}
+	if count, err := ExternalServices.Count(ctx, ExternalServicesListOptions{URLHost: "OTHER.example.com"}); err != nil {
+		t.Fatal(err)
+	} else if count != 2 {
+		t.Errorf("got count %d, want 2", count)
+	}
+}
+
+func TestExternalServices_DeleteWithReason(t *testing.T) {
+	ctx := dbtesting.TestContext(t)
+
+	es := &types.ExternalService{Kind: "GITHUB", DisplayName: "GitHub", Config: "{}"}
+	if err := ExternalServices.Create(ctx, es); err != nil {
+		t.Fatal(err)
+	}
+	if err := ExternalServices.DeleteWithReason(ctx, es.ID, "replaced by GitHub Enterprise"); err != nil {
+		t.Fatal(err)
+	}
+
+	deleted, err := ExternalServices.List(ctx, ExternalServicesListOptions{IncludeDeleted: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deleted) != 1 {
+		t.Fatalf("got %d external services, want 1", len(deleted))
+	}
+	if deleted[0].DeletedAt == nil {
+		t.Error("got nil DeletedAt")
+	}
+	if want := "replaced by GitHub Enterprise"; deleted[0].DeletionReason == nil || *deleted[0].DeletionReason != want {
+		t.Errorf("got deletion reason %v, want %q", deleted[0].DeletionReason, want)
+	}
+
+	events, err := ExternalServices.ListAuditLog(ctx, es.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 {
+		t.Fatalf("got %d audit events, want 1", len(events))
+	}
+	if events[0].Action != ExternalServiceAuditActionDelete || events[0].Details != "replaced by GitHub Enterprise" {
+		t.Errorf("got audit event %+v", events[0])
+	}
+
+	// Deleting again is not allowed.
+	if err := ExternalServices.DeleteWithReason(ctx, es.ID, "again"); err == nil {
+		t.Error("got nil error deleting an already deleted external service")
+	}
+}
+
+func TestExternalServices_SetNamespace(t *testing.T) {
+	ctx := dbtesting.TestContext(t)
+
+	user, err := Users.Create(ctx, NewUser{Username: "u"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	es := &types.ExternalService{Kind: "GITHUB", DisplayName: "GitHub", Config: "{}"}
+	if err := ExternalServices.Create(ctx, es); err != nil {
+		t.Fatal(err)
+	}
+	createdUpdatedAt := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	if _, err := dbconn.Global.ExecContext(ctx, "UPDATE external_services SET updated_at=$1 WHERE id=$2", createdUpdatedAt, es.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	namespace := func() *int32 {
+		t.Helper()
+		var namespaceUserID *int32
+		if err := dbconn.Global.QueryRowContext(ctx, "SELECT namespace_user_id FROM external_services WHERE id=$1", es.ID).Scan(&namespaceUserID); err != nil {
+			t.Fatal(err)
+		}
+		return namespaceUserID
+	}
+
+	// Transfer to the user, then promote back to site-wide.
+	if err := ExternalServices.SetNamespace(ctx, es.ID, &user.ID); err != nil {
+		t.Fatal(err)
+	}
+	if got := namespace(); got == nil || *got != user.ID {
+		t.Errorf("got namespace %v, want user %d", got, user.ID)
+	}
+	if err := ExternalServices.SetNamespace(ctx, es.ID, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := namespace(); got != nil {
+		t.Errorf("got namespace user %d, want site-wide", *got)
+	}
+
+	updated, err := ExternalServices.GetByID(ctx, es.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !updated.UpdatedAt.After(createdUpdatedAt) {
+		t.Errorf("got updated_at %v, want after %v", updated.UpdatedAt, createdUpdatedAt)
+	}
+
+	events, err := ExternalServices.ListAuditLog(ctx, es.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, e := range events {
+		got = append(got, e.Action+" "+e.Details)
+	}
+	want := []string{
+		fmt.Sprintf("transfer from site to user %d", user.ID),
+		fmt.Sprintf("transfer from user %d to site", user.ID),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got audit log %q, want %q", got, want)
+	}
+
+	if err := ExternalServices.SetNamespace(ctx, es.ID+1, nil); !errcode.IsNotFound(err) {
+		t.Errorf("got error %v, want not found", err)
+	}
+}
+
+func TestExternalServices_Undelete(t *testing.T) {
+	ctx := dbtesting.TestContext(t)
+	conf.Mock(&schema.SiteConfiguration{ExternalServicesRestoreWindowDays: 7})
+	defer conf.Mock(nil)
+
+	create := func(displayName string) *types.ExternalService {
+		t.Helper()
+		es := &types.ExternalService{Kind: "GITHUB", DisplayName: displayName, Config: "{}"}
+		if err := ExternalServices.Create(ctx, es); err != nil {
+			t.Fatal(err)
+		}
+		if err := ExternalServices.DeleteWithReason(ctx, es.ID, "oops"); err != nil {
+			t.Fatal(err)
+		}
+		return es
+	}
+	recent := create("recent")
+	expired := create("expired")
+	if _, err := dbconn.Global.ExecContext(ctx, "UPDATE external_services SET deleted_at=now()-interval '8 days' WHERE id=$1", expired.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dbconn.Global.ExecContext(ctx, "INSERT INTO external_services(id, kind, display_name, config, deleted_at) VALUES(0, 'MIGRATION', '', '{}', now())"); err != nil {
+		t.Fatal(err)
+	}
+
+	restorable, err := ExternalServices.ListRestorable(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(restorable) != 1 || restorable[0].ID != recent.ID {
+		t.Fatalf("got restorable %+v, want only %d", restorable, recent.ID)
+	}
+	if deadline, want := ExternalServiceRestoreDeadline(*restorable[0].DeletedAt), restorable[0].DeletedAt.Add(7*24*time.Hour); !deadline.Equal(want) {
+		t.Errorf("got deadline %s, want %s", deadline, want)
+	}
+
+	if err := ExternalServices.Undelete(ctx, expired.ID); !errcode.IsBadRequest(err) {
+		t.Errorf("expired: got error %v, want bad request", err)
+	}
+	if err := ExternalServices.Undelete(ctx, 0); !errcode.IsNotFound(err) {
+		t.Errorf("sentinel: got error %v, want not found", err)
+	}
+
+	if err := ExternalServices.Undelete(ctx, recent.ID); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ExternalServices.GetByID(ctx, recent.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.DeletedAt != nil || got.DeletionReason != nil {
+		t.Errorf("got %+v, want undeleted", got)
+	}
+	events, err := ExternalServices.ListAuditLog(ctx, recent.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var actions []string
+	for _, e := range events {
+		actions = append(actions, e.Action)
+	}
+	if want := []string{ExternalServiceAuditActionDelete, ExternalServiceAuditActionUndelete}; !reflect.DeepEqual(actions, want) {
+		t.Errorf("got audit actions %v, want %v", actions, want)
+	}
+
+	// Undeleting again is not allowed.
+	if err := ExternalServices.Undelete(ctx, recent.ID); err == nil {
+		t.Error("got nil error undeleting an external service that is not deleted")
+	}
+}
+
+func TestExternalServices_HardDelete(t *testing.T) {
+	ctx := dbtesting.TestContext(t)
+
+	create := func(displayName string, deletedDaysAgo int) *types.ExternalService {
+		t.Helper()
+		es := &types.ExternalService{Kind: "GITHUB", DisplayName: displayName, Config: "{}"}
+		if err := ExternalServices.Create(ctx, es); err != nil {
+			t.Fatal(err)
+		}
+		if deletedDaysAgo > 0 {
+			if err := ExternalServices.DeleteWithReason(ctx, es.ID, "unused"); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := dbconn.Global.ExecContext(ctx, "UPDATE external_services SET deleted_at=now()-$1*interval '1 day' WHERE id=$2", deletedDaysAgo, es.ID); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return es
+	}
+	old := create("old", 100)
+	recent := create("recent", 10)
+	active := create("active", 0)
+	if _, err := dbconn.Global.ExecContext(ctx, "INSERT INTO external_services(id, kind, display_name, config, deleted_at) VALUES(0, 'MIGRATION', '', '{}', now()-interval '1000 days')"); err != nil {
+		t.Fatal(err)
+	}
+
+	if count, err := ExternalServices.CountDeleted(ctx); err != nil {
+		t.Fatal(err)
+	} else if count != 2 {
+		t.Errorf("got %d deleted external services, want 2 (excluding the placeholder)", count)
+	}
+
+	deleted, err := ExternalServices.ListDeletedBefore(ctx, time.Now().Add(-30*24*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deleted) != 1 || deleted[0].ID != old.ID {
+		t.Fatalf("got deleted %+v, want only %d", deleted, old.ID)
+	}
+
+	if err := ExternalServices.HardDelete(ctx, old.ID); err != nil {
+		t.Fatal(err)
+	}
+	if count, err := ExternalServices.CountDeleted(ctx); err != nil {
+		t.Fatal(err)
+	} else if count != 1 {
+		t.Errorf("got %d deleted external services after purging, want 1", count)
+	}
+	if _, err := ExternalServices.GetByIDIncludingDeleted(ctx, old.ID); !errcode.IsNotFound(err) {
+		t.Errorf("got error %v, want not found", err)
+	}
+	for _, table := range []string{"external_service_configs_history", "external_service_audit_log"} {
+		var count int
+		if err := dbconn.Global.QueryRowContext(ctx, "SELECT COUNT(*) FROM "+table+" WHERE external_service_id=$1", old.ID).Scan(&count); err != nil {
+			t.Fatal(err)
+		}
+		if count != 0 {
+			t.Errorf("%s: got %d rows, want 0", table, count)
+		}
+	}
+	// The other external services' rows are kept.
+	if events, err := ExternalServices.ListAuditLog(ctx, recent.ID); err != nil {
+		t.Fatal(err)
+	} else if len(events) != 1 {
+		t.Errorf("got %d audit events for recent, want 1", len(events))
+	}
+
+	if err := ExternalServices.HardDelete(ctx, active.ID); err == nil {
+		t.Error("got nil error hard-deleting an external service that is not deleted")
+	}
+	if err := ExternalServices.HardDelete(ctx, 0); !errcode.IsNotFound(err) {
+		t.Errorf("sentinel: got error %v, want not found", err)
+	}
+	if err := ExternalServices.HardDelete(ctx, old.ID); !errcode.IsNotFound(err) {
+		t.Errorf("already purged: got error %v, want not found", err)
+	}
+}
+
+func TestExternalServices_ListKinds(t *testing.T) {
+	ctx := dbtesting.TestContext(t)
+
+	for _, kind := range []string{"GITLAB", "GITHUB", "GITHUB", "PHABRICATOR", "GITHUB", "GITLAB"} {
+		if err := ExternalServices.Create(ctx, &types.ExternalService{Kind: kind, DisplayName: kind, Config: "{}"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	deleted := &types.ExternalService{Kind: "PHABRICATOR", DisplayName: "deleted", Config: "{}"}
+	if err := ExternalServices.Create(ctx, deleted); err != nil {
+		t.Fatal(err)
+	}
+	if err := ExternalServices.Delete(ctx, deleted.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ExternalServices.ListKinds(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []ExternalServiceKindCount{
+		{Kind: "GITHUB", Count: 3},
+		{Kind: "GITLAB", Count: 2},
+		{Kind: "PHABRICATOR", Count: 1},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestExternalServices_StatusByKind(t *testing.T) {
+	ctx := dbtesting.TestContext(t)
+
+	create := func(kind string, syncErr error, synced, disabled bool) {
+		t.Helper()
+		es := &types.ExternalService{Kind: kind, DisplayName: kind, Config: "{}"}
+		if err := ExternalServices.Create(ctx, es); err != nil {
+			t.Fatal(err)
+		}
+		if synced {
+			if err := ExternalServices.RecordSyncResult(ctx, es.ID, syncErr); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if disabled {
+			if _, err := dbconn.Global.ExecContext(ctx, "UPDATE external_services SET disabled=true WHERE id=$1", es.ID); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	create("GITHUB", nil, true, false)
+	create("GITHUB", nil, true, false)
+	create("GITHUB", errors.New("x"), true, false)
+	create("GITHUB", nil, false, false)
+	create("GITHUB", errors.New("x"), true, true)
+	create("GITLAB", nil, false, false)
+
+	deleted := &types.ExternalService{Kind: "PHABRICATOR", DisplayName: "deleted", Config: "{}"}
+	if err := ExternalServices.Create(ctx, deleted); err != nil {
+		t.Fatal(err)
+	}
+	if err := ExternalServices.Delete(ctx, deleted.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ExternalServices.StatusByKind(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]ExternalServiceKindStatus{
+		"GITHUB": {Healthy: 2, Failing: 1, Unknown: 1, Disabled: 1},
+		"GITLAB": {Unknown: 1},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestExternalServices_ListAllConfigs(t *testing.T) {
+	ctx := dbtesting.TestContext(t)
+
+	github := &types.ExternalService{Kind: "GITHUB", DisplayName: "GitHub", Config: "{\n  // comment\n  \"token\": \"t\",\n}"}
+	gitlab := &types.ExternalService{Kind: "GITLAB", DisplayName: "GitLab", Config: `{"token": "u"}`}
+	deleted := &types.ExternalService{Kind: "PHABRICATOR", DisplayName: "deleted", Config: "{}"}
+	for _, es := range []*types.ExternalService{github, gitlab, deleted} {
+		if err := ExternalServices.Create(ctx, es); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := ExternalServices.Delete(ctx, deleted.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	configs, err := ExternalServices.ListAllConfigs(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, c := range configs {
+		var v interface{}
+		if err := json.Unmarshal(c.Config, &v); err != nil {
+			t.Errorf("external service %d: config is not JSON: %s", c.ID, err)
+		}
+		got = append(got, fmt.Sprintf("%d %s %v", c.ID, c.Kind, v))
+	}
+	want := []string{
+		fmt.Sprintf("%d GITLAB map[token:u]", gitlab.ID),
+		fmt.Sprintf("%d GITHUB map[token:t]", github.ID),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestExternalServices_BulkPatchConfig(t *testing.T) {
+	ctx := dbtesting.TestContext(t)
+
+	create := func(kind, config string) *types.ExternalService {
+		t.Helper()
+		es := &types.ExternalService{Kind: kind, DisplayName: kind, Config: config}
+		if err := ExternalServices.Create(ctx, es); err != nil {
+			t.Fatal(err)
+		}
+		return es
+	}
+	github1 := create("GITHUB", `{"url": "https://github.com"}`)
+	github2 := create("GITHUB", `{"url": "https://ghe.example.com", "exclude": [{"name": "a/b"}]}`)
+	gitlab := create("GITLAB", `{"url": "https://gitlab.com"}`)
+
+	configOf := func(id int64) map[string]interface{} {
+		t.Helper()
+		es, err := ExternalServices.GetByID(ctx, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var v map[string]interface{}
+		if err := jsonc.Unmarshal(es.Config, &v); err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+
+	updated, err := ExternalServices.BulkPatchConfig(ctx, ExternalServicesListOptions{Kind: "GITHUB"}, []byte(`{"exclude": [{"name": "c/d"}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated != 2 {
+		t.Errorf("got %d updated, want 2", updated)
+	}
+	for _, es := range []*types.ExternalService{github1, github2} {
+		if got, want := configOf(es.ID)["exclude"], []interface{}{map[string]interface{}{"name": "c/d"}}; !reflect.DeepEqual(got, want) {
+			t.Errorf("external service %d: got exclude %v, want %v", es.ID, got, want)
+		}
+	}
+	if _, ok := configOf(gitlab.ID)["exclude"]; ok {
+		t.Error("GITLAB external service was patched")
+	}
+
+	// A patch that makes any config invalid fails the whole batch.
+	if _, err := ExternalServices.BulkPatchConfig(ctx, ExternalServicesListOptions{}, []byte(`{"token": "${not a name}"}`)); err == nil {
+		t.Fatal("got nil error for patch producing an invalid config")
+	}
+	for _, es := range []*types.ExternalService{github1, github2, gitlab} {
+		if _, ok := configOf(es.ID)["token"]; ok {
+			t.Errorf("external service %d was patched by a failed batch", es.ID)
+		}
+	}
+}
+
+func TestExternalServices_BulkRename(t *testing.T) {
+	ctx := dbtesting.TestContext(t)
+
+	create := func(kind, displayName string) *types.ExternalService {
+		t.Helper()
+		es := &types.ExternalService{Kind: kind, DisplayName: displayName, Config: "{}"}
+		if err := ExternalServices.Create(ctx, es); err != nil {
+			t.Fatal(err)
+		}
+		return es
+	}
+	github1 := create("GITHUB", "a")
+	github2 := create("GITHUB", "b")
+	gitlab := create("GITLAB", "c")
+
+	displayNameOf := func(id int64) string {
+		t.Helper()
+		es, err := ExternalServices.GetByID(ctx, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return es.DisplayName
+	}
+
+	renamed, err := ExternalServices.BulkRename(ctx, ExternalServicesListOptions{Kind: "GITHUB"}, "prod-", "-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if renamed != 2 {
+		t.Errorf("got %d renamed, want 2", renamed)
+	}
+	want := map[int64]string{github1.ID: "prod-a-1", github2.ID: "prod-b-1", gitlab.ID: "c"}
+	for id, want := range want {
+		if got := displayNameOf(id); got != want {
+			t.Errorf("external service %d: got display name %q, want %q", id, got, want)
+		}
+	}
+
+	if _, err := ExternalServices.BulkRename(ctx, ExternalServicesListOptions{}, "", ""); err == nil {
+		t.Error("got nil error for an empty prefix and suffix")
+	}
+
+	// A read-only external service fails the whole batch.
+	if _, err := ExternalServices.Upsert(ctx, &types.ExternalService{Kind: "GITLAB", DisplayName: "managed", Config: "{}"}, ExternalServiceUpsertOptions{ReadOnly: true}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ExternalServices.BulkRename(ctx, ExternalServicesListOptions{Kind: "GITLAB"}, "x-", ""); !isReadOnlyError(err) {
+		t.Fatalf("got error %v, want read-only error", err)
+	}
+	if got := displayNameOf(gitlab.ID); got != "c" {
+		t.Errorf("got display name %q after a failed batch, want %q", got, "c")
+	}
+}
+
+func TestExternalServices_SetDisabledByKind(t *testing.T) {
+	ctx := dbtesting.TestContext(t)
+
+	for _, kind := range []string{"GITHUB", "GITHUB", "GITLAB"} {
+		if err := ExternalServices.Create(ctx, &types.ExternalService{Kind: kind, DisplayName: kind, Config: "{}"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	deleted := &types.ExternalService{Kind: "GITHUB", DisplayName: "deleted", Config: "{}"}
+	if err := ExternalServices.Create(ctx, deleted); err != nil {
+		t.Fatal(err)
+	}
+	if err := ExternalServices.Delete(ctx, deleted.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := ExternalServices.SetDisabledByKind(ctx, "GITHUB", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("got %d changed, want 2", n)
+	}
+
+	services, err := ExternalServices.List(ctx, ExternalServicesListOptions{IncludeDeleted: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, es := range services {
+		want := es.Kind == "GITHUB" && es.DeletedAt == nil
+		if es.Disabled != want {
+			t.Errorf("external service %d (%s): got disabled %v, want %v", es.ID, es.Kind, es.Disabled, want)
+		}
+	}
+
+	// Disabling again changes nothing.
+	if n, err := ExternalServices.SetDisabledByKind(ctx, "GITHUB", true); err != nil {
+		t.Fatal(err)
+	} else if n != 0 {
+		t.Errorf("got %d changed, want 0", n)
+	}
+}
+
+func TestExternalServices_GetByIDIncludingDeleted(t *testing.T) {
+	ctx := dbtesting.TestContext(t)
+
+	es := &types.ExternalService{Kind: "GITHUB", DisplayName: "GitHub", Config: `{"url": "https://github.com"}`}
+	if err := ExternalServices.Create(ctx, es); err != nil {
+		t.Fatal(err)
+	}
+	if err := ExternalServices.Delete(ctx, es.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ExternalServices.GetByID(ctx, es.ID); !errcode.IsNotFound(err) {
+		t.Errorf("GetByID: got error %v, want not found", err)
+	}
+
+	got, err := ExternalServices.GetByIDIncludingDeleted(ctx, es.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.DeletedAt == nil || got.Config != es.Config {
+		t.Errorf("got %+v, want deleted external service with config %q", got, es.Config)
+	}
+
+	if _, err := ExternalServices.GetByIDIncludingDeleted(ctx, es.ID+1); !errcode.IsNotFound(err) {
+		t.Errorf("got error %v, want not found", err)
+	}
+}
+
+func TestExternalServices_ReadsNotFound(t *testing.T) {
+	ctx := dbtesting.TestContext(t)
+
+	es := &types.ExternalService{Kind: "GITHUB", DisplayName: "GitHub", Config: "{}"}
+	if err := ExternalServices.Create(ctx, es); err != nil {
+		t.Fatal(err)
+	}
+	deleted := &types.ExternalService{Kind: "GITLAB", DisplayName: "GitLab", Config: "{}"}
+	if err := ExternalServices.Create(ctx, deleted); err != nil {
+		t.Fatal(err)
+	}
+	if err := ExternalServices.Delete(ctx, deleted.ID); err != nil {
+		t.Fatal(err)
+	}
+	missingID := deleted.ID + 1
+
+	tests := map[string]struct {
+		read         func() error
+		wantNotFound bool
+	}{
+		"GetByID": {
+			read:         func() error { _, err := ExternalServices.GetByID(ctx, es.ID); return err },
+			wantNotFound: false,
+		},
+		"GetByID missing": {
+			read:         func() error { _, err := ExternalServices.GetByID(ctx, missingID); return err },
+			wantNotFound: true,
+		},
+		"GetByID deleted": {
+			read:         func() error { _, err := ExternalServices.GetByID(ctx, deleted.ID); return err },
+			wantNotFound: true,
+		},
+		"GetByIDIncludingDeleted missing": {
+			read:         func() error { _, err := ExternalServices.GetByIDIncludingDeleted(ctx, missingID); return err },
+			wantNotFound: true,
+		},
+		"GetByDisplayName": {
+			read:         func() error { _, err := ExternalServices.GetByDisplayName(ctx, "GitHub"); return err },
+			wantNotFound: false,
+		},
+		"GetByDisplayName missing": {
+			read:         func() error { _, err := ExternalServices.GetByDisplayName(ctx, "Bitbucket"); return err },
+			wantNotFound: true,
+		},
+		"GetByDisplayName deleted": {
+			read:         func() error { _, err := ExternalServices.GetByDisplayName(ctx, "GitLab"); return err },
+			wantNotFound: true,
+		},
+		"GetByIDs": {
+			read:         func() error { _, err := ExternalServices.GetByIDs(ctx, es.ID); return err },
+			wantNotFound: false,
+		},
+		"GetByIDs missing": {
+			read:         func() error { _, err := ExternalServices.GetByIDs(ctx, es.ID, missingID); return err },
+			wantNotFound: true,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := test.read()
+			if test.wantNotFound {
+				if !errcode.IsNotFound(err) {
+					t.Errorf("got error %v, want not found", err)
+				}
+			} else if err != nil {
+				t.Errorf("got error %v, want nil", err)
+			}
+		})
+	}
+
+	got, err := ExternalServices.GetByIDs(ctx, es.ID, es.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].ID != es.ID || got[1].ID != es.ID {
+		t.Errorf("got %+v, want external service %d twice", got, es.ID)
+	}
+}
+
+func TestExternalServices_VerifyMigrationIntegrity(t *testing.T) {
+	tests := map[string]struct {
+		setup   []string
+		wantErr string
+	}{
+		"ok": {
+			setup: []string{`INSERT INTO external_services(id, kind, display_name, config, deleted_at) VALUES(0, 'MIGRATION', '', '{}', now())`},
+		},
+		"ok with lowercase sentinel": {
+			setup: []string{`INSERT INTO external_services(id, kind, display_name, config, deleted_at) VALUES(0, 'migration', '', '{}', now())`},
+		},
+		"not run": {
+			wantErr: "the external services migration has not run (there is no sentinel row with id 0)",
+		},
+		"real service with id 0": {
+			setup:   []string{`INSERT INTO external_services(id, kind, display_name, config) VALUES(0, 'GITHUB', 'GitHub', '{}')`},
+			wantErr: `external service with id 0 is not the migration sentinel (its kind is "GITHUB")`,
+		},
+		"sentinel not deleted": {
+			setup:   []string{`INSERT INTO external_services(id, kind, display_name, config) VALUES(0, 'MIGRATION', '', '{}')`},
+			wantErr: "the external services migration sentinel (id 0) is not soft-deleted",
+		},
+		"duplicate sentinel": {
+			setup: []string{
+				`INSERT INTO external_services(id, kind, display_name, config, deleted_at) VALUES(0, 'MIGRATION', '', '{}', now())`,
+				`INSERT INTO external_services(id, kind, display_name, config, deleted_at) VALUES(7, 'MIGRATION', '', '{}', now())`,
+			},
+			wantErr: "found 1 unexpected external services migration sentinel rows (ids [7])",
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := dbtesting.TestContext(t)
+			for _, q := range test.setup {
+				if _, err := dbconn.Global.ExecContext(ctx, q); err != nil {
+					t.Fatal(err)
+				}
+			}
+			err := ExternalServices.VerifyMigrationIntegrity(ctx)
+			if test.wantErr == "" {
+				if err != nil {
+					t.Errorf("got error %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != test.wantErr {
+				t.Errorf("got error %v, want %q", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestExternalServices_ConfigHistory(t *testing.T) {
+	ctx := dbtesting.TestContext(t)
+
+	es := &types.ExternalService{Kind: "GITHUB", DisplayName: "GitHub", Config: `{"v": 1}`}
+	if err := ExternalServices.Create(ctx, es); err != nil {
+		t.Fatal(err)
+	}
+	for _, config := range []string{`{"v": 2}`, `{"v": 3}`} {
+		config := config
+		if err := ExternalServices.Update(ctx, es.ID, &ExternalServiceUpdate{Config: &config}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Updates that don't change the config don't create versions.
+	displayName := "GitHub.com"
+	if err := ExternalServices.Update(ctx, es.ID, &ExternalServiceUpdate{DisplayName: &displayName}); err != nil {
+		t.Fatal(err)
+	}
+
+	configs := func(limit int) []string {
+		t.Helper()
+		versions, err := ExternalServices.GetConfigHistory(ctx, es.ID, limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var configs []string
+		for _, v := range versions {
+			if v.ExternalServiceID != es.ID {
+				t.Errorf("got version of external service %d, want %d", v.ExternalServiceID, es.ID)
+			}
+			configs = append(configs, v.Config)
+		}
+		return configs
+	}
+	if got, want := configs(10), []string{`{"v": 3}`, `{"v": 2}`, `{"v": 1}`}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got history %q, want %q", got, want)
+	}
+	if got, want := configs(1), []string{`{"v": 3}`}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got history %q, want %q", got, want)
+	}
+
+	versions, err := ExternalServices.GetConfigHistory(ctx, es.ID, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ExternalServices.RevertConfig(ctx, es.ID, versions[2].ID); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := ExternalServices.GetByID(ctx, es.ID); err != nil {
+		t.Fatal(err)
+	} else if want := `{"v": 1}`; got.Config != want {
+		t.Errorf("got config %q after revert, want %q", got.Config, want)
+	}
+	if got, want := configs(10), []string{`{"v": 1}`, `{"v": 3}`, `{"v": 2}`, `{"v": 1}`}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got history %q after revert, want %q", got, want)
+	}
+
+	// Versions of other external services can't be reverted to.
+	other := &types.ExternalService{Kind: "GITHUB", DisplayName: "other", Config: "{}"}
+	if err := ExternalServices.Create(ctx, other); err != nil {
+		t.Fatal(err)
+	}
+	if err := ExternalServices.RevertConfig(ctx, other.ID, versions[0].ID); err == nil {
+		t.Error("got nil error reverting to another external service's version")
+	}
+}
+
+func TestExternalServices_CreateValidatesKind(t *testing.T) {
+	ctx := dbtesting.TestContext(t)
+
+	for _, kind := range []string{"github", "GitHub", "GITHUB"} {
+		es := &types.ExternalService{Kind: kind, DisplayName: kind, Config: "{}"}
+		if err := ExternalServices.Create(ctx, es); err != nil {
+			t.Fatalf("kind %q: %s", kind, err)
+		}
+		got, err := ExternalServices.GetByID(ctx, es.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := "GITHUB"; got.Kind != want {
+			t.Errorf("kind %q: got stored kind %q, want %q", kind, got.Kind, want)
+		}
+	}
+
+	err := ExternalServices.Create(ctx, &types.ExternalService{Kind: "GITHB", DisplayName: "typo", Config: "{}"})
+	if _, ok := err.(UnknownKindError); !ok {
+		t.Fatalf("got error %v, want UnknownKindError", err)
+	}
+	if want := `unknown external service kind "GITHB" (valid kinds are AWSCODECOMMIT, BITBUCKETSERVER, GITHUB, GITLAB, GITOLITE, PHABRICATOR)`; err.Error() != want {
+		t.Errorf("got error %q, want %q", err, want)
+	}
+	if n, err := ExternalServices.Count(ctx, ExternalServicesListOptions{}); err != nil {
+		t.Fatal(err)
+	} else if n != 3 {
+		t.Errorf("got %d external services, want 3", n)
+	}
+}
+
+func TestExternalServices_ImportUpsert(t *testing.T) {
+	ctx := dbtesting.TestContext(t)
+
+	existing := &types.ExternalService{Kind: "GITHUB", DisplayName: "existing", Config: `{"v": 1}`}
+	if err := ExternalServices.Create(ctx, existing); err != nil {
+		t.Fatal(err)
+	}
+	deleted := &types.ExternalService{Kind: "GITHUB", DisplayName: "deleted", Config: `{"v": 1}`}
+	if err := ExternalServices.Create(ctx, deleted); err != nil {
+		t.Fatal(err)
+	}
+	if err := ExternalServices.Delete(ctx, deleted.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := ExternalServices.Import(ctx, []*types.ExternalService{
+		{Kind: "gitlab", DisplayName: "existing", Config: `{"v": 2}`},
+		{Kind: "GITHUB", DisplayName: "deleted", Config: `{"v": 2}`},
+		{Kind: "GITHUB", DisplayName: "new", Config: `{"v": 2}`},
+	}, ImportModeUpsert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("got %d results, want 3", len(results))
+	}
+	if want := (ImportResult{DisplayName: "existing", ID: existing.ID}); results[0] != want {
+		t.Errorf("got result %+v, want %+v", results[0], want)
+	}
+	if want := (ImportResult{DisplayName: "deleted", ID: deleted.ID}); results[1] != want {
+		t.Errorf("got result %+v, want %+v", results[1], want)
+	}
+	if !results[2].Created {
+		t.Errorf("got result %+v, want created", results[2])
+	}
+
+	all, err := ExternalServices.List(ctx, ExternalServicesListOptions{IncludeDeleted: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 3 {
+		t.Fatalf("got %d external services, want 3 (no duplicates)", len(all))
+	}
+	for _, es := range all {
+		if es.DeletedAt != nil {
+			t.Errorf("%s: got deleted, want undeleted", es.DisplayName)
+		}
+		if want := `{"v": 2}`; es.Config != want {
+			t.Errorf("%s: got config %q, want %q", es.DisplayName, es.Config, want)
+		}
+	}
+	if got, err := ExternalServices.GetByID(ctx, existing.ID); err != nil {
+		t.Fatal(err)
+	} else if got.Kind != "GITLAB" {
+		t.Errorf("got kind %q, want GITLAB", got.Kind)
+	}
+
+	// The config history of updated external services is preserved.
+	versions, err := ExternalServices.GetConfigHistory(ctx, existing.ID, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 2 {
+		t.Errorf("got %d config versions, want 2", len(versions))
+	}
+}
+
+func TestExternalServices_ImportUpsert_RestoreWindow(t *testing.T) {
+	ctx := dbtesting.TestContext(t)
+	conf.Mock(&schema.SiteConfiguration{ExternalServicesRestoreWindowDays: 7})
+	defer conf.Mock(nil)
+
+	// An external service deleted before the restore window can't be undeleted (as with Undelete),
+	// so importing one with the same display name creates a new external service.
+	expired := &types.ExternalService{Kind: "GITHUB", DisplayName: "expired", Config: `{"v": 1}`}
+	if err := ExternalServices.Create(ctx, expired); err != nil {
+		t.Fatal(err)
+	}
+	if err := ExternalServices.Delete(ctx, expired.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dbconn.Global.ExecContext(ctx, "UPDATE external_services SET deleted_at=now()-interval '8 days' WHERE id=$1", expired.ID); err != nil {
+		t.Fatal(err)
+	}
+	imported := importData(t, []*types.ExternalService{{Kind: "GITHUB", DisplayName: "expired", Config: `{"v": 2}`}})
+
+	plan, err := ExternalServices.ImportDryRun(ctx, imported, ImportModeUpsert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (ImportPlanItem{DisplayName: "expired", Kind: "GITHUB", Action: ImportActionCreate}); plan.Items[0] != want {
+		t.Errorf("got plan item %+v, want %+v", plan.Items[0], want)
+	}
+
+	results, err := ExternalServices.ImportData(ctx, imported, ImportModeUpsert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !results[0].Created || results[0].ID == expired.ID {
+		t.Errorf("got result %+v, want a new external service", results[0])
+	}
+	if got, err := ExternalServices.GetByIDIncludingDeleted(ctx, expired.ID); err != nil {
+		t.Fatal(err)
+	} else if got.DeletedAt == nil || got.Config != expired.Config {
+		t.Errorf("got %+v, want the expired external service unchanged", got)
+	}
+}
+
+func TestExternalServices_ImportDryRun(t *testing.T) {
+	ctx := dbtesting.TestContext(t)
+
+	existing := &types.ExternalService{Kind: "GITHUB", DisplayName: "existing", Config: "{\n  \"v\": 1\n}"}
+	if err := ExternalServices.Create(ctx, existing); err != nil {
+		t.Fatal(err)
+	}
+	deleted := &types.ExternalService{Kind: "GITHUB", DisplayName: "deleted", Config: `{"v": 1}`}
+	if err := ExternalServices.Create(ctx, deleted); err != nil {
+		t.Fatal(err)
+	}
+	if err := ExternalServices.Delete(ctx, deleted.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ExternalServices.Upsert(ctx, &types.ExternalService{Kind: "GITHUB", DisplayName: "managed", Config: "{}"}, ExternalServiceUpsertOptions{ReadOnly: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	imported := importData(t, []*types.ExternalService{
+		{Kind: "github", DisplayName: "existing", Config: "{\n  \"v\": 2\n}"},
+		{Kind: "GITHUB", DisplayName: "deleted", Config: `{"v": 1}`},
+		{Kind: "GITHUB", DisplayName: "new", Config: `{"v": 1}`},
+		{Kind: "GITHUB", DisplayName: "new", Config: `{"v": 2}`},
+		{Kind: "NOPE", DisplayName: "bad kind", Config: "{}"},
+		{Kind: "GITHUB", DisplayName: "bad config", Config: "{"},
+		{Kind: "GITHUB", DisplayName: "managed", Config: "{}"},
+	})
+	plan, err := ExternalServices.ImportDryRun(ctx, imported, ImportModeUpsert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []ImportPlanItem{
+		{DisplayName: "existing", Kind: "GITHUB", Action: ImportActionUpdate, ID: existing.ID, ConfigDiff: " {\n-  \"v\": 1\n+  \"v\": 2\n }\n"},
+		{DisplayName: "deleted", Kind: "GITHUB", Action: ImportActionUndelete, ID: deleted.ID},
+		{DisplayName: "new", Kind: "GITHUB", Action: ImportActionCreate},
+		{DisplayName: "new", Kind: "GITHUB", Action: ImportActionUpdate, ConfigDiff: "-{\"v\": 1}\n+{\"v\": 2}\n"},
+	}
+	if len(plan.Items) != 7 {
+		t.Fatalf("got %d plan items, want 7", len(plan.Items))
+	}
+	if !reflect.DeepEqual(plan.Items[:len(want)], want) {
+		t.Errorf("got plan items %+v, want %+v", plan.Items[:len(want)], want)
+	}
+	for _, item := range plan.Items[len(want):] {
+		if item.Error == "" {
+			t.Errorf("%s: got no error, want error", item.DisplayName)
+		}
+	}
+	if !plan.HasErrors() {
+		t.Error("got HasErrors false, want true")
+	}
+
+	// Nothing is changed.
+	all, err := ExternalServices.List(ctx, ExternalServicesListOptions{IncludeDeleted: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 3 {
+		t.Errorf("got %d external services, want 3", len(all))
+	}
+	if got, err := ExternalServices.GetByID(ctx, existing.ID); err != nil {
+		t.Fatal(err)
+	} else if got.Config != existing.Config {
+		t.Errorf("got config %q, want %q", got.Config, existing.Config)
+	}
+
+	// In ImportModeInsert, every external service is created.
+	plan, err = ExternalServices.ImportDryRun(ctx, imported, ImportModeInsert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (ImportPlanItem{DisplayName: "existing", Kind: "GITHUB", Action: ImportActionCreate}); plan.Items[0] != want {
+		t.Errorf("got plan item %+v, want %+v", plan.Items[0], want)
+	}
+
+	// Data that ImportData can't parse is an error, not a plan.
+	if _, err := ExternalServices.ImportDryRun(ctx, []byte(`{"kind": "GITHUB"}`), ImportModeUpsert); err == nil {
+		t.Error("got no error for invalid data, want error")
+	}
+}
+
+// importData returns the external services as data for ImportData and ImportDryRun.
+func importData(t *testing.T, externalServices []*types.ExternalService) []byte {
+	t.Helper()
+	exported := make([]ExportedExternalService, len(externalServices))
+	for i, es := range externalServices {
+		exported[i] = toExportedExternalService(es)
+	}
+	data, err := json.Marshal(exported)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestConfigDiff(t *testing.T) {
+	tests := map[string]struct {
+		oldConfig, newConfig string
+		want                 string
+	}{
+		"equal":            {`{"a": 1}`, `{"a": 1}`, ""},
+		"changed line":     {"{\n  \"a\": 1\n}", "{\n  \"a\": 2\n}", " {\n-  \"a\": 1\n+  \"a\": 2\n }\n"},
+		"added line":       {"{\n}", "{\n  \"a\": 1\n}", " {\n+  \"a\": 1\n }\n"},
+		"no final newline": {`{}`, `{"a": 1}`, "-{}\n+{\"a\": 1}\n"},
+	}
+	for name, test := range tests {
+		if got := configDiff(test.oldConfig, test.newConfig); got != test.want {
+			t.Errorf("%s: got %q, want %q", name, got, test.want)
+		}
+	}
+}
+
+func TestExternalServices_ListNeverSynced(t *testing.T) {
+	ctx := dbtesting.TestContext(t)
+
+	var services []*types.ExternalService
+	for _, displayName := range []string{"synced", "failed", "new", "migrated"} {
+		es := &types.ExternalService{Kind: "GITHUB", DisplayName: displayName, Config: "{}"}
+		if err := ExternalServices.Create(ctx, es); err != nil {
+			t.Fatal(err)
+		}
+		services = append(services, es)
+	}
+	if err := ExternalServices.RecordSyncResult(ctx, services[0].ID, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := ExternalServices.RecordSyncResult(ctx, services[1].ID, errors.New("x")); err != nil {
+		t.Fatal(err)
+	}
+
+	displayNames := func(opt ExternalServicesListOptions) []string {
+		t.Helper()
+		list, err := ExternalServices.List(ctx, opt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, es := range list {
+			names = append(names, es.DisplayName)
+		}
+		return names
+	}
+	if got, want := displayNames(ExternalServicesListOptions{NeverSynced: true, OrderBy: ExternalServicesOrderByIDAsc}), []string{"new", "migrated"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got, want := displayNames(ExternalServicesListOptions{NeverSynced: true, AfterID: services[2].ID}), []string{"migrated"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("with AfterID: got %q, want %q", got, want)
+	}
+	if got, want := displayNames(ExternalServicesListOptions{OrderBy: ExternalServicesOrderByIDAsc}), []string{"synced", "failed", "new", "migrated"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("without NeverSynced: got %q, want %q", got, want)
+	}
+}
+
+func TestExternalServices_ListFields(t *testing.T) {
+	ctx := dbtesting.TestContext(t)
+
+	es := &types.ExternalService{Kind: "GITHUB", DisplayName: "GitHub", Config: `{"token": "abc"}`}
+	if err := ExternalServices.Create(ctx, es); err != nil {
+		t.Fatal(err)
+	}
+
+	list := func(fields ExternalServiceFields) *types.ExternalService {
+		t.Helper()
+		services, err := ExternalServices.List(ctx, ExternalServicesListOptions{Fields: fields})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(services) != 1 {
+			t.Fatalf("got %d external services, want 1", len(services))
+		}
+		return services[0]
+	}
+
+	all := list(ExternalServiceFieldsAll)
+	if all.Config != es.Config || all.CreatedAt.IsZero() || all.Health == "" {
+		t.Errorf("all fields: got %+v, want all fields populated", all)
+	}
+
+	withoutConfig := list(ExternalServiceFieldsWithoutConfig)
+	if want := *all; withoutConfig.Config != "" || !reflect.DeepEqual(withoutConfig, &types.ExternalService{
+		ID: want.ID, Kind: want.Kind, DisplayName: want.DisplayName, CreatedAt: want.CreatedAt, UpdatedAt: want.UpdatedAt, Health: want.Health,
+	}) {
+		t.Errorf("without config: got %+v, want all fields except Config", withoutConfig)
+	}
+
+	if got, want := list(ExternalServiceFieldsBasic), (&types.ExternalService{ID: es.ID, Kind: "GITHUB", DisplayName: "GitHub"}); !reflect.DeepEqual(got, want) {
+		t.Errorf("basic: got %+v, want %+v", got, want)
+	}
+}
+
+func BenchmarkExternalServices_ListFields(b *testing.B) {
+	ctx := dbtesting.TestContext(b)
+
+	// Large configs (e.g., with many repositories listed) make reading them expensive.
+	config := `{"repos": [` + strings.Repeat(`"github.com/example/repository", `, 1000) + `"github.com/example/last"]}`
+	for i := 0; i < 100; i++ {
+		if err := ExternalServices.Create(ctx, &types.ExternalService{Kind: "GITHUB", DisplayName: fmt.Sprintf("GitHub %d", i), Config: config}); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	for name, fields := range map[string]ExternalServiceFields{
+		"all":            ExternalServiceFieldsAll,
+		"without config": ExternalServiceFieldsWithoutConfig,
+		"basic":          ExternalServiceFieldsBasic,
+	} {
+		b.Run(name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := ExternalServices.List(ctx, ExternalServicesListOptions{Fields: fields}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestExternalServices_ReadOnly(t *testing.T) {
+	ctx := dbtesting.TestContext(t)
+
+	es := &types.ExternalService{Kind: "github", DisplayName: "managed", Config: `{"v": 1}`}
+	created, err := ExternalServices.Upsert(ctx, es, ExternalServiceUpsertOptions{ReadOnly: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !created || es.ID == 0 || es.Kind != "GITHUB" {
+		t.Fatalf("got created %v and %+v, want a new GITHUB external service", created, es)
+	}
+	if got, err := ExternalServices.GetByID(ctx, es.ID); err != nil {
+		t.Fatal(err)
+	} else if !got.ReadOnly {
+		t.Error("got writable, want read-only")
+	}
+
+	// Read-only external services can't be updated, deleted, or imported over.
+	config := `{"v": 2}`
+	if err := ExternalServices.Update(ctx, es.ID, &ExternalServiceUpdate{Config: &config}); !isReadOnlyError(err) {
+		t.Errorf("Update: got error %v, want read-only error", err)
+	}
+	if err := ExternalServices.Delete(ctx, es.ID); !isReadOnlyError(err) {
+		t.Errorf("Delete: got error %v, want read-only error", err)
+	}
+	if _, err := ExternalServices.Import(ctx, []*types.ExternalService{{Kind: "GITHUB", DisplayName: "managed", Config: config}}, ImportModeUpsert); !isReadOnlyError(err) {
+		t.Errorf("Import: got error %v, want read-only error", err)
+	}
+	if err := ExternalServices.SetNamespace(ctx, es.ID, nil); !isReadOnlyError(err) {
+		t.Errorf("SetNamespace: got error %v, want read-only error", err)
+	}
+	if _, err := ExternalServices.BulkPatchConfig(ctx, ExternalServicesListOptions{Kind: "GITHUB"}, []byte(`{"v": 3}`)); !isReadOnlyError(err) {
+		t.Errorf("BulkPatchConfig: got error %v, want read-only error", err)
+	}
+	if n, err := ExternalServices.SetDisabledByKind(ctx, "GITHUB", true); err != nil {
+		t.Fatal(err)
+	} else if n != 0 {
+		t.Errorf("SetDisabledByKind: got %d disabled, want 0", n)
+	}
+	if got, err := ExternalServices.GetByID(ctx, es.ID); err != nil {
+		t.Fatal(err)
+	} else if got.Config != `{"v": 1}` || got.Disabled {
+		t.Errorf("got config %q and disabled %v, want them unchanged", got.Config, got.Disabled)
+	}
+
+	// Upsert updates it in place, and can make it writable again.
+	created, err = ExternalServices.Upsert(ctx, &types.ExternalService{Kind: "GITHUB", DisplayName: "managed", Config: config}, ExternalServiceUpsertOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created {
+		t.Error("got created, want updated")
+	}
+	got, err := ExternalServices.GetByID(ctx, es.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ReadOnly || got.Config != config {
+		t.Errorf("got read-only %v and config %q, want writable with config %q", got.ReadOnly, got.Config, config)
+	}
+	if err := ExternalServices.Delete(ctx, es.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := ExternalServices.Delete(ctx, es.ID); !errcode.IsNotFound(err) {
+		t.Errorf("got error %v, want not found", err)
+	}
+}
+
+func isReadOnlyError(err error) bool {
+	_, ok := err.(readOnlyExternalServiceError)
+	return ok
+}
+
+func TestExternalServices_UppercaseKindMigration(t *testing.T) {
+	ctx := dbtesting.TestContext(t)
+
+	// Bypass Create, which would normalize the kind.
+	if _, err := dbconn.Global.ExecContext(ctx, "INSERT INTO external_services(kind, display_name, config) VALUES('GitHub', 'mixed case', '{}')"); err != nil {
+		t.Fatal(err)
+	}
+
+	up, err := migrations.Asset("1528395567_.up.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The migration is idempotent.
+	for i := 0; i < 2; i++ {
+		if _, err := dbconn.Global.ExecContext(ctx, string(up)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	services, err := ExternalServices.List(ctx, ExternalServicesListOptions{Kind: "GITHUB"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(services) != 1 || services[0].Kind != "GITHUB" {
+		t.Fatalf("got %+v, want 1 external service of kind GITHUB", services)
+	}
+
+	// Update keeps kinds uppercase.
+	kind := "gitlab"
+	if err := ExternalServices.Update(ctx, services[0].ID, &ExternalServiceUpdate{Kind: &kind}); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := ExternalServices.GetByID(ctx, services[0].ID); err != nil {
+		t.Fatal(err)
+	} else if got.Kind != "GITLAB" {
+		t.Errorf("got kind %q, want GITLAB", got.Kind)
+	}
+	if kind := "GITLB"; ExternalServices.Update(ctx, services[0].ID, &ExternalServiceUpdate{Kind: &kind}) == nil {
+		t.Error("got nil error updating to an unknown kind")
+	}
+}
+
+func TestValidateConfig_UnknownKind(t *testing.T) {
+	_, err := validateConfig("GITHB", `{"url": "https://github.com"}`, configValidationOptions{})
+	if e, ok := err.(UnknownKindError); !ok || e.Kind != "GITHB" {
+		t.Fatalf("got error %v, want UnknownKindError for GITHB", err)
+	}
+	if !errcode.IsBadRequest(err) {
+		t.Errorf("got error %v, want a bad request error", err)
+	}
+	if want := "valid kinds are AWSCODECOMMIT, BITBUCKETSERVER, GITHUB, GITLAB, GITOLITE, PHABRICATOR"; !strings.Contains(err.Error(), want) {
+		t.Errorf("got error %q, want it to list the valid kinds", err)
+	}
+
+	// Configs of known kinds are still validated.
+	if _, err := validateConfig("github", `{"url": `, configValidationOptions{}); err == nil {
+		t.Error("got nil error for an invalid config of a known kind")
+	} else if _, ok := err.(UnknownKindError); ok {
+		t.Errorf("got UnknownKindError for known kind: %v", err)
+	}
+}
+
+func TestMigrateJsonConfigToExternalServices_Retry(t *testing.T) {
+	conf.Mock(&schema.SiteConfiguration{ExperimentalFeatures: &schema.ExperimentalFeatures{ExternalServices: "enabled"}})
+	defer conf.Mock(nil)
+	origAttempt, origDelays := migrateJsonConfigAttempt, migrationRetryDelays
+	defer func() {
+		migrateJsonConfigAttempt, migrationRetryDelays = origAttempt, origDelays
+		migrated = false
+	}()
+	migrationRetryDelays = []time.Duration{time.Millisecond, time.Millisecond}
+
+	// stub returns a migration attempt that fails with the errors (in order) and then succeeds.
+	var calls int
+	stub := func(errs ...error) func(context.Context) error {
+		calls = 0
+		return func(context.Context) error {
+			calls++
+			if calls <= len(errs) {
+				return errs[calls-1]
+			}
+			return nil
+		}
+	}
+	tests := map[string]struct {
+		errs         []error
+		wantCalls    int
+		wantMigrated bool
+	}{
+		"success":                {wantCalls: 1, wantMigrated: true},
+		"concurrent migration":   {errs: []error{&pq.Error{Constraint: "external_services_pkey"}}, wantCalls: 1, wantMigrated: true},
+		"transient then success": {errs: []error{driver.ErrBadConn, &pq.Error{Code: "40P01"}}, wantCalls: 3, wantMigrated: true},
+		"transient until retries are exhausted": {
+			errs:      []error{driver.ErrBadConn, driver.ErrBadConn, driver.ErrBadConn},
+			wantCalls: 3,
+		},
+		"not retriable": {errs: []error{errors.New("x")}, wantCalls: 1, wantMigrated: true},
+	}
+	for label, test := range tests {
+		t.Run(label, func(t *testing.T) {
+			migrated = false
+			migrateJsonConfigAttempt = stub(test.errs...)
+			ExternalServices.migrateJsonConfigToExternalServices(context.Background())
+			if calls != test.wantCalls {
+				t.Errorf("got %d attempts, want %d", calls, test.wantCalls)
+			}
+			if migrated != test.wantMigrated {
+				t.Errorf("got migrated %v, want %v", migrated, test.wantMigrated)
+			}
+
+			// The migration is only attempted again if it didn't complete.
+			migrateJsonConfigAttempt = stub()
+			ExternalServices.migrateJsonConfigToExternalServices(context.Background())
+			if want := map[bool]int{true: 0, false: 1}[test.wantMigrated]; calls != want {
+				t.Errorf("got %d attempts on the next call, want %d", calls, want)
+			}
+		})
+	}
+}
+
+func TestMigrateJsonConfigToExternalServices_UnlockedWhileWaiting(t *testing.T) {
+	conf.Mock(&schema.SiteConfiguration{ExperimentalFeatures: &schema.ExperimentalFeatures{ExternalServices: "enabled"}})
+	defer conf.Mock(nil)
+	origAttempt, origDelays := migrateJsonConfigAttempt, migrationRetryDelays
+	defer func() {
+		migrateJsonConfigAttempt, migrationRetryDelays = origAttempt, origDelays
+		migrated = false
+	}()
+	migrationRetryDelays = []time.Duration{200 * time.Millisecond}
+
+	// While the first attempt's retry is pending, migrateMu must be acquirable.
+	unlocked := make(chan struct{})
+	var calls int
+	migrateJsonConfigAttempt = func(context.Context) error {
+		calls++
+		if calls == 1 {
+			go func() {
+				migrateMu.Lock()
+				migrateMu.Unlock()
+				close(unlocked)
+			}()
+			return driver.ErrBadConn
+		}
+		select {
+		case <-unlocked:
+		default:
+			t.Error("migrateMu was held while waiting to retry")
+		}
+		return nil
+	}
+	migrated = false
+	ExternalServices.migrateJsonConfigToExternalServices(context.Background())
+	if calls != 2 || !migrated {
+		t.Errorf("got %d attempts and migrated %v, want 2 and true", calls, migrated)
+	}
+}
+
+func TestMigrateJsonConfigToExternalServices_NoDuplicates(t *testing.T) {
+	ctx := dbtesting.TestContext(t)
+	conf.Mock(&schema.SiteConfiguration{
+		ExperimentalFeatures: &schema.ExperimentalFeatures{ExternalServices: "enabled"},
+		Github: []*schema.GitHubConnection{
+			{Url: "https://github.com", Token: "a"},
+			{Url: "https://github.example.com", Token: "b"},
+		},
+	})
+	defer conf.Mock(nil)
+	defer func() { migrated = false }()
+
+	// An external service with the same config as the second connection (but formatted
+	// differently) already exists.
+	if err := ExternalServices.Create(ctx, &types.ExternalService{Kind: "GITHUB", DisplayName: "existing", Config: `{"token": "b", "url": "https://github.example.com", /* comment */}`}); err != nil {
+		t.Fatal(err)
+	}
+
+	displayNames := func() []string {
+		t.Helper()
+		rows, err := dbconn.Global.QueryContext(ctx, "SELECT display_name FROM external_services WHERE id<>0 ORDER BY id")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rows.Close()
+		var names []string
+		for rows.Next() {
+			var name string
+			if err := rows.Scan(&name); err != nil {
+				t.Fatal(err)
+			}
+			names = append(names, name)
+		}
+		return names
+	}
+	want := []string{"existing", "Migrated GitHub 1"}
+
+	migrated = false
+	ExternalServices.migrateJsonConfigToExternalServices(ctx)
+	if got := displayNames(); !reflect.DeepEqual(got, want) {
+		t.Errorf("after the first run: got %v, want %v", got, want)
+	}
+
+	// Re-running the migration without the sentinel row is a no-op.
+	if _, err := dbconn.Global.ExecContext(ctx, "DELETE FROM external_services WHERE id=0"); err != nil {
+		t.Fatal(err)
+	}
+	migrated = false
+	ExternalServices.migrateJsonConfigToExternalServices(ctx)
+	if !migrated {
+		t.Error("got migrated false after the second run, want true")
+	}
+	if got := displayNames(); !reflect.DeepEqual(got, want) {
+		t.Errorf("after the second run: got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,215 @@
+package db
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/sourcegraph/sourcegraph/pkg/conf"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+// kindConfigValidators are the validation rules specific to each kind of external service, beyond
+// the checks that validateConfig performs for all kinds. Each is called with the decoded config
+// (if it is a JSON object) and returns an error if the config is invalid, and warnings about
+// problems that don't prevent the config from being saved.
+var kindConfigValidators = map[string]func(config map[string]interface{}) (warnings []string, err error){
+	"AWSCODECOMMIT":   validateAWSCodeCommitRegion,
+	"BITBUCKETSERVER": allConfigValidators(validateCodeHostURL, validateCredentialsPresent("token", "password")),
+	"GITHUB":          allConfigValidators(validateCodeHostURL, validateCredentialsPresent("token")),
+	"GITLAB":          allConfigValidators(validateCodeHostURL, validateCredentialsPresent("token")),
+	"PHABRICATOR":     validatePhabricatorConfig,
+}
+
+// allConfigValidators returns a validation rule that applies all of the rules, returning all of
+// their warnings (or the first error).
+func allConfigValidators(validators ...func(config map[string]interface{}) ([]string, error)) func(config map[string]interface{}) ([]string, error) {
+	return func(config map[string]interface{}) ([]string, error) {
+		var warnings []string
+		for _, validate := range validators {
+			w, err := validate(config)
+			if err != nil {
+				return nil, err
+			}
+			warnings = append(warnings, w...)
+		}
+		return warnings, nil
+	}
+}
+
+// validateCredentialsPresent returns a validation rule that warns if none of the fields (which hold
+// credentials, such as "token") is set. Without credentials, the code host only allows access to
+// public repositories, which is usually a mistake. It is not an error, because anonymous access is
+// occasionally intended.
+func validateCredentialsPresent(fields ...string) func(config map[string]interface{}) ([]string, error) {
+	return func(config map[string]interface{}) ([]string, error) {
+		for _, field := range fields {
+			if s, _ := config[field].(string); s != "" {
+				return nil, nil
+			}
+		}
+		quoted := make([]string, len(fields))
+		for i, field := range fields {
+			quoted[i] = strconv.Quote(field)
+		}
+		return []string{fmt.Sprintf("no credentials are set (%s), so only public repositories can be accessed", strings.Join(quoted, " or "))}, nil
+	}
+}
+
+// kindConfigTypes returns a new value of the type of the config of each kind of external service,
+// for checkUnknownFields.
+var kindConfigTypes = map[string]func() interface{}{
+	"AWSCODECOMMIT":   func() interface{} { return &schema.AWSCodeCommitConnection{} },
+	"BITBUCKETSERVER": func() interface{} { return &schema.BitbucketServerConnection{} },
+	"GITHUB":          func() interface{} { return &schema.GitHubConnection{} },
+	"GITLAB":          func() interface{} { return &schema.GitLabConnection{} },
+	"GITOLITE":        func() interface{} { return &schema.GitoliteConnection{} },
+	"PHABRICATOR":     func() interface{} { return &schema.PhabricatorConnection{} },
+}
+
+// checkUnknownFields returns an error naming the first property of the (normalized) config that is
+// not in the schema of the kind, such as "tokens" (instead of "token") in a GitHub config.
+func checkUnknownFields(kind string, config []byte) error {
+	newConfig, ok := kindConfigTypes[kind]
+	if !ok {
+		return nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(config))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(newConfig()); err != nil {
+		return fmt.Errorf("invalid %s config: %s", kind, strings.TrimPrefix(err.Error(), "json: "))
+	}
+	return nil
+}
+
+// validatePhabricatorConfig checks that each entry of the "repos" list has both a path and a
+// callsign. Otherwise the repository can never be linked to Phabricator.
+func validatePhabricatorConfig(config map[string]interface{}) ([]string, error) {
+	repos, ok := config["repos"].([]interface{})
+	if !ok {
+		return nil, nil
+	}
+	for i, v := range repos {
+		repo, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("repos[%d] must be an object with path and callsign fields", i)
+		}
+		for _, field := range []string{"path", "callsign"} {
+			if s, _ := repo[field].(string); s == "" {
+				return nil, fmt.Errorf("repos[%d] is missing the required field %q", i, field)
+			}
+		}
+	}
+	return nil, nil
+}
+
+// validateCodeHostURL checks that the "url" of the code host is an absolute http(s) URL of the
+// code host's root (such as https://github.example.com), and warns if it is not https. A url that
+// references a variable (which isn't expanded yet) is not checked.
+func validateCodeHostURL(config map[string]interface{}) ([]string, error) {
+	s, _ := config["url"].(string)
+	if s == "" || strings.Contains(s, "${") {
+		return nil, nil
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, fmt.Errorf("url %q is not a valid URL: %s", s, err)
+	}
+	switch {
+	case u.Scheme == "":
+		return nil, fmt.Errorf("url %q is missing the scheme (such as https://)", s)
+	case u.Scheme != "http" && u.Scheme != "https":
+		return nil, fmt.Errorf("url %q must use the http or https scheme, not %q", s, u.Scheme)
+	case u.Host == "":
+		return nil, fmt.Errorf("url %q is missing the host", s)
+	case u.Path != "" && u.Path != "/":
+		return nil, fmt.Errorf("url %q has a path (%q), but it must be the URL of the code host's root", s, u.Path)
+	}
+	if u.Scheme == "http" {
+		return []string{fmt.Sprintf("url %q uses http, so credentials and code are sent unencrypted (use https if the code host supports it)", s)}, nil
+	}
+	return nil, nil
+}
+
+// awsCodeCommitRegions are the AWS regions in which AWS CodeCommit is available (the enum of the
+// AWSCodeCommitConnection region property in the site configuration schema). Other regions can be
+// allowed with the externalServices.awsCodeCommitExtraRegions site configuration property.
+var awsCodeCommitRegions = []string{
+	"ap-northeast-1",
+	"ap-northeast-2",
+	"ap-south-1",
+	"ap-southeast-1",
+	"ap-southeast-2",
+	"ca-central-1",
+	"eu-central-1",
+	"eu-west-1",
+	"eu-west-2",
+	"sa-east-1",
+	"us-east-1",
+	"us-east-2",
+	"us-west-1",
+	"us-west-2",
+}
+
+// validateAWSCodeCommitRegion checks that the "region" is one in which AWS CodeCommit is available
+// (see awsCodeCommitRegions), so that a mistyped region is reported when the config is saved
+// instead of as an endpoint failure when it is synced. A region that references a variable is not
+// checked.
+func validateAWSCodeCommitRegion(config map[string]interface{}) ([]string, error) {
+	region, _ := config["region"].(string)
+	if region == "" || strings.Contains(region, "${") {
+		return nil, nil
+	}
+	regions := append(append([]string(nil), awsCodeCommitRegions...), conf.Get().ExternalServicesAwsCodeCommitExtraRegions...)
+	for _, r := range regions {
+		if r == region {
+			return nil, nil
+		}
+	}
+	return nil, fmt.Errorf("region %q is not a known AWS CodeCommit region (did you mean %q?); to use a region that is not known, add it to the externalServices.awsCodeCommitExtraRegions site configuration property", region, closestString(region, regions))
+}
+
+// closestString returns the candidate with the smallest edit distance to s (the first, if there
+// is a tie).
+func closestString(s string, candidates []string) string {
+	var closest string
+	min := -1
+	for _, c := range candidates {
+		if d := editDistance(s, c); min == -1 || d < min {
+			closest, min = c, d
+		}
+	}
+	return closest
+}
+
+// editDistance returns the Levenshtein distance between a and b (the number of single-byte
+// insertions, deletions, and substitutions needed to change one into the other).
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = minInt(prev[j]+1, minInt(cur[j-1]+1, prev[j-1]+cost))
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
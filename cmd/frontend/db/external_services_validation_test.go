@@ -0,0 +1,354 @@
+package db
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/pkg/conf"
+	"github.com/sourcegraph/sourcegraph/pkg/version"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+func TestValidateConfig_Phabricator(t *testing.T) {
+	tests := map[string]struct {
+		config  string
+		wantErr string
+	}{
+		"valid": {
+			config: `{"url": "https://phabricator.example.com", "repos": [{"path": "github.com/foo/bar", "callsign": "BAR"}]}`,
+		},
+		"no repos": {
+			config: `{"url": "https://phabricator.example.com"}`,
+		},
+		"missing callsign": {
+			config:  `{"repos": [{"path": "github.com/foo/bar", "callsign": "BAR"}, {"path": "github.com/foo/baz"}]}`,
+			wantErr: `repos[1] is missing the required field "callsign"`,
+		},
+		"empty path": {
+			config:  `{"repos": [{"path": "", "callsign": "BAR"}]}`,
+			wantErr: `repos[0] is missing the required field "path"`,
+		},
+		"not an object": {
+			config:  `{"repos": ["github.com/foo/bar"]}`,
+			wantErr: `repos[0] must be an object with path and callsign fields`,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := validateConfig("PHABRICATOR", test.config, configValidationOptions{})
+			if test.wantErr == "" {
+				if err != nil {
+					t.Fatalf("got error %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != test.wantErr {
+				t.Errorf("got error %v, want %q", err, test.wantErr)
+			}
+		})
+	}
+
+	// Other kinds don't have the rule.
+	if _, err := validateConfig("GITHUB", `{"repos": [{}]}`, configValidationOptions{}); err != nil {
+		t.Errorf("GITHUB: got error %v, want nil", err)
+	}
+}
+
+func TestValidateConfig_CodeHostURL(t *testing.T) {
+	// The configs have a token, so that they aren't warned about for missing credentials.
+	tests := map[string]struct {
+		config       string
+		wantWarnings []string
+		wantErr      string
+	}{
+		"valid":          {config: `{"token": "t", "url": "https://github.example.com"}`},
+		"trailing slash": {config: `{"token": "t", "url": "https://github.example.com/"}`},
+		"no url":         {config: `{"token": "t"}`},
+		"variable":       {config: `{"token": "t", "url": "${SRC_EXTSVC_SECRET_GITHUB_URL}"}`},
+		"http": {
+			config:       `{"token": "t", "url": "http://github.example.com"}`,
+			wantWarnings: []string{`url "http://github.example.com" uses http, so credentials and code are sent unencrypted (use https if the code host supports it)`},
+		},
+		"missing scheme": {
+			config:  `{"token": "t", "url": "github.example.com"}`,
+			wantErr: `url "github.example.com" is missing the scheme (such as https://)`,
+		},
+		"other scheme": {
+			config:  `{"token": "t", "url": "ssh://github.example.com"}`,
+			wantErr: `url "ssh://github.example.com" must use the http or https scheme, not "ssh"`,
+		},
+		"missing host": {
+			config:  `{"token": "t", "url": "https:///foo"}`,
+			wantErr: `url "https:///foo" is missing the host`,
+		},
+		"has a path": {
+			config:  `{"token": "t", "url": "https://github.example.com/foo/bar"}`,
+			wantErr: `url "https://github.example.com/foo/bar" has a path ("/foo/bar"), but it must be the URL of the code host's root`,
+		},
+	}
+	for _, kind := range []string{"GITHUB", "GITLAB", "BITBUCKETSERVER"} {
+		for name, test := range tests {
+			t.Run(kind+" "+name, func(t *testing.T) {
+				warnings, err := validateConfig(kind, test.config, configValidationOptions{})
+				if name == "variable" {
+					// The undefined variable is warned about separately.
+					warnings = nil
+				}
+				if test.wantErr == "" {
+					if err != nil {
+						t.Fatalf("got error %v, want nil", err)
+					}
+				} else if err == nil || err.Error() != test.wantErr {
+					t.Fatalf("got error %v, want %q", err, test.wantErr)
+				}
+				if !reflect.DeepEqual(warnings, test.wantWarnings) {
+					t.Errorf("got warnings %q, want %q", warnings, test.wantWarnings)
+				}
+			})
+		}
+	}
+}
+
+func TestValidateConfig_CredentialsPresent(t *testing.T) {
+	tests := []struct {
+		kind, config string
+		wantWarnings []string
+	}{
+		{"GITHUB", `{"url": "https://github.com", "token": "t"}`, nil},
+		{"GITHUB", `{"url": "https://github.com", "token": "${SRC_EXTSVC_SECRET_GITHUB_TOKEN}"}`, nil},
+		{"GITHUB", `{"url": "https://github.com"}`, []string{`no credentials are set ("token"), so only public repositories can be accessed`}},
+		{"GITHUB", `{"url": "https://github.com", "token": ""}`, []string{`no credentials are set ("token"), so only public repositories can be accessed`}},
+		{"GITLAB", `{"url": "https://gitlab.com"}`, []string{`no credentials are set ("token"), so only public repositories can be accessed`}},
+		{"BITBUCKETSERVER", `{"url": "https://bitbucket.example.com", "token": "t"}`, nil},
+		{"BITBUCKETSERVER", `{"url": "https://bitbucket.example.com", "username": "u", "password": "p"}`, nil},
+		{"BITBUCKETSERVER", `{"url": "https://bitbucket.example.com", "username": "u"}`, []string{`no credentials are set ("token" or "password"), so only public repositories can be accessed`}},
+		{"GITOLITE", `{"host": "git@gitolite.example.com"}`, nil},
+	}
+	for _, test := range tests {
+		warnings, err := validateConfig(test.kind, test.config, configValidationOptions{})
+		if err != nil {
+			t.Fatalf("%s %s: %s", test.kind, test.config, err)
+		}
+		if test.config == `{"url": "https://github.com", "token": "${SRC_EXTSVC_SECRET_GITHUB_TOKEN}"}` {
+			// The undefined variable is warned about separately.
+			warnings = nil
+		}
+		if !reflect.DeepEqual(warnings, test.wantWarnings) {
+			t.Errorf("%s %s: got warnings %q, want %q", test.kind, test.config, warnings, test.wantWarnings)
+		}
+	}
+}
+
+func TestValidateConfig_TrailingContent(t *testing.T) {
+	tests := map[string]struct {
+		config  string
+		wantErr string
+	}{
+		"trailing garbage": {
+			config:  `{}trailing`,
+			wantErr: `failed to parse JSON: unexpected "trailing" after the JSON value (at offset 2)`,
+		},
+		"two values": {
+			config:  `{} {}`,
+			wantErr: `failed to parse JSON: unexpected "{}" after the JSON value (at offset 3)`,
+		},
+		"trailing comments": {
+			config: "{\"url\": \"https://github.example.com\"}\n// comment\n/* another comment */\n",
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := validateConfig("GITHUB", test.config, configValidationOptions{})
+			if test.wantErr == "" {
+				if err != nil {
+					t.Fatalf("got error %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != test.wantErr {
+				t.Errorf("got error %v, want %q", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateConfig_RejectUnknownFields(t *testing.T) {
+	tests := map[string]struct {
+		config        string
+		wantStrictErr string
+	}{
+		"known fields": {
+			config: `{"url": "https://github.example.com", "token": "t", "repos": ["a/b"]}`,
+		},
+		"typo": {
+			config:        `{"url": "https://github.example.com", "tokens": "t"}`,
+			wantStrictErr: `invalid GITHUB config: unknown field "tokens"`,
+		},
+		"typo in a nested object": {
+			config:        `{"url": "https://github.example.com", "authorization": {"ttlSecond": 1}}`,
+			wantStrictErr: `invalid GITHUB config: unknown field "ttlSecond"`,
+		},
+		"newer field": {
+			config:        `{"url": "https://github.example.com", "someFieldFromANewerVersion": true}`,
+			wantStrictErr: `invalid GITHUB config: unknown field "someFieldFromANewerVersion"`,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			// Unknown fields are allowed by default.
+			if _, err := validateConfig("GITHUB", test.config, configValidationOptions{}); err != nil {
+				t.Errorf("lenient: got error %v, want nil", err)
+			}
+
+			_, err := validateConfig("GITHUB", test.config, configValidationOptions{RejectUnknownFields: true})
+			if test.wantStrictErr == "" {
+				if err != nil {
+					t.Errorf("strict: got error %v, want nil", err)
+				}
+			} else if err == nil || err.Error() != test.wantStrictErr {
+				t.Errorf("strict: got error %v, want %q", err, test.wantStrictErr)
+			}
+		})
+	}
+}
+
+func TestValidateConfig_AWSCodeCommitRegion(t *testing.T) {
+	conf.Mock(&schema.SiteConfiguration{ExternalServicesAwsCodeCommitExtraRegions: []string{"us-gov-west-1"}})
+	defer conf.Mock(nil)
+
+	tests := map[string]struct {
+		config  string
+		wantErr string
+	}{
+		"known region":  {config: `{"region": "eu-west-2"}`},
+		"extra region":  {config: `{"region": "us-gov-west-1"}`},
+		"no region":     {config: `{}`},
+		"variable":      {config: `{"region": "${SRC_EXTSVC_SECRET_AWS_REGION}"}`},
+		"mistyped":      {config: `{"region": "us-est-1"}`, wantErr: `region "us-est-1" is not a known AWS CodeCommit region (did you mean "us-east-1"?)`},
+		"unknown extra": {config: `{"region": "us-gov-east-1"}`, wantErr: `region "us-gov-east-1" is not a known AWS CodeCommit region (did you mean "us-gov-west-1"?)`},
+	}
+	for name, test := range tests {
+		_, err := validateConfig("AWSCODECOMMIT", test.config, configValidationOptions{})
+		if test.wantErr == "" {
+			if err != nil {
+				t.Errorf("%s: got error %v, want nil", name, err)
+			}
+		} else if err == nil || !strings.HasPrefix(err.Error(), test.wantErr) {
+			t.Errorf("%s: got error %v, want it to start with %q", name, err, test.wantErr)
+		}
+	}
+}
+
+// TestAWSCodeCommitRegions_MatchSchema checks that awsCodeCommitRegions is the same as the enum in
+// the site configuration schema, so that the list is only updated in one place.
+func TestAWSCodeCommitRegions_MatchSchema(t *testing.T) {
+	var siteSchema struct {
+		Definitions struct {
+			AWSCodeCommitConnection struct {
+				Properties struct {
+					Region struct {
+						Enum []string
+					}
+				}
+			}
+		}
+	}
+	if err := json.Unmarshal([]byte(schema.SiteSchemaJSON), &siteSchema); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := awsCodeCommitRegions, siteSchema.Definitions.AWSCodeCommitConnection.Properties.Region.Enum; !reflect.DeepEqual(got, want) {
+		t.Errorf("got regions %q, want the schema's enum %q", got, want)
+	}
+}
+
+func TestValidateConfigVersioned(t *testing.T) {
+	tests := map[string]struct {
+		kind, config, version string
+		wantErr               string
+	}{
+		"valid":           {kind: "GITHUB", config: `{"url": "https://github.example.com", "token": "t"}`, version: version.Version()},
+		"running version": {kind: "github", config: `{"url": "https://github.example.com", "token": "t"}`},
+		"unknown field":   {kind: "GITLAB", config: `{"url": "https://gitlab.example.com", "token": "t", "tokens": "t"}`, wantErr: "invalid GITLAB config for version " + version.Version() + ": "},
+		"unknown version": {kind: "GITHUB", config: `{}`, version: "0.0", wantErr: `unknown schema version "0.0"`},
+		"wrong type":      {kind: "GITOLITE", config: `{"prefix": "gitolite.example.com/", "host": "git@gitolite.example.com", "blacklist": 1}`, wantErr: "invalid GITOLITE config for version " + version.Version() + ": "},
+	}
+	for name, test := range tests {
+		_, err := validateConfigVersioned(test.kind, test.config, test.version)
+		if test.wantErr == "" {
+			if err != nil {
+				t.Errorf("%s: got error %v, want nil", name, err)
+			}
+		} else if err == nil || !strings.HasPrefix(err.Error(), test.wantErr) {
+			t.Errorf("%s: got error %v, want it to start with %q", name, err, test.wantErr)
+		}
+	}
+}
+
+func TestValidateConfigForSchema(t *testing.T) {
+	// A schema (as of a hypothetical other version) in which GitHub configs require a property that
+	// the running version's schema doesn't, and which has no GitLab definition.
+	const siteSchema = `{
+		"definitions": {
+			"GitHubConnection": {
+				"type": "object",
+				"required": ["url", "token", "org"],
+				"properties": {"url": {"type": "string"}, "token": {"type": "string"}, "org": {"type": "string"}}
+			}
+		}
+	}`
+	tests := map[string]struct {
+		kind, config string
+		wantErr      string
+	}{
+		"valid":            {kind: "GITHUB", config: `{"url": "https://github.example.com", "token": "t", "org": "o"}`},
+		"missing required": {kind: "GITHUB", config: `{"url": "https://github.example.com", "token": "t"}`, wantErr: "invalid GITHUB config for version next: "},
+		"unsupported kind": {kind: "GITLAB", config: `{"url": "https://gitlab.example.com", "token": "t"}`, wantErr: "GITLAB external services are not supported in version next"},
+	}
+	for name, test := range tests {
+		_, err := validateConfigForSchema(test.kind, test.config, "next", siteSchema)
+		if test.wantErr == "" {
+			if err != nil {
+				t.Errorf("%s: got error %v, want nil", name, err)
+			}
+		} else if err == nil || !strings.HasPrefix(err.Error(), test.wantErr) {
+			t.Errorf("%s: got error %v, want it to start with %q", name, err, test.wantErr)
+		}
+	}
+}
+
+// TestKindSchemaDefinitions checks that every kind has a config definition in the site
+// configuration schema.
+func TestKindSchemaDefinitions(t *testing.T) {
+	var s struct {
+		Definitions map[string]json.RawMessage
+	}
+	if err := json.Unmarshal([]byte(schema.SiteSchemaJSON), &s); err != nil {
+		t.Fatal(err)
+	}
+	for _, kind := range externalServiceKinds {
+		if _, ok := s.Definitions[kindSchemaDefinitions[kind]]; !ok {
+			t.Errorf("no definition for %s", kind)
+		}
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"", "abc", 3},
+		{"us-east-1", "us-east-1", 0},
+		{"us-est-1", "us-east-1", 1},
+		{"kitten", "sitting", 3},
+	}
+	for _, test := range tests {
+		if got := editDistance(test.a, test.b); got != test.want {
+			t.Errorf("editDistance(%q, %q): got %d, want %d", test.a, test.b, got, test.want)
+		}
+	}
+}
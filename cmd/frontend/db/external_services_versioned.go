@@ -0,0 +1,107 @@
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/sourcegraph/sourcegraph/pkg/jsonc"
+	"github.com/sourcegraph/sourcegraph/pkg/version"
+	"github.com/sourcegraph/sourcegraph/schema"
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// kindSchemaDefinitions are the names of the definitions in the site configuration schema of the
+// config of each kind of external service.
+var kindSchemaDefinitions = map[string]string{
+	"AWSCODECOMMIT":   "AWSCodeCommitConnection",
+	"BITBUCKETSERVER": "BitbucketServerConnection",
+	"GITHUB":          "GitHubConnection",
+	"GITLAB":          "GitLabConnection",
+	"GITOLITE":        "GitoliteConnection",
+	"PHABRICATOR":     "PhabricatorConnection",
+}
+
+// validateConfigVersioned validates an external service config as validateConfig does, and also
+// against the JSON Schema of the kind's config in the site configuration schema of the version of
+// Sourcegraph (see schema.SiteSchemaJSONForVersion). An empty schemaVersion is the running version
+// (version.Version()).
+func validateConfigVersioned(kind, config, schemaVersion string) (warnings []string, err error) {
+	if schemaVersion == "" {
+		schemaVersion = version.Version()
+	}
+	siteSchema, ok := schema.SiteSchemaJSONForVersion(schemaVersion)
+	if !ok {
+		return nil, fmt.Errorf("unknown schema version %q (only the schema of the running version, %q, is known)", schemaVersion, version.Version())
+	}
+	return validateConfigForSchema(kind, config, schemaVersion, siteSchema)
+}
+
+// validateConfigForSchema validates an external service config as validateConfig does, and also
+// against the JSON Schema of the kind's config in siteSchema, a site configuration schema (the
+// content of site.schema.json) of the version of Sourcegraph named by schemaVersion (which is only
+// used in errors). The schema may be supplied by the caller, so that configs can be checked before
+// upgrading to a version whose schema isn't known to the running version.
+//
+// Unlike validateConfig, properties that are not in the schema are errors. Variable references
+// (${NAME}) are validated as written, not expanded.
+func validateConfigForSchema(kind, config, schemaVersion, siteSchema string) (warnings []string, err error) {
+	warnings, err = validateConfig(kind, config, configValidationOptions{})
+	if err != nil {
+		return nil, err
+	}
+	kind, _ = normalizeKind(kind)
+
+	var s struct {
+		Definitions map[string]json.RawMessage `json:"definitions"`
+	}
+	if err := json.Unmarshal([]byte(siteSchema), &s); err != nil {
+		return nil, err
+	}
+	definition := kindSchemaDefinitions[kind]
+	if _, ok := s.Definitions[definition]; !ok {
+		return nil, fmt.Errorf("%s external services are not supported in version %s", kind, schemaVersion)
+	}
+
+	// Refer to the kind's definition from a schema with all of the definitions, so that the
+	// definitions it refers to are resolved.
+	definitions, err := json.Marshal(s.Definitions)
+	if err != nil {
+		return nil, err
+	}
+	kindSchema, err := gojsonschema.NewSchema(gojsonschema.NewStringLoader(fmt.Sprintf(`{"$ref": "#/definitions/%s", "definitions": %s}`, definition, definitions)))
+	if err != nil {
+		return nil, err
+	}
+	normalized, err := jsonc.ParseValue(config)
+	if err != nil {
+		return nil, err
+	}
+	res, err := kindSchema.Validate(gojsonschema.NewBytesLoader(normalized))
+	if err != nil {
+		return nil, err
+	}
+	if !res.Valid() {
+		problems := make([]string, len(res.Errors()))
+		for i, e := range res.Errors() {
+			problems[i] = fmt.Sprintf("%s: %s", e.Field(), e.Description())
+		}
+		return nil, fmt.Errorf("invalid %s config for version %s: %s", kind, schemaVersion, strings.Join(problems, "; "))
+	}
+	return warnings, nil
+}
+
+// ValidateConfigForVersion validates an external service config without saving it against the
+// site configuration schema of the version of Sourcegraph (see validateConfigVersioned). Like
+// ValidateConfig, it returns warnings about problems that don't prevent the config from being saved.
+func (*externalServices) ValidateConfigForVersion(kind, config, schemaVersion string) (warnings []string, err error) {
+	return validateConfigVersioned(kind, config, schemaVersion)
+}
+
+// ValidateConfigForSchema validates an external service config without saving it against a site
+// configuration schema supplied by the caller (see validateConfigForSchema), such as the schema of
+// the version that the instance is being upgraded to. Like ValidateConfig, it returns warnings
+// about problems that don't prevent the config from being saved.
+func (*externalServices) ValidateConfigForSchema(kind, config, schemaVersion, siteSchema string) (warnings []string, err error) {
+	return validateConfigForSchema(kind, config, schemaVersion, siteSchema)
+}
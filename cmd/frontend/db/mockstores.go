@@ -20,6 +20,7 @@ type MockStores struct {
 	Phabricator MockPhabricator
 
 	ExternalAccounts MockExternalAccounts
+	ExternalServices MockExternalServices
 
 	OrgInvitations MockOrgInvitations
 }
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
 	"sync"
+	"time"
 
 	graphql "github.com/graph-gophers/graphql-go"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
@@ -12,22 +13,6 @@ import (
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
 )
 
-var externalServiceKinds = map[string]struct{}{
-	"AWSCODECOMMIT":   struct{}{},
-	"BITBUCKETSERVER": struct{}{},
-	"GITHUB":          struct{}{},
-	"GITLAB":          struct{}{},
-	"GITOLITE":        struct{}{},
-	"PHABRICATOR":     struct{}{},
-}
-
-func validateKind(kind string) error {
-	if _, ok := externalServiceKinds[kind]; !ok {
-		return fmt.Errorf("invalid external service kind: %s", kind)
-	}
-	return nil
-}
-
 func (r *schemaResolver) AddExternalService(ctx context.Context, args *struct {
 	Input *struct {
 		Kind        string
@@ -40,10 +25,6 @@ func (r *schemaResolver) AddExternalService(ctx context.Context, args *struct {
 		return nil, err
 	}
 
-	if err := validateKind(args.Input.Kind); err != nil {
-		return nil, err
-	}
-
 	externalService := &types.ExternalService{
 		Kind:        args.Input.Kind,
 		DisplayName: args.Input.DisplayName,
@@ -106,6 +87,8 @@ func (*schemaResolver) DeleteExternalService(ctx context.Context, args *struct {
 
 func (r *schemaResolver) ExternalServices(ctx context.Context, args *struct {
 	graphqlutil.ConnectionArgs
+	CreatedAfter *string
+	UpdatedAfter *string
 }) (*externalServiceConnectionResolver, error) {
 	// 🚨 SECURITY: Only site admins may read external services (they have secrets).
 	if err := backend.CheckCurrentUserIsSiteAdmin(ctx); err != nil {
@@ -113,9 +96,28 @@ func (r *schemaResolver) ExternalServices(ctx context.Context, args *struct {
 	}
 	var opt db.ExternalServicesListOptions
 	args.ConnectionArgs.Set(&opt.LimitOffset)
+	var err error
+	if opt.CreatedAfter, err = parseTimeArg("createdAfter", args.CreatedAfter); err != nil {
+		return nil, err
+	}
+	if opt.UpdatedAfter, err = parseTimeArg("updatedAfter", args.UpdatedAfter); err != nil {
+		return nil, err
+	}
 	return &externalServiceConnectionResolver{opt: opt}, nil
 }
 
+// parseTimeArg parses the optional RFC 3339 timestamp argument with the given name.
+func parseTimeArg(name string, value *string) (*time.Time, error) {
+	if value == nil {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, *value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s argument (must be an RFC 3339 timestamp): %s", name, err)
+	}
+	return &t, nil
+}
+
 type externalServiceConnectionResolver struct {
 	opt db.ExternalServicesListOptions
 
@@ -0,0 +1,74 @@
+package graphqlbackend
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/graph-gophers/graphql-go/gqltesting"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+)
+
+func TestExternalServices_TimeFilters(t *testing.T) {
+	resetMocks()
+	db.Mocks.Users.GetByCurrentAuthUser = func(context.Context) (*types.User, error) {
+		return &types.User{SiteAdmin: true}, nil
+	}
+
+	wantCreatedAfter := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	wantUpdatedAfter := time.Date(2018, 2, 1, 12, 0, 0, 0, time.UTC)
+	checkOpt := func(opt db.ExternalServicesListOptions) {
+		t.Helper()
+		if opt.CreatedAfter == nil || !opt.CreatedAfter.Equal(wantCreatedAfter) {
+			t.Errorf("got CreatedAfter %v, want %v", opt.CreatedAfter, wantCreatedAfter)
+		}
+		if opt.UpdatedAfter == nil || !opt.UpdatedAfter.Equal(wantUpdatedAfter) {
+			t.Errorf("got UpdatedAfter %v, want %v", opt.UpdatedAfter, wantUpdatedAfter)
+		}
+	}
+	db.Mocks.ExternalServices.List = func(ctx context.Context, opt db.ExternalServicesListOptions) ([]*types.ExternalService, error) {
+		checkOpt(opt)
+		return []*types.ExternalService{{ID: 1, Kind: "GITHUB", DisplayName: "GitHub"}}, nil
+	}
+	db.Mocks.ExternalServices.Count = func(ctx context.Context, opt db.ExternalServicesListOptions) (int, error) {
+		checkOpt(opt)
+		return 1, nil
+	}
+
+	gqltesting.RunTests(t, []*gqltesting.Test{
+		{
+			Schema: GraphQLSchema,
+			Query: `
+				{
+					externalServices(createdAfter: "2018-01-01T00:00:00Z", updatedAfter: "2018-02-01T12:00:00Z") {
+						nodes { displayName }
+						totalCount
+					}
+				}
+			`,
+			ExpectedResult: `
+				{
+					"externalServices": {
+						"nodes": [
+							{
+								"displayName": "GitHub"
+							}
+						],
+						"totalCount": 1
+					}
+				}
+			`,
+		},
+	})
+}
+
+func TestParseTimeArg(t *testing.T) {
+	if got, err := parseTimeArg("after", nil); got != nil || err != nil {
+		t.Errorf("got %v, %v, want nil, nil", got, err)
+	}
+	value := "yesterday"
+	if _, err := parseTimeArg("after", &value); err == nil {
+		t.Error("got nil error, want error for an invalid timestamp")
+	}
+}
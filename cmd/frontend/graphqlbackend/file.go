@@ -1,34 +1,118 @@
 package graphqlbackend
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
 	"html/template"
+	"io"
+	"mime"
+	"net/http"
+	"os"
 	"path"
 	"strings"
 	"time"
 
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
 	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/conf"
 	"github.com/sourcegraph/sourcegraph/pkg/highlight"
 	"github.com/sourcegraph/sourcegraph/pkg/markdown"
 	"github.com/sourcegraph/sourcegraph/pkg/vcs/git"
 )
 
+// Content returns the content of this blob as text, without its byte-order mark (see HasBOM).
 func (r *gitTreeEntryResolver) Content(ctx context.Context) (string, error) {
-	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
-	defer cancel()
-
-	cachedRepo, err := backend.CachedGitRepo(ctx, r.commit.repo.repo)
+	content, _, err := r.content(ctx)
 	if err != nil {
 		return "", err
 	}
+	return string(stripBOM(content)), nil
+}
+
+// content returns the content of this blob and whether it is binary. Both are memoized.
+func (r *gitTreeEntryResolver) content(ctx context.Context) (content []byte, binary bool, err error) {
+	r.contentOnce.Do(func() {
+		cachedRepo, err := backend.CachedGitRepo(ctx, r.commit.repo.repo)
+		if err != nil {
+			r.contentErr = err
+			return
+		}
+		var content []byte
+		r.contentErr = withGitTimeout(ctx, "ReadFile", func(ctx context.Context) (err error) {
+			content, err = git.ReadFile(ctx, *cachedRepo, api.CommitID(r.commit.oid), r.path)
+			return err
+		})
+		if r.contentErr == nil {
+			r.contentBytes = content
+			r.contentBinary = highlight.IsBinary(content)
+		}
+	})
+	return r.contentBytes, r.contentBinary, r.contentErr
+}
+
+// ByteSize returns the size of this blob in bytes, without reading its content. It is an error to
+// call it on a directory.
+func (r *gitTreeEntryResolver) ByteSize(ctx context.Context) (int32, error) {
+	if r.IsDirectory() {
+		return 0, errors.New("byteSize is not defined for a directory")
+	}
+	size, err := r.size(ctx)
+	return int32(size), err
+}
 
-	contents, err := git.ReadFile(ctx, *cachedRepo, api.CommitID(r.commit.oid), r.path)
+// ByteSizeHuman returns the size of this blob formatted for display (see humanByteSize). It is empty
+// for a directory.
+func (r *gitTreeEntryResolver) ByteSizeHuman(ctx context.Context) (string, error) {
+	if r.IsDirectory() {
+		return "", nil
+	}
+	size, err := r.size(ctx)
 	if err != nil {
 		return "", err
 	}
+	return humanByteSize(size), nil
+}
 
-	return string(contents), nil
+// humanByteSize formats a size in bytes in decimal (SI) units with one decimal place, such as
+// "512 B", "1.2 KB", and "3.4 MB". Sizes that would round to 1000 of a unit use the next unit.
+func humanByteSize(n int64) string {
+	if n < 1000 {
+		return fmt.Sprintf("%d B", n)
+	}
+	v := float64(n)
+	for _, unit := range []string{"KB", "MB", "GB", "TB", "PB"} {
+		v /= 1000
+		if v < 999.95 {
+			return fmt.Sprintf("%.1f %s", v, unit)
+		}
+	}
+	return fmt.Sprintf("%.1f EB", v/1000)
+}
+
+// size returns the size of this blob in bytes. The size is known from the stat if this entry was
+// listed or stat'd from the repository; otherwise (e.g., for search results and diffs, whose stat is
+// created with createFileInfo and has no size), it is looked up with git.Stat.
+func (r *gitTreeEntryResolver) size(ctx context.Context) (int64, error) {
+	if _, ok := r.stat.(fileInfo); !ok {
+		return r.stat.Size(), nil
+	}
+	cachedRepo, err := backend.CachedGitRepo(ctx, r.commit.repo.repo)
+	if err != nil {
+		return 0, err
+	}
+	var stat os.FileInfo
+	if err := withGitTimeout(ctx, "Stat", func(ctx context.Context) (err error) {
+		stat, err = git.Stat(ctx, *cachedRepo, api.CommitID(r.commit.oid), r.path)
+		return err
+	}); err != nil {
+		return 0, err
+	}
+	return stat.Size(), nil
 }
 
 func (r *gitTreeEntryResolver) RichHTML(ctx context.Context) (string, error) {
@@ -72,21 +156,145 @@ func (*schemaResolver) HighlightCode(ctx context.Context, args *struct {
 }
 
 func (r *gitTreeEntryResolver) Binary(ctx context.Context) (bool, error) {
-	content, err := r.Content(ctx)
+	_, binary, err := r.content(ctx)
+	return binary, err
+}
+
+// ContentBase64 returns the content of this blob, base64-encoded (with padding), so that clients can
+// fetch binary blobs, whose content isn't valid in a GraphQL string. It is an error to call it on a
+// directory or on a blob larger than maxRenderedBlobSize (whose content isn't read).
+func (r *gitTreeEntryResolver) ContentBase64(ctx context.Context) (string, error) {
+	if r.IsDirectory() {
+		return "", errors.New("contentBase64 is not defined for a directory")
+	}
+	size, err := r.size(ctx)
+	if err != nil {
+		return "", err
+	}
+	if max := maxRenderedBlobSize(); size > max {
+		return "", fmt.Errorf("blob %s is too large (%d bytes, and the maximum is %d bytes)", r.path, size, max)
+	}
+	content, _, err := r.content(ctx)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(content), nil
+}
+
+// TotalLines returns the number of lines in this blob (see countLines), or 0 if it is binary. It is
+// an error to call it on a directory.
+func (r *gitTreeEntryResolver) TotalLines(ctx context.Context) (int32, error) {
+	if r.IsDirectory() {
+		return 0, errors.New("totalLines is not defined for a directory")
+	}
+	content, binary, err := r.content(ctx)
+	if err != nil || binary {
+		return 0, err
+	}
+	return countLines(content), nil
+}
+
+// ContentSHA256 returns the hex-encoded SHA-256 digest of the content of this blob. Unlike the Git
+// blob OID, it can be computed from the content alone. The content is streamed through the hash (so
+// that large blobs aren't read into memory). It is an error to call it on a directory.
+func (r *gitTreeEntryResolver) ContentSHA256(ctx context.Context) (string, error) {
+	if r.IsDirectory() {
+		return "", errors.New("contentSHA256 is not defined for a directory")
+	}
+	cachedRepo, err := backend.CachedGitRepo(ctx, r.commit.repo.repo)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	err = withGitTimeout(ctx, "ReadFile", func(ctx context.Context) error {
+		rc, err := git.NewFileReader(ctx, *cachedRepo, api.CommitID(r.commit.oid), r.path)
+		if err != nil {
+			return err
+		}
+		defer rc.Close()
+		_, err = io.Copy(h, rc)
+		return err
+	})
 	if err != nil {
-		return false, err
+		return "", err
 	}
-	return highlight.IsBinary([]byte(content)), nil
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// countLines returns the number of lines in content. A final line without a trailing newline is
+// counted, so "a\nb" and "a\nb\n" both have 2 lines. Empty content has 0 lines.
+func countLines(content []byte) int32 {
+	n := bytes.Count(content, []byte("\n"))
+	if len(content) > 0 && content[len(content)-1] != '\n' {
+		n++
+	}
+	return int32(n)
+}
+
+// Possible values of RenderMode.
+const (
+	renderModeText           = "text"
+	renderModeImage          = "image"
+	renderModeBinaryDownload = "binary-download"
+	renderModeTooLarge       = "too-large"
+)
+
+func maxRenderedBlobSize() int64 {
+	if max := conf.Get().MaxRenderedBlobSize; max > 0 {
+		return int64(max)
+	}
+	return 1 << 20 // 1 MiB
+}
+
+// RenderMode returns how a client should display this blob: inline as text ("text") or as an
+// image ("image"), as a download link because it is binary ("binary-download"), or as a message that
+// it is too large to display inline ("too-large"). The size threshold is the maxRenderedBlobSize
+// site configuration property.
+func (r *gitTreeEntryResolver) RenderMode(ctx context.Context) (string, error) {
+	size, err := r.size(ctx)
+	if err != nil {
+		return "", err
+	}
+	return renderMode(r.path, size, maxRenderedBlobSize(), func() ([]byte, error) {
+		content, err := r.Content(ctx)
+		return []byte(content), err
+	})
+}
+
+// renderMode returns the render mode of the blob at the path with the given size. The content is
+// only read if the blob is not too large.
+func renderMode(name string, size, maxSize int64, readContent func() ([]byte, error)) (string, error) {
+	if size > maxSize {
+		return renderModeTooLarge, nil
+	}
+	content, err := readContent()
+	if err != nil {
+		return "", err
+	}
+	if strings.HasPrefix(mime.TypeByExtension(path.Ext(name)), "image/") || strings.HasPrefix(http.DetectContentType(content), "image/") {
+		return renderModeImage, nil
+	}
+	if highlight.IsBinary(content) {
+		return renderModeBinaryDownload, nil
+	}
+	return renderModeText, nil
 }
 
 type highlightedFileResolver struct {
-	aborted bool
-	html    string
+	aborted         bool
+	html            string
+	servedFromCache bool // whether html is from highlightCache
 }
 
 func (h *highlightedFileResolver) Aborted() bool { return h.aborted }
 func (h *highlightedFileResolver) HTML() string  { return h.html }
 
+// ServedFromCache reports whether the highlighted HTML was served from highlightCache. It is for
+// debugging the cache, so it is only reported to site admins (and is false for other users).
+func (h *highlightedFileResolver) ServedFromCache(ctx context.Context) bool {
+	return h.servedFromCache && backend.CheckCurrentUserIsSiteAdmin(ctx) == nil
+}
+
 func (r *gitTreeEntryResolver) Highlight(ctx context.Context, args *struct {
 	DisableTimeout bool
 	IsLightTheme   bool
@@ -100,6 +308,17 @@ func (r *gitTreeEntryResolver) Highlight(ctx context.Context, args *struct {
 		return nil, err
 	}
 
+	// Serve the highlighted HTML from the cache if this blob (with the same name and theme) was
+	// highlighted recently.
+	blob, _, err := git.GetObject(ctx, *cachedRepo, string(r.commit.oid)+":"+r.path)
+	if err != nil {
+		return nil, err
+	}
+	cacheKey := highlightCacheKey(blob, path.Base(r.path), args.IsLightTheme)
+	if html, ok := highlightCache.get(cacheKey); ok {
+		return &highlightedFileResolver{html: html, servedFromCache: true}, nil
+	}
+
 	content, err := git.ReadFile(ctx, *cachedRepo, api.CommitID(r.commit.oid), r.path)
 	if err != nil {
 		return nil, err
@@ -110,10 +329,14 @@ func (r *gitTreeEntryResolver) Highlight(ctx context.Context, args *struct {
 		html   template.HTML
 		result = &highlightedFileResolver{}
 	)
-	html, result.aborted, err = highlight.Code(ctx, content, r.path, args.DisableTimeout, args.IsLightTheme)
+	html, result.aborted, err = highlight.Code(ctx, stripBOM(content), r.path, args.DisableTimeout, args.IsLightTheme)
 	if err != nil {
 		return nil, err
 	}
 	result.html = string(html)
+	if !result.aborted {
+		// Aborted results are unhighlighted plain text, which should be highlighted next time.
+		highlightCache.add(cacheKey, result.html)
+	}
 	return result, nil
 }
@@ -0,0 +1,79 @@
+package graphqlbackend
+
+import (
+	"strconv"
+	"sync"
+
+	"github.com/golang/groupcache/lru"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sourcegraph/sourcegraph/pkg/vcs/git"
+)
+
+// highlightCacheMaxBytes is the maximum total size of the highlighted HTML in highlightCache.
+const highlightCacheMaxBytes = 64 << 20 // 64 MiB
+
+// highlightCache caches the highlighted HTML of recently viewed blobs. Entries are keyed by the
+// blob's OID (not its commit and path), so the same file at different commits shares an entry. Blobs
+// are immutable, so entries never need to be invalidated; the least recently used entries are
+// evicted when the total size exceeds maxBytes.
+var highlightCache = newHighlightHTMLCache(highlightCacheMaxBytes)
+
+var highlightCacheCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "src",
+	Subsystem: "graphql",
+	Name:      "highlight_cache_hit",
+	Help:      "Counts cache hits and misses for highlighted blobs.",
+}, []string{"type"})
+
+func init() {
+	prometheus.MustRegister(highlightCacheCounter)
+}
+
+type highlightHTMLCache struct {
+	maxBytes int
+
+	mu    sync.Mutex
+	lru   *lru.Cache
+	bytes int // total size of the cached HTML
+}
+
+func newHighlightHTMLCache(maxBytes int) *highlightHTMLCache {
+	c := &highlightHTMLCache{maxBytes: maxBytes, lru: lru.New(0)}
+	c.lru.OnEvicted = func(_ lru.Key, value interface{}) { c.bytes -= len(value.(string)) }
+	return c
+}
+
+// highlightCacheKey returns the cache key for the highlighted HTML of the blob. The file name is
+// part of the key because it determines the language the blob is highlighted as.
+func highlightCacheKey(blob git.OID, name string, isLightTheme bool) string {
+	return blob.String() + ":" + strconv.FormatBool(isLightTheme) + ":" + name
+}
+
+func (c *highlightHTMLCache) get(key string) (html string, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	v, ok := c.lru.Get(key)
+	if !ok {
+		highlightCacheCounter.WithLabelValues("miss").Inc()
+		return "", false
+	}
+	highlightCacheCounter.WithLabelValues("hit").Inc()
+	return v.(string), true
+}
+
+// add adds the highlighted HTML to the cache, unless it alone is larger than maxBytes.
+func (c *highlightHTMLCache) add(key, html string) {
+	if len(html) > c.maxBytes {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.lru.Get(key); ok {
+		return
+	}
+	c.lru.Add(key, html)
+	c.bytes += len(html)
+	for c.bytes > c.maxBytes {
+		c.lru.RemoveOldest()
+	}
+}
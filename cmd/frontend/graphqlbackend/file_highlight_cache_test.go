@@ -0,0 +1,78 @@
+package graphqlbackend
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/actor"
+	"github.com/sourcegraph/sourcegraph/pkg/vcs/git"
+)
+
+func TestHighlightHTMLCache(t *testing.T) {
+	c := newHighlightHTMLCache(10)
+	c.add("a", "aaaa")
+	c.add("b", "bbbb")
+	if html, ok := c.get("a"); !ok || html != "aaaa" {
+		t.Fatalf("got %q, %v, want hit", html, ok)
+	}
+
+	// Adding c exceeds maxBytes, so the least recently used entry (b) is evicted.
+	c.add("c", "cccc")
+	if _, ok := c.get("b"); ok {
+		t.Error("got hit for b, want it to be evicted")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, ok := c.get(key); !ok {
+			t.Errorf("got miss for %s, want hit", key)
+		}
+	}
+	if want := 8; c.bytes != want {
+		t.Errorf("got %d bytes, want %d", c.bytes, want)
+	}
+
+	// An entry larger than maxBytes is not cached at all.
+	c.add("d", strings.Repeat("d", 11))
+	if _, ok := c.get("d"); ok {
+		t.Error("got hit for too large entry, want miss")
+	}
+}
+
+func TestHighlightCacheKey(t *testing.T) {
+	var blob git.OID
+	keys := map[string]bool{}
+	for _, key := range []string{
+		highlightCacheKey(blob, "a.go", false),
+		highlightCacheKey(blob, "a.go", true),
+		highlightCacheKey(blob, "a.py", false),
+		highlightCacheKey(git.OID{1}, "a.go", false),
+	} {
+		if keys[key] {
+			t.Errorf("duplicate key %q", key)
+		}
+		keys[key] = true
+	}
+}
+
+func TestHighlightedFile_ServedFromCache(t *testing.T) {
+	resetMocks()
+	defer func() { db.Mocks.Users.GetByCurrentAuthUser = nil }()
+	ctx := actor.WithActor(context.Background(), &actor.Actor{UID: 1})
+	r := &highlightedFileResolver{html: "<pre></pre>", servedFromCache: true}
+
+	for _, siteAdmin := range []bool{false, true} {
+		db.Mocks.Users.GetByCurrentAuthUser = func(ctx context.Context) (*types.User, error) {
+			return &types.User{ID: 1, SiteAdmin: siteAdmin}, nil
+		}
+		if got := r.ServedFromCache(ctx); got != siteAdmin {
+			t.Errorf("site admin %v: got servedFromCache %v, want %v", siteAdmin, got, siteAdmin)
+		}
+	}
+
+	// A cache miss is never reported as served from the cache.
+	if (&highlightedFileResolver{}).ServedFromCache(ctx) {
+		t.Error("got servedFromCache true for a cache miss, want false")
+	}
+}
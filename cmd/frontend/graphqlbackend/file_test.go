@@ -0,0 +1,220 @@
+package graphqlbackend
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/conf"
+	"github.com/sourcegraph/sourcegraph/pkg/vcs/git"
+	"github.com/sourcegraph/sourcegraph/pkg/vcs/util"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+func TestRenderMode(t *testing.T) {
+	png := []byte("\x89PNG\x0d\x0a\x1a\x0a\x00\x00\x00\x0dIHDR")
+	tests := map[string]struct {
+		name    string
+		size    int64
+		content []byte
+		want    string
+	}{
+		"text":                     {name: "main.go", content: []byte("package main"), want: renderModeText},
+		"image by extension":       {name: "logo.svg", content: []byte("<svg></svg>"), want: renderModeImage},
+		"image by content":         {name: "logo", content: png, want: renderModeImage},
+		"binary":                   {name: "a.out", content: []byte("\x7fELF\x02\x01\x01\x00\xff\xfe"), want: renderModeBinaryDownload},
+		"too large":                {name: "big.txt", size: 101, want: renderModeTooLarge},
+		"too large image":          {name: "big.png", size: 101, want: renderModeTooLarge},
+		"at the threshold is text": {name: "big.txt", size: 100, content: []byte("x"), want: renderModeText},
+	}
+	for label, test := range tests {
+		t.Run(label, func(t *testing.T) {
+			got, err := renderMode(test.name, test.size, 100, func() ([]byte, error) {
+				if test.size > 100 {
+					return nil, errors.New("content of a too large blob should not be read")
+				}
+				return test.content, nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != test.want {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestGitTreeEntry_ByteSize(t *testing.T) {
+	resetMocks()
+	contents := map[string]string{
+		"empty":           "",
+		"newline.txt":     "a\nb\n",
+		"no-newline.txt":  "a\nb",
+		"unicode.txt":     "héllo, 世界",
+		"binary":          "\x00\x01\xff\xfe",
+		"crlf-no-newline": "a\r\nb",
+	}
+	git.Mocks.Stat = func(commit api.CommitID, path string) (os.FileInfo, error) {
+		content, ok := contents[path]
+		if !ok {
+			return nil, &os.PathError{Op: "stat", Path: path, Err: os.ErrNotExist}
+		}
+		return &util.FileInfo{Name_: path, Size_: int64(len(content))}, nil
+	}
+	defer git.ResetMocks()
+
+	commit := &gitCommitResolver{repo: &repositoryResolver{repo: &types.Repo{ID: 2, Name: "example.com/repo"}}, oid: exampleCommitSHA1}
+	for name, content := range contents {
+		t.Run(name, func(t *testing.T) {
+			// An entry whose stat is from a listing has its size, and one whose stat is synthesized
+			// (as for search results) must look it up.
+			for _, stat := range []os.FileInfo{&util.FileInfo{Name_: name, Size_: int64(len(content))}, createFileInfo(name, false)} {
+				r := &gitTreeEntryResolver{commit: commit, path: name, stat: stat}
+				got, err := r.ByteSize(context.Background())
+				if err != nil {
+					t.Fatal(err)
+				}
+				if want := int32(len(content)); got != want {
+					t.Errorf("%T: got %d, want %d", stat, got, want)
+				}
+			}
+		})
+	}
+
+	t.Run("directory", func(t *testing.T) {
+		r := &gitTreeEntryResolver{commit: commit, path: "dir", stat: createFileInfo("dir", true)}
+		if _, err := r.ByteSize(context.Background()); err == nil {
+			t.Error("got nil error, want error for a directory")
+		}
+	})
+}
+
+func TestGitTreeEntry_ContentSHA256(t *testing.T) {
+	contents := map[string]string{
+		"empty":   "",
+		"hello":   "hello\n",
+		"binary":  "\x00\x01\xff\xfe",
+		"large":   strings.Repeat("x", 1<<20),
+		"unicode": "héllo, 世界",
+	}
+	git.Mocks.NewFileReader = func(commit api.CommitID, name string) (io.ReadCloser, error) {
+		content, ok := contents[name]
+		if !ok {
+			return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+		}
+		return ioutil.NopCloser(strings.NewReader(content)), nil
+	}
+	defer git.ResetMocks()
+
+	commit := &gitCommitResolver{repo: &repositoryResolver{repo: &types.Repo{ID: 2, Name: "example.com/repo"}}, oid: exampleCommitSHA1}
+	for name, content := range contents {
+		r := &gitTreeEntryResolver{commit: commit, path: name, stat: createFileInfo(name, false)}
+		got, err := r.ContentSHA256(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := fmt.Sprintf("%x", sha256.Sum256([]byte(content))); got != want {
+			t.Errorf("%s: got %s, want %s", name, got, want)
+		}
+	}
+	if _, err := (&gitTreeEntryResolver{commit: commit, path: "missing", stat: createFileInfo("missing", false)}).ContentSHA256(context.Background()); !os.IsNotExist(err) {
+		t.Errorf("missing: got error %v, want not exist", err)
+	}
+	if _, err := (&gitTreeEntryResolver{commit: commit, path: "dir", stat: createFileInfo("dir", true)}).ContentSHA256(context.Background()); err == nil {
+		t.Error("directory: got nil error, want error")
+	}
+}
+
+func TestGitTreeEntry_ContentBase64(t *testing.T) {
+	conf.Mock(&schema.SiteConfiguration{MaxRenderedBlobSize: 8})
+	defer conf.Mock(nil)
+
+	newEntry := func(content string) *gitTreeEntryResolver {
+		r := &gitTreeEntryResolver{path: "f", stat: &util.FileInfo{Name_: "f", Size_: int64(len(content))}}
+		// Populate the memoized content, so that ContentBase64 doesn't read it from the repository.
+		r.contentOnce.Do(func() { r.contentBytes = []byte(content) })
+		return r
+	}
+	for _, content := range []string{"", "hello\n", "\x89PNG\x00\xff"} {
+		got, err := newEntry(content).ContentBase64(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := base64.StdEncoding.EncodeToString([]byte(content)); got != want {
+			t.Errorf("%q: got %q, want %q", content, got, want)
+		}
+	}
+
+	if _, err := newEntry("too large!").ContentBase64(context.Background()); err == nil {
+		t.Error("too large: got nil error, want error")
+	}
+	dir := &gitTreeEntryResolver{path: "d", stat: createFileInfo("d", true)}
+	if _, err := dir.ContentBase64(context.Background()); err == nil {
+		t.Error("directory: got nil error, want error")
+	}
+}
+
+func TestHumanByteSize(t *testing.T) {
+	tests := map[int64]string{
+		0:             "0 B",
+		1:             "1 B",
+		999:           "999 B",
+		1000:          "1.0 KB",
+		1234:          "1.2 KB",
+		999949:        "999.9 KB",
+		999950:        "1.0 MB",
+		3400000:       "3.4 MB",
+		1 << 30:       "1.1 GB",
+		2500000000:    "2.5 GB",
+		5000000000000: "5.0 TB",
+	}
+	for size, want := range tests {
+		if got := humanByteSize(size); got != want {
+			t.Errorf("%d: got %q, want %q", size, got, want)
+		}
+	}
+}
+
+func TestGitTreeEntry_ByteSizeHuman(t *testing.T) {
+	blob := &gitTreeEntryResolver{path: "f", stat: &util.FileInfo{Name_: "f", Size_: 1234}}
+	if got, err := blob.ByteSizeHuman(context.Background()); err != nil {
+		t.Fatal(err)
+	} else if want := "1.2 KB"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	dir := &gitTreeEntryResolver{path: "d", stat: &util.FileInfo{Name_: "d", Mode_: os.ModeDir, Size_: 4096}}
+	if got, err := dir.ByteSizeHuman(context.Background()); err != nil {
+		t.Fatal(err)
+	} else if got != "" {
+		t.Errorf("directory: got %q, want empty", got)
+	}
+}
+
+func TestCountLines(t *testing.T) {
+	tests := map[string]int32{
+		"":           0,
+		"\n":         1,
+		"a":          1,
+		"a\n":        1,
+		"a\nb":       2,
+		"a\nb\n":     2,
+		"a\r\nb\r\n": 2,
+		"a\n\n":      2,
+	}
+	for content, want := range tests {
+		if got := countLines([]byte(content)); got != want {
+			t.Errorf("%q: got %d, want %d", content, got, want)
+		}
+	}
+}
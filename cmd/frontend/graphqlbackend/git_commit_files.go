@@ -0,0 +1,67 @@
+package graphqlbackend
+
+import (
+	"context"
+	"os"
+	"path"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/vcs/git"
+)
+
+// Files returns the Git blobs in this commit at the given paths, in the same order. A path that
+// doesn't exist or isn't a blob is resolved as null instead of failing the whole batch.
+//
+// Instead of one git.Stat call per path, the paths are grouped by their parent directory and each
+// parent directory is listed once. The contents of the blobs are read (and memoized) when they are
+// resolved, so a request that doesn't need them only lists the directories.
+func (r *gitCommitResolver) Files(ctx context.Context, args *struct {
+	Paths []string
+}) ([]*gitTreeEntryResolver, error) {
+	cachedRepo, err := backend.CachedGitRepo(ctx, r.repo.repo)
+	if err != nil {
+		return nil, err
+	}
+
+	dirs := map[string]map[string]os.FileInfo{} // parent directory -> entry name -> entry
+	for _, p := range args.Paths {
+		dir := path.Dir(path.Clean(p))
+		if dir == "." {
+			dir = ""
+		}
+		if _, ok := dirs[dir]; ok {
+			continue
+		}
+		var entries []os.FileInfo
+		if err := withGitTimeout(ctx, "ReadDir", func(ctx context.Context) (err error) {
+			entries, err = git.ReadDir(ctx, *cachedRepo, api.CommitID(r.oid), dir, false)
+			return err
+		}); err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		byName := make(map[string]os.FileInfo, len(entries))
+		for _, entry := range entries {
+			byName[entry.Name()] = entry
+		}
+		dirs[dir] = byName
+	}
+
+	files := make([]*gitTreeEntryResolver, len(args.Paths))
+	for i, p := range args.Paths {
+		dir := path.Dir(path.Clean(p))
+		if dir == "." {
+			dir = ""
+		}
+		stat, ok := dirs[dir][path.Base(p)]
+		if !ok || !stat.Mode().IsRegular() {
+			continue
+		}
+		files[i] = &gitTreeEntryResolver{
+			commit: r,
+			path:   p,
+			stat:   stat,
+		}
+	}
+	return files, nil
+}
@@ -0,0 +1,85 @@
+package graphqlbackend
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/graph-gophers/graphql-go/gqltesting"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/vcs/git"
+	"github.com/sourcegraph/sourcegraph/pkg/vcs/util"
+)
+
+func TestGitCommit_Files(t *testing.T) {
+	resetMocks()
+	db.Mocks.Repos.MockGetByName(t, "github.com/gorilla/mux", 2)
+	backend.Mocks.Repos.ResolveRev = func(ctx context.Context, repo *types.Repo, rev string) (api.CommitID, error) {
+		return exampleCommitSHA1, nil
+	}
+	backend.Mocks.Repos.MockGetCommit_Return_NoCheck(t, &git.Commit{ID: exampleCommitSHA1})
+
+	readDirCalls := map[string]int{}
+	git.Mocks.ReadDir = func(commit api.CommitID, name string, recurse bool) ([]os.FileInfo, error) {
+		readDirCalls[name]++
+		switch name {
+		case "":
+			return []os.FileInfo{
+				&util.FileInfo{Name_: "README.md", Mode_: 0644},
+				&util.FileInfo{Name_: "a", Mode_: os.ModeDir},
+			}, nil
+		case "a":
+			return []os.FileInfo{
+				&util.FileInfo{Name_: "b.go", Mode_: 0644},
+				&util.FileInfo{Name_: "c.go", Mode_: 0644},
+			}, nil
+		}
+		return nil, &os.PathError{Op: "ls-tree", Path: name, Err: os.ErrNotExist}
+	}
+	defer git.ResetMocks()
+
+	gqltesting.RunTests(t, []*gqltesting.Test{
+		{
+			Schema: GraphQLSchema,
+			Query: `
+				{
+					repository(name: "github.com/gorilla/mux") {
+						commit(rev: "` + exampleCommitSHA1 + `") {
+							files(paths: ["README.md", "a/b.go", "a/missing.go", "a", "a/c.go", "x/y.go"]) {
+								path
+							}
+						}
+					}
+				}
+			`,
+			ExpectedResult: `
+				{
+					"repository": {
+						"commit": {
+							"files": [
+								{"path": "README.md"},
+								{"path": "a/b.go"},
+								null,
+								null,
+								{"path": "a/c.go"},
+								null
+							]
+						}
+					}
+				}
+			`,
+		},
+	})
+	for dir, calls := range readDirCalls {
+		if calls != 1 {
+			t.Errorf("got %d ReadDir calls for %q, want 1", calls, dir)
+		}
+	}
+	if len(readDirCalls) != 3 {
+		t.Errorf("got ReadDir calls for %v, want 3 directories", readDirCalls)
+	}
+}
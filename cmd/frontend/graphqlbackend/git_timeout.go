@@ -0,0 +1,57 @@
+package graphqlbackend
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/pkg/conf"
+)
+
+// gitTimeoutError is returned by a resolver when a Git operation (performed by gitserver) doesn't
+// complete within the timeout. It is temporary, so the request may be retried.
+type gitTimeoutError struct {
+	op      string // the Git operation, e.g. "ReadDir"
+	timeout time.Duration
+}
+
+func (e *gitTimeoutError) Error() string {
+	return fmt.Sprintf("git %s timed out after %s", e.op, e.timeout)
+}
+
+func (e *gitTimeoutError) Timeout() bool   { return true }
+func (e *gitTimeoutError) Temporary() bool { return true }
+
+// gitRequestTimeout returns the maximum duration of each Git operation performed by a resolver.
+func gitRequestTimeout() time.Duration {
+	if seconds := conf.Get().GitRequestTimeoutSeconds; seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	return 30 * time.Second
+}
+
+// withGitTimeout calls f (which performs the Git operation op) with a context that is canceled
+// after gitRequestTimeout. If the timeout is reached, it returns a *gitTimeoutError without waiting
+// for f to return, so that a hung gitserver can't block the resolver even if f doesn't respect the
+// context. Because f may still be running when withGitTimeout returns, f must only set local
+// variables, which the caller may use (or copy into shared state, such as a resolver's memoized
+// fields) only if no error is returned.
+func withGitTimeout(ctx context.Context, op string, f func(ctx context.Context) error) error {
+	timeout := gitRequestTimeout()
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() { done <- f(ctx) }()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	if err != nil && ctx.Err() == context.DeadlineExceeded {
+		return &gitTimeoutError{op: op, timeout: timeout}
+	}
+	return err
+}
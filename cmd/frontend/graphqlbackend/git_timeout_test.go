@@ -0,0 +1,61 @@
+package graphqlbackend
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/conf"
+	"github.com/sourcegraph/sourcegraph/pkg/errcode"
+	"github.com/sourcegraph/sourcegraph/pkg/vcs/git"
+	"github.com/sourcegraph/sourcegraph/pkg/vcs/util"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+func TestGitTree_EntriesTimeout(t *testing.T) {
+	resetMocks()
+	conf.Mock(&schema.SiteConfiguration{GitRequestTimeoutSeconds: 1})
+	defer conf.Mock(nil)
+
+	// Simulate a hung gitserver that doesn't respect context cancellation.
+	unblock := make(chan struct{})
+	defer close(unblock)
+	git.Mocks.ReadDir = func(commit api.CommitID, name string, recurse bool) ([]os.FileInfo, error) {
+		<-unblock
+		return nil, nil
+	}
+	defer git.ResetMocks()
+
+	tree := &gitTreeEntryResolver{
+		commit: &gitCommitResolver{repo: &repositoryResolver{repo: &types.Repo{ID: 2, Name: "github.com/gorilla/mux"}}, oid: exampleCommitSHA1},
+		path:   "foo",
+		stat:   &util.FileInfo{Name_: "foo", Mode_: os.ModeDir},
+	}
+	_, err := tree.Entries(context.Background(), &gitTreeEntryConnectionArgs{})
+	if _, ok := err.(*gitTimeoutError); !ok {
+		t.Fatalf("got error %v, want *gitTimeoutError", err)
+	}
+	if !errcode.IsTemporary(err) || !errcode.IsTimeout(err) {
+		t.Errorf("got error %v, want a temporary timeout error", err)
+	}
+}
+
+func TestWithGitTimeout(t *testing.T) {
+	want := os.ErrNotExist
+	if err := withGitTimeout(context.Background(), "ReadFile", func(ctx context.Context) error { return want }); err != want {
+		t.Errorf("got error %v, want %v", err, want)
+	}
+
+	// A canceled parent context is not a timeout.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := withGitTimeout(ctx, "ReadFile", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	if err != context.Canceled {
+		t.Errorf("got error %v, want %v", err, context.Canceled)
+	}
+}
@@ -10,9 +10,12 @@ import (
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/graphqlbackend/graphqlutil"
 	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/gitserver"
 	"github.com/sourcegraph/sourcegraph/pkg/vcs/git"
 )
 
+// IsRoot reports whether this tree entry is the repository root, whose path is empty (or "/" or
+// ".", depending on how it was requested).
 func (r *gitTreeEntryResolver) IsRoot() bool {
 	path := path.Clean(r.path)
 	return path == "/" || path == "." || path == ""
@@ -24,8 +27,20 @@ type gitTreeEntryConnectionArgs struct {
 	// If recurseSingleChild is true, we will return a flat list of every
 	// directory and file in a single-child nest.
 	RecursiveSingleChild bool
+	// If RespectGitignore is true, entries ignored by the .gitignore files committed at this
+	// commit are omitted.
+	RespectGitignore bool
+	// OrderBy is a TreeEntryOrderBy value. If empty, entries are ordered by name (directories
+	// first).
+	OrderBy string
 }
 
+// Possible values of the TreeEntryOrderBy GraphQL enum.
+const (
+	treeEntryOrderByName         = "NAME"
+	treeEntryOrderByLastModified = "LAST_MODIFIED"
+)
+
 func (r *gitTreeEntryResolver) Entries(ctx context.Context, args *gitTreeEntryConnectionArgs) ([]*gitTreeEntryResolver, error) {
 	return r.entries(ctx, args, nil)
 }
@@ -43,33 +58,66 @@ func (r *gitTreeEntryResolver) entries(ctx context.Context, args *gitTreeEntryCo
 	if err != nil {
 		return nil, err
 	}
-	entries, err := git.ReadDir(ctx, *cachedRepo, api.CommitID(r.commit.oid), r.path, r.isRecursive || args.Recursive)
-	if err != nil {
-		if strings.Contains(err.Error(), "file does not exist") { // TODO proper error value
-			// empty tree is not an error
-		} else {
+	var entries []os.FileInfo
+	var siblingCount int // only known for a non-recursive listing
+	if r.isRecursive || args.Recursive {
+		err = withGitTimeout(ctx, "ReadDir", func(ctx context.Context) (err error) {
+			entries, err = readDirRecursive(ctx, *cachedRepo, api.CommitID(r.commit.oid), r.path)
+			return err
+		})
+		if err != nil && !isEmptyTreeError(err) {
+			return nil, err
+		}
+	} else {
+		if entries, err = r.readDir(ctx); err != nil {
 			return nil, err
 		}
+		// Copy entries so that sorting them doesn't reorder the memoized listing.
+		entries = append([]os.FileInfo(nil), entries...)
+		siblingCount = len(entries)
 	}
 
-	sort.Sort(byDirectory(entries))
-
-	if args.First != nil && len(entries) > int(*args.First) {
-		entries = entries[:int(*args.First)]
+	if args.RespectGitignore {
+		entries, err = filterGitignored(ctx, getGitignoreRules(*cachedRepo, api.CommitID(r.commit.oid)), r.path, entries)
+		if err != nil {
+			return nil, err
+		}
 	}
 
+	sort.Sort(byDirectory(entries))
+
 	var prefix string
 	if r.path != "" {
 		prefix = r.path + "/"
 	}
 
+	lastCommits := newLastCommitBatch(r.commit)
+	for _, entry := range entries {
+		lastCommits.add(prefix + entry.Name()) // relies on git paths being cleaned already
+	}
+
+	if args.OrderBy == treeEntryOrderByLastModified {
+		if entries, err = sortByLastModified(ctx, lastCommits, prefix, entries); err != nil {
+			return nil, err
+		}
+	}
+
+	if args.First != nil && len(entries) > int(*args.First) {
+		entries = entries[:int(*args.First)]
+	}
+
 	var l []*gitTreeEntryResolver
 	for _, entry := range entries {
 		if filter == nil || filter(entry) {
+			entryPath := prefix + entry.Name()
+			// Construct the entry from the listing's file info (instead of looking up each entry
+			// again), so that listing a directory requires a constant number of gitserver calls.
 			l = append(l, &gitTreeEntryResolver{
-				commit: r.commit,
-				path:   prefix + entry.Name(), // relies on git paths being cleaned already
-				stat:   entry,
+				commit:       r.commit,
+				path:         entryPath,
+				stat:         entry,
+				lastCommits:  lastCommits,
+				siblingCount: siblingCount,
 			})
 		}
 	}
@@ -85,6 +133,149 @@ func (r *gitTreeEntryResolver) entries(ctx context.Context, args *gitTreeEntryCo
 	return l, nil
 }
 
+// isEmptyTreeError reports whether err is from listing an empty tree, which is not an error.
+func isEmptyTreeError(err error) bool {
+	return strings.Contains(err.Error(), "file does not exist") // TODO proper error value
+}
+
+// readDir returns the entries directly within this tree. The listing is memoized.
+func (r *gitTreeEntryResolver) readDir(ctx context.Context) ([]os.FileInfo, error) {
+	r.readDirOnce.Do(func() {
+		cachedRepo, err := backend.CachedGitRepo(ctx, r.commit.repo.repo)
+		if err != nil {
+			r.readDirErr = err
+			return
+		}
+		var entries []os.FileInfo
+		err = withGitTimeout(ctx, "ReadDir", func(ctx context.Context) (err error) {
+			entries, err = git.ReadDir(ctx, *cachedRepo, api.CommitID(r.commit.oid), r.path, false)
+			return err
+		})
+		if err == nil {
+			r.readDirEntries = entries
+		} else if !isEmptyTreeError(err) {
+			r.readDirErr = err
+		}
+	})
+	return r.readDirEntries, r.readDirErr
+}
+
+// SubdirectoryCount returns the number of directories directly within this directory.
+func (r *gitTreeEntryResolver) SubdirectoryCount(ctx context.Context, args *struct{ RespectGitignore bool }) (int32, error) {
+	return r.countChildren(ctx, args.RespectGitignore, func(fi os.FileInfo) bool { return fi.Mode().IsDir() })
+}
+
+// FileCountImmediate returns the number of files (non-directories, as in Files) directly within
+// this directory.
+func (r *gitTreeEntryResolver) FileCountImmediate(ctx context.Context, args *struct{ RespectGitignore bool }) (int32, error) {
+	return r.countChildren(ctx, args.RespectGitignore, func(fi os.FileInfo) bool { return !fi.Mode().IsDir() })
+}
+
+// countChildren returns the number of entries directly within this directory that match the
+// filter, from the memoized listing (see readDir).
+func (r *gitTreeEntryResolver) countChildren(ctx context.Context, respectGitignore bool, filter func(fi os.FileInfo) bool) (int32, error) {
+	if !r.IsDirectory() {
+		return 0, nil
+	}
+	entries, err := r.readDir(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if respectGitignore {
+		cachedRepo, err := backend.CachedGitRepo(ctx, r.commit.repo.repo)
+		if err != nil {
+			return 0, err
+		}
+		entries, err = filterGitignored(ctx, getGitignoreRules(*cachedRepo, api.CommitID(r.commit.oid)), r.path, entries)
+		if err != nil {
+			return 0, err
+		}
+	}
+	var n int32
+	for _, entry := range entries {
+		if filter(entry) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// readmeNames are the file names (compared case-insensitively) that Readme looks for, in order of
+// preference.
+var readmeNames = []string{"readme.md", "readme", "readme.txt"}
+
+// Readme returns the README file directly within this directory (not in its subdirectories), or
+// nil if there is none or this entry is not a directory.
+func (r *gitTreeEntryResolver) Readme(ctx context.Context) (*gitTreeEntryResolver, error) {
+	if !r.IsDirectory() {
+		return nil, nil
+	}
+	entries, err := r.readDir(ctx)
+	if err != nil {
+		return nil, err
+	}
+	readme := findReadme(entries)
+	if readme == nil {
+		return nil, nil
+	}
+	var prefix string
+	if r.path != "" {
+		prefix = r.path + "/"
+	}
+	return &gitTreeEntryResolver{commit: r.commit, path: prefix + readme.Name(), stat: readme}, nil
+}
+
+// findReadme returns the most preferred README file among entries, or nil if there is none.
+func findReadme(entries []os.FileInfo) os.FileInfo {
+	var readme os.FileInfo
+	readmeRank := len(readmeNames)
+	for _, entry := range entries {
+		if entry.Mode().IsDir() {
+			continue
+		}
+		for rank, name := range readmeNames {
+			if rank < readmeRank && strings.EqualFold(entry.Name(), name) {
+				readme, readmeRank = entry, rank
+				break
+			}
+		}
+	}
+	return readme
+}
+
+// maxRecursiveTreeEntries is the maximum number of entries returned by a recursive tree listing.
+// Trees with more entries are truncated so that a single request can't exhaust the frontend's
+// memory.
+const maxRecursiveTreeEntries = 100000
+
+// readDirRecursive returns the entries of the tree at path and of all of its subtrees, stopping
+// after maxRecursiveTreeEntries entries.
+//
+// The root tree is listed with git.ReadDir, which caches its listing (it is the most frequently
+// requested, and the slowest to list in large repositories), and then truncated. Other
+// trees are streamed with git.WalkTree, so that the listing stops being read (and the rest of it is
+// never materialized) once the limit is reached.
+func readDirRecursive(ctx context.Context, repo gitserver.Repo, commit api.CommitID, path string) ([]os.FileInfo, error) {
+	if path == "" {
+		entries, err := git.ReadDir(ctx, repo, commit, path, true)
+		if len(entries) > maxRecursiveTreeEntries {
+			entries = entries[:maxRecursiveTreeEntries]
+		}
+		// Copy entries so that callers that sort them don't reorder the cached listing.
+		return append([]os.FileInfo(nil), entries...), err
+	}
+
+	var entries []os.FileInfo
+	err := git.WalkTree(ctx, repo, commit, path, func(fi os.FileInfo) error {
+		entries = append(entries, fi)
+		if len(entries) >= maxRecursiveTreeEntries {
+			return git.StopWalk
+		}
+		return nil
+	})
+	return entries, err
+}
+
 type byDirectory []os.FileInfo
 
 func (s byDirectory) Len() int {
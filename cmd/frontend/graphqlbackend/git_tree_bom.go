@@ -0,0 +1,67 @@
+package graphqlbackend
+
+import (
+	"bytes"
+	"context"
+	"io"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/vcs/git"
+)
+
+// byteOrderMarks are the byte-order marks (BOMs) that are detected at the beginning of blobs: UTF-8,
+// UTF-16 (big-endian), and UTF-16 (little-endian).
+var byteOrderMarks = [][]byte{{0xEF, 0xBB, 0xBF}, {0xFE, 0xFF}, {0xFF, 0xFE}}
+
+// maxBOMLength is the length of the longest of byteOrderMarks.
+const maxBOMLength = 3
+
+// HasBOM reports whether this blob begins with a UTF-8 or UTF-16 byte-order mark. (The text fields
+// of a blob, such as content, omit it.) Only the first few bytes of the blob are read. It is false
+// for directories.
+func (r *gitTreeEntryResolver) HasBOM(ctx context.Context) (bool, error) {
+	if r.IsDirectory() {
+		return false, nil
+	}
+	cachedRepo, err := backend.CachedGitRepo(ctx, r.commit.repo.repo)
+	if err != nil {
+		return false, err
+	}
+	var prefix []byte
+	err = withGitTimeout(ctx, "ReadFile", func(ctx context.Context) error {
+		rc, err := git.NewFileReader(ctx, *cachedRepo, api.CommitID(r.commit.oid), r.path)
+		if err != nil {
+			return err
+		}
+		defer rc.Close()
+		buf := make([]byte, maxBOMLength)
+		n, err := io.ReadFull(rc, buf)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return err
+		}
+		prefix = buf[:n]
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return bomLength(prefix) > 0, nil
+}
+
+// bomLength returns the length of the byte-order mark at the beginning of content, or 0 if it has
+// none.
+func bomLength(content []byte) int {
+	for _, bom := range byteOrderMarks {
+		if bytes.HasPrefix(content, bom) {
+			return len(bom)
+		}
+	}
+	return 0
+}
+
+// stripBOM returns content without its byte-order mark (if any), so that rendering, highlighting,
+// and parsing the first line aren't thrown off by it.
+func stripBOM(content []byte) []byte {
+	return content[bomLength(content):]
+}
@@ -0,0 +1,96 @@
+package graphqlbackend
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/vcs/git"
+)
+
+func TestBOMLength(t *testing.T) {
+	tests := map[string]int{
+		"":                 0,
+		"a":                0,
+		"\xEF\xBB\xBFa":    3,
+		"\xEF\xBB":         0,
+		"\xFE\xFF\x00a":    2,
+		"\xFF\xFEa\x00":    2,
+		"a\xEF\xBB\xBF":    0,
+		"\xEF\xBB\xBF\xEF": 3,
+	}
+	for content, want := range tests {
+		if got := bomLength([]byte(content)); got != want {
+			t.Errorf("%q: got %d, want %d", content, got, want)
+		}
+	}
+}
+
+func TestGitTreeEntry_HasBOM(t *testing.T) {
+	contents := map[string]string{
+		"utf-8":    "\xEF\xBB\xBFpackage main\n" + strings.Repeat("x", 1000),
+		"utf-16le": "\xFF\xFEa\x00",
+		"none":     "package main\n",
+		"empty":    "",
+	}
+	var read int
+	git.Mocks.NewFileReader = func(commit api.CommitID, name string) (io.ReadCloser, error) {
+		content, ok := contents[name]
+		if !ok {
+			return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+		}
+		return ioutil.NopCloser(&countingReader{r: strings.NewReader(content), n: &read}), nil
+	}
+	defer git.ResetMocks()
+
+	commit := &gitCommitResolver{repo: &repositoryResolver{repo: &types.Repo{ID: 2, Name: "example.com/repo"}}, oid: exampleCommitSHA1}
+	want := map[string]bool{"utf-8": true, "utf-16le": true, "none": false, "empty": false}
+	for name, want := range want {
+		read = 0
+		r := &gitTreeEntryResolver{commit: commit, path: name, stat: createFileInfo(name, false)}
+		got, err := r.HasBOM(context.Background())
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+		if got != want {
+			t.Errorf("%s: got %v, want %v", name, got, want)
+		}
+		if read > maxBOMLength {
+			t.Errorf("%s: read %d bytes, want at most %d", name, read, maxBOMLength)
+		}
+	}
+
+	dir := &gitTreeEntryResolver{commit: commit, path: "dir", stat: createFileInfo("dir", true)}
+	if got, err := dir.HasBOM(context.Background()); err != nil || got {
+		t.Errorf("directory: got %v, %v, want false", got, err)
+	}
+}
+
+func TestGitTreeEntry_ContentWithoutBOM(t *testing.T) {
+	r := &gitTreeEntryResolver{path: "f", stat: createFileInfo("f", false)}
+	// Populate the memoized content, so that Content doesn't read it from the repository.
+	r.contentOnce.Do(func() { r.contentBytes = []byte("\xEF\xBB\xBFa\nb\n") })
+
+	got, err := r.Content(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "a\nb\n"; got != want {
+		t.Errorf("got content %q, want %q", got, want)
+	}
+	snippet, err := r.Snippet(context.Background(), &struct {
+		StartLine    int32
+		ContextLines int32
+	}{StartLine: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "a\n"; snippet.content != want {
+		t.Errorf("got snippet %q, want %q", snippet.content, want)
+	}
+}
@@ -0,0 +1,105 @@
+package graphqlbackend
+
+import (
+	"context"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/graphqlbackend/graphqlutil"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/vcs/git"
+)
+
+// Changes returns the files in this tree (recursively) that were added, removed, or modified
+// between the base revision and this tree's commit.
+func (r *gitTreeEntryResolver) Changes(ctx context.Context, args *struct {
+	graphqlutil.ConnectionArgs
+	Base string
+}) (*treeEntryChangeConnectionResolver, error) {
+	base, changes, err := r.changesSince(ctx, args.Base)
+	if err != nil {
+		return nil, err
+	}
+	return &treeEntryChangeConnectionResolver{base: base, head: r.commit, changes: changes, first: args.First}, nil
+}
+
+// changesSince resolves the base revision and returns its commit and the changes to this entry (or,
+// if it is a tree, to the files in it) between the base commit and this entry's commit.
+func (r *gitTreeEntryResolver) changesSince(ctx context.Context, baseRev string) (*gitCommitResolver, []*git.TreeChange, error) {
+	cachedRepo, err := backend.CachedGitRepo(ctx, r.commit.repo.repo)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Call ResolveRevision to trigger a fetch from the remote (in case the base commit doesn't
+	// exist).
+	var baseID api.CommitID
+	if err := withGitTimeout(ctx, "ResolveRevision", func(ctx context.Context) (err error) {
+		baseID, err = git.ResolveRevision(ctx, *cachedRepo, nil, baseRev, nil)
+		return err
+	}); err != nil {
+		return nil, nil, err
+	}
+	var baseCommit *git.Commit
+	if err := withGitTimeout(ctx, "GetCommit", func(ctx context.Context) (err error) {
+		baseCommit, err = git.GetCommit(ctx, *cachedRepo, baseID)
+		return err
+	}); err != nil {
+		return nil, nil, err
+	}
+
+	var changes []*git.TreeChange
+	if err := withGitTimeout(ctx, "DiffTree", func(ctx context.Context) (err error) {
+		changes, err = git.DiffTree(ctx, *cachedRepo, baseID, api.CommitID(r.commit.oid), r.path)
+		return err
+	}); err != nil {
+		return nil, nil, err
+	}
+	return toGitCommitResolver(r.commit.repo, baseCommit), changes, nil
+}
+
+type treeEntryChangeConnectionResolver struct {
+	base, head *gitCommitResolver
+	changes    []*git.TreeChange
+	first      *int32
+}
+
+func (r *treeEntryChangeConnectionResolver) Nodes() []*treeEntryChangeResolver {
+	changes := r.changes
+	if r.first != nil && len(changes) > int(*r.first) {
+		changes = changes[:*r.first]
+	}
+	resolvers := make([]*treeEntryChangeResolver, len(changes))
+	for i, change := range changes {
+		resolvers[i] = &treeEntryChangeResolver{base: r.base, head: r.head, change: change}
+	}
+	return resolvers
+}
+
+func (r *treeEntryChangeConnectionResolver) TotalCount() int32 { return int32(len(r.changes)) }
+
+func (r *treeEntryChangeConnectionResolver) PageInfo() *graphqlutil.PageInfo {
+	return graphqlutil.HasNextPage(r.first != nil && len(r.changes) > int(*r.first))
+}
+
+type treeEntryChangeResolver struct {
+	base, head *gitCommitResolver
+	change     *git.TreeChange
+}
+
+func (r *treeEntryChangeResolver) Path() string { return r.change.Path }
+
+func (r *treeEntryChangeResolver) ChangeKind() string { return r.change.Kind }
+
+func (r *treeEntryChangeResolver) OldEntry() *gitTreeEntryResolver {
+	if r.change.Kind == git.TreeChangeAdded {
+		return nil
+	}
+	return &gitTreeEntryResolver{commit: r.base, path: r.change.Path, stat: createFileInfo(r.change.Path, false)}
+}
+
+func (r *treeEntryChangeResolver) NewEntry() *gitTreeEntryResolver {
+	if r.change.Kind == git.TreeChangeRemoved {
+		return nil
+	}
+	return &gitTreeEntryResolver{commit: r.head, path: r.change.Path, stat: createFileInfo(r.change.Path, false)}
+}
@@ -0,0 +1,105 @@
+package graphqlbackend
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/graph-gophers/graphql-go/gqltesting"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/vcs/git"
+	"github.com/sourcegraph/sourcegraph/pkg/vcs/util"
+)
+
+func TestGitTree_Changes(t *testing.T) {
+	const baseCommitSHA1 = "abcdefabcdefabcdefabcdefabcdefabcdefabcd"
+
+	resetMocks()
+	db.Mocks.Repos.MockGetByName(t, "github.com/gorilla/mux", 2)
+	backend.Mocks.Repos.ResolveRev = func(ctx context.Context, repo *types.Repo, rev string) (api.CommitID, error) {
+		return exampleCommitSHA1, nil
+	}
+	backend.Mocks.Repos.MockGetCommit_Return_NoCheck(t, &git.Commit{ID: exampleCommitSHA1})
+
+	git.Mocks.Stat = func(commit api.CommitID, path string) (os.FileInfo, error) {
+		return &util.FileInfo{Name_: "", Mode_: os.ModeDir}, nil
+	}
+	git.Mocks.ResolveRevision = func(spec string, opt *git.ResolveRevisionOptions) (api.CommitID, error) {
+		if spec != "v1.0" {
+			t.Errorf("got base %q, want %q", spec, "v1.0")
+		}
+		return baseCommitSHA1, nil
+	}
+	git.Mocks.GetCommit = func(id api.CommitID) (*git.Commit, error) {
+		return &git.Commit{ID: id}, nil
+	}
+	git.Mocks.DiffTree = func(base, head api.CommitID, dir string) ([]*git.TreeChange, error) {
+		if base != baseCommitSHA1 || head != exampleCommitSHA1 || dir != "foo" {
+			t.Errorf("wrong arguments to DiffTree: %q, %q, %q", base, head, dir)
+		}
+		return []*git.TreeChange{
+			{Path: "foo/added", Kind: git.TreeChangeAdded},
+			{Path: "foo/modified", Kind: git.TreeChangeModified},
+			{Path: "foo/removed", Kind: git.TreeChangeRemoved},
+		}, nil
+	}
+	defer git.ResetMocks()
+
+	gqltesting.RunTests(t, []*gqltesting.Test{
+		{
+			Schema: GraphQLSchema,
+			Query: `
+				{
+					repository(name: "github.com/gorilla/mux") {
+						commit(rev: "` + exampleCommitSHA1 + `") {
+							tree(path: "foo") {
+								changes(base: "v1.0", first: 2) {
+									nodes {
+										path
+										changeKind
+										oldEntry { canonicalURL }
+										newEntry { canonicalURL }
+									}
+									totalCount
+									pageInfo { hasNextPage }
+								}
+							}
+						}
+					}
+				}
+			`,
+			ExpectedResult: `
+				{
+					"repository": {
+						"commit": {
+							"tree": {
+								"changes": {
+									"nodes": [
+										{
+											"path": "foo/added",
+											"changeKind": "ADDED",
+											"oldEntry": null,
+											"newEntry": {"canonicalURL": "/github.com/gorilla/mux@` + exampleCommitSHA1 + `/-/blob/foo/added"}
+										},
+										{
+											"path": "foo/modified",
+											"changeKind": "MODIFIED",
+											"oldEntry": {"canonicalURL": "/github.com/gorilla/mux@` + baseCommitSHA1 + `/-/blob/foo/modified"},
+											"newEntry": {"canonicalURL": "/github.com/gorilla/mux@` + exampleCommitSHA1 + `/-/blob/foo/modified"}
+										}
+									],
+									"totalCount": 3,
+									"pageInfo": {"hasNextPage": true}
+								}
+							}
+						}
+					}
+				}
+			`,
+		},
+	})
+}
@@ -0,0 +1,69 @@
+package graphqlbackend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/globals"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/signedurl"
+	"github.com/sourcegraph/sourcegraph/pkg/actor"
+)
+
+// SignedDownloadURL returns an absolute, short-lived URL from which the raw contents of this blob
+// (or a zip archive of this tree) at this commit can be downloaded as the current user, without a
+// session or access token (see package signedurl). It is nil if the current user is anonymous or
+// signed URLs are disabled.
+func (r *gitTreeEntryResolver) SignedDownloadURL(ctx context.Context) (*string, error) {
+	// 🚨 SECURITY: The URL authenticates as the current user, so only sign it for that user.
+	a := actor.FromContext(ctx)
+	if !a.IsAuthenticated() {
+		return nil, nil
+	}
+
+	downloadPath := r.commit.canonicalRepoRevURL() + "/-/raw/" + r.path
+	query, err := signedurl.Sign(downloadPath, a.UID)
+	if err == signedurl.ErrDisabled {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if r.IsDirectory() {
+		query.Set("format", "zip")
+	}
+	u := globals.ExternalURL.ResolveReference(&url.URL{Path: downloadPath, RawQuery: query.Encode()}).String()
+	return &u, nil
+}
+
+// Possible values of the format argument of ArchiveURL.
+const (
+	archiveFormatZip = "ZIP"
+	archiveFormatTar = "TAR"
+	archiveFormatTgz = "TGZ"
+)
+
+// ArchiveURL returns the URL from which an archive of this tree at this commit can be downloaded in
+// the format (see serveRaw, which streams the archive from git archive). It returns an error for a
+// blob, whose contents are downloaded from its raw URL instead.
+func (r *gitTreeEntryResolver) ArchiveURL(args *struct{ Format string }) (string, error) {
+	if !r.IsDirectory() {
+		return "", errors.New("archiveURL is only defined for a tree")
+	}
+	var format string
+	switch args.Format {
+	case archiveFormatZip:
+		format = "zip"
+	case archiveFormatTar:
+		format = "tar"
+	case archiveFormatTgz:
+		format = "tgz"
+	default:
+		return "", fmt.Errorf("unsupported archive format %q", args.Format)
+	}
+	archiveURL := url.URL{
+		Path:     r.commit.canonicalRepoRevURL() + "/-/raw/" + r.path,
+		RawQuery: url.Values{"format": []string{format}}.Encode(),
+	}
+	return archiveURL.String(), nil
+}
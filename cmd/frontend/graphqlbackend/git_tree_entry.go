@@ -6,6 +6,8 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"strings"
+	"sync"
 	"time"
 
 	log15 "gopkg.in/inconshreveable/log15.v2"
@@ -26,11 +28,85 @@ type gitTreeEntryResolver struct {
 	stat os.FileInfo // this tree entry's file info
 
 	isRecursive bool // whether entries is populated recursively (otherwise just current level of hierarchy)
+
+	lastCommits     *lastCommitBatch // resolves LastCommit together with sibling entries (optional)
+	lastCommitsOnce sync.Once        // creates lastCommits if it is not set
+
+	// siblingCount is the number of entries in this entry's parent directory (including this
+	// entry), if known from the listing that produced this entry, or 0 if unknown.
+	siblingCount int
+
+	// submoduleOnce memoizes the submodule (if any) and its repository name.
+	submoduleOnce     sync.Once
+	submodule         *gitSubmoduleResolver
+	submoduleRepoName string
+	submoduleRepoErr  error
+
+	// readDirOnce memoizes the (non-recursive) listing of this tree, so that the entries fields and
+	// the counts of its children use a single git.ReadDir call.
+	readDirOnce    sync.Once
+	readDirEntries []os.FileInfo
+	readDirErr     error
+
+	// contentOnce memoizes the content of this blob and whether it is binary, so that Content,
+	// Binary, and TotalLines read it only once.
+	contentOnce   sync.Once
+	contentBytes  []byte
+	contentBinary bool
+	contentErr    error
 }
 
 func (r *gitTreeEntryResolver) Path() string { return r.path }
 func (r *gitTreeEntryResolver) Name() string { return path.Base(r.path) }
 
+// Depth returns the number of components of this tree entry's path, after cleaning it (so that,
+// e.g., "a/b", "/a/b", and "a//b/" all have depth 2). The root has depth 0.
+func (r *gitTreeEntryResolver) Depth() int32 {
+	if r.IsRoot() {
+		return 0
+	}
+	return int32(strings.Count(strings.Trim(path.Clean(r.path), "/"), "/") + 1)
+}
+
+// PathComponents returns the components of this tree entry's path, cleaned as a path relative to the
+// repository root (as in relativePath), so that clients can build breadcrumbs and links without
+// splitting the path themselves. The root has no components.
+func (r *gitTreeEntryResolver) PathComponents() []string {
+	p := strings.TrimPrefix(path.Clean("/"+filepath.ToSlash(r.path)), "/")
+	if p == "" {
+		return []string{}
+	}
+	return strings.Split(p, "/")
+}
+
+// RelativePath returns this tree entry's path relative to the directory base (which is relative
+// to the repository root). It returns an error if base is not this entry or one of its ancestors.
+func (r *gitTreeEntryResolver) RelativePath(args *struct{ Base string }) (string, error) {
+	return relativePath(args.Base, r.path)
+}
+
+func relativePath(base, target string) (string, error) {
+	// Clean both paths as repository-root-relative paths, so that "", ".", and "/" all refer to
+	// the root.
+	base = strings.TrimPrefix(path.Clean("/"+filepath.ToSlash(base)), "/")
+	target = strings.TrimPrefix(path.Clean("/"+filepath.ToSlash(target)), "/")
+	if base == "" {
+		base = "."
+	}
+	if target == "" {
+		target = "."
+	}
+	rel, err := filepath.Rel(filepath.FromSlash(base), filepath.FromSlash(target))
+	if err != nil {
+		return "", err
+	}
+	rel = filepath.ToSlash(rel)
+	if rel == ".." || strings.HasPrefix(rel, "../") {
+		return "", fmt.Errorf("base path %q is not an ancestor of %q", base, target)
+	}
+	return rel, nil
+}
+
 func (r *gitTreeEntryResolver) ToGitTree() (*gitTreeEntryResolver, bool) { return r, true }
 func (r *gitTreeEntryResolver) ToGitBlob() (*gitTreeEntryResolver, bool) { return r, true }
 
@@ -42,10 +118,11 @@ func (r *gitTreeEntryResolver) IsRecursive() bool { return r.isRecursive }
 
 func (r *gitTreeEntryResolver) URL() string {
 	if submodule := r.Submodule(); submodule != nil {
-		repoName, err := cloneURLToRepoName(submodule.URL())
+		repoName, err := r.submoduleRepo()
 		if err != nil {
+			// Link to the submodule on its code host instead (see gitSubmoduleResolver.Status).
 			log15.Error("Failed to resolve submodule repository name from clone URL", "cloneURL", submodule.URL())
-			return ""
+			return submodule.webURL()
 		}
 		return "/" + repoName + "@" + submodule.Commit()
 	}
@@ -72,15 +149,90 @@ func (r *gitTreeEntryResolver) urlPath(prefix string) string {
 
 func (r *gitTreeEntryResolver) IsDirectory() bool { return r.stat.Mode().IsDir() }
 
+// Icon returns a hint for which icon a client should display for this tree entry: "submodule",
+// "folder", "symlink", "executable", or "file".
+func (r *gitTreeEntryResolver) Icon() string {
+	switch r.gitMode() {
+	case gitModeSubmodule:
+		return "submodule"
+	case gitModeTree:
+		return "folder"
+	case gitModeSymlink:
+		return "symlink"
+	case gitModeExecutable:
+		return "executable"
+	default:
+		return "file"
+	}
+}
+
+// The Git modes of tree entries (as in "git ls-tree" output).
+const (
+	gitModeRegular    = 0100644
+	gitModeExecutable = 0100755
+	gitModeSymlink    = 0120000
+	gitModeTree       = 040000
+	gitModeSubmodule  = 0160000
+)
+
+// gitMode returns the Git mode of this tree entry, derived from the file mode of its stat (as
+// returned by git.ReadDir or git.Stat).
+func (r *gitTreeEntryResolver) gitMode() int32 {
+	mode := r.stat.Mode()
+	switch {
+	case r.Submodule() != nil || mode&git.ModeSubmodule == git.ModeSubmodule:
+		return gitModeSubmodule
+	case mode.IsDir():
+		return gitModeTree
+	case mode&os.ModeSymlink != 0:
+		return gitModeSymlink
+	case mode.Perm()&0111 != 0:
+		return gitModeExecutable
+	default:
+		return gitModeRegular
+	}
+}
+
+func (r *gitTreeEntryResolver) IsExecutable() bool { return r.gitMode() == gitModeExecutable }
+
+func (r *gitTreeEntryResolver) IsSymlink() bool { return r.gitMode() == gitModeSymlink }
+
+func (r *gitTreeEntryResolver) GitModeOctal() int32 { return r.gitMode() }
+
+// GitMode returns the Git mode of this tree entry formatted as in "git ls-tree" output (6 octal
+// digits, zero-padded, so a tree is "040000").
+func (r *gitTreeEntryResolver) GitMode() string { return fmt.Sprintf("%06o", r.gitMode()) }
+
+// ExternalURLs returns the URLs to this tree entry on external services. For the root tree, they
+// are the URLs to the repository's home page (instead of to the root directory at this revision).
 func (r *gitTreeEntryResolver) ExternalURLs(ctx context.Context) ([]*externallink.Resolver, error) {
+	if r.IsRoot() {
+		return externallink.Repository(ctx, r.commit.repo.repo)
+	}
 	return externallink.FileOrDir(ctx, r.commit.repo.repo, r.commit.inputRevOrImmutableRev(), r.path, r.stat.Mode().IsDir())
 }
 
 func (r *gitTreeEntryResolver) Submodule() *gitSubmoduleResolver {
-	if submoduleInfo, ok := r.stat.Sys().(git.Submodule); ok {
-		return &gitSubmoduleResolver{submodule: submoduleInfo}
-	}
-	return nil
+	r.resolveSubmodule()
+	return r.submodule
+}
+
+// submoduleRepo returns the name of the repository of the submodule (which must exist), as resolved
+// from its clone URL.
+func (r *gitTreeEntryResolver) submoduleRepo() (string, error) {
+	r.resolveSubmodule()
+	return r.submoduleRepoName, r.submoduleRepoErr
+}
+
+// resolveSubmodule resolves the submodule of this entry (if any) and its repository name once per
+// resolver, because URL, Icon, and Submodule all need them.
+func (r *gitTreeEntryResolver) resolveSubmodule() {
+	r.submoduleOnce.Do(func() {
+		if submoduleInfo, ok := r.stat.Sys().(git.Submodule); ok {
+			r.submodule = &gitSubmoduleResolver{submodule: submoduleInfo}
+			r.submoduleRepoName, r.submoduleRepoErr = cloneURLToRepoName(r.submodule.URL())
+		}
+	})
 }
 
 func cloneURLToRepoName(cloneURL string) (string, error) {
@@ -102,12 +254,19 @@ func (r *gitTreeEntryResolver) IsSingleChild(ctx context.Context, args *gitTreeE
 	if !r.IsDirectory() {
 		return false, nil
 	}
+	if r.siblingCount > 0 {
+		// Avoid listing the parent directory again.
+		return r.siblingCount == 1, nil
+	}
 	cachedRepo, err := backend.CachedGitRepo(ctx, r.commit.repo.repo)
 	if err != nil {
 		return false, err
 	}
-	entries, err := git.ReadDir(ctx, *cachedRepo, api.CommitID(r.commit.oid), filepath.Dir(r.path), false)
-	if err != nil {
+	var entries []os.FileInfo
+	if err := withGitTimeout(ctx, "ReadDir", func(ctx context.Context) (err error) {
+		entries, err = git.ReadDir(ctx, *cachedRepo, api.CommitID(r.commit.oid), filepath.Dir(r.path), false)
+		return err
+	}); err != nil {
 		return false, err
 	}
 	return len(entries) == 1, nil
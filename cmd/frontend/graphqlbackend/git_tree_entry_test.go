@@ -0,0 +1,472 @@
+package graphqlbackend
+
+import (
+	"context"
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/graph-gophers/graphql-go/gqltesting"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/repoupdater"
+	"github.com/sourcegraph/sourcegraph/pkg/repoupdater/protocol"
+	"github.com/sourcegraph/sourcegraph/pkg/vcs/git"
+	"github.com/sourcegraph/sourcegraph/pkg/vcs/util"
+)
+
+func TestRelativePath(t *testing.T) {
+	tests := []struct {
+		base, target string
+		want         string
+		wantErr      bool
+	}{
+		{base: "", target: "a/b/c.go", want: "a/b/c.go"},
+		{base: "/", target: "a/b/c.go", want: "a/b/c.go"},
+		{base: ".", target: "a", want: "a"},
+		{base: "a", target: "a/b/c.go", want: "b/c.go"},
+		{base: "a/", target: "a/b/c.go", want: "b/c.go"},
+		{base: "a/b", target: "a/b", want: "."},
+		{base: "", target: "", want: "."},
+		{base: "a/b", target: "a", wantErr: true},
+		{base: "x", target: "a/b/c.go", wantErr: true},
+		{base: "a/bc", target: "a/b/c.go", wantErr: true},
+	}
+	for _, test := range tests {
+		got, err := relativePath(test.base, test.target)
+		if (err != nil) != test.wantErr {
+			t.Errorf("relativePath(%q, %q): got error %v, want error %v", test.base, test.target, err, test.wantErr)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("relativePath(%q, %q): got %q, want %q", test.base, test.target, got, test.want)
+		}
+	}
+}
+
+func TestGitTreeEntry_Depth(t *testing.T) {
+	tests := map[string]int32{
+		"":       0,
+		".":      0,
+		"/":      0,
+		"a":      1,
+		"a/b":    2,
+		"/a/b":   2,
+		"a//b/":  2,
+		"a/./b":  2,
+		"a/b/c/": 3,
+	}
+	for path, want := range tests {
+		if got := (&gitTreeEntryResolver{path: path}).Depth(); got != want {
+			t.Errorf("%q: got %d, want %d", path, got, want)
+		}
+	}
+}
+
+func TestGitTreeEntry_PathComponents(t *testing.T) {
+	tests := map[string][]string{
+		"":         {},
+		".":        {},
+		"/":        {},
+		"a":        {"a"},
+		"a/b":      {"a", "b"},
+		"/a/b":     {"a", "b"},
+		"a//b/":    {"a", "b"},
+		"a/./b":    {"a", "b"},
+		"a/../b":   {"b"},
+		"../a":     {"a"},
+		"a/b/c.go": {"a", "b", "c.go"},
+	}
+	for path, want := range tests {
+		got := (&gitTreeEntryResolver{path: path}).PathComponents()
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%q: got %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestGitTreeEntry_Icon(t *testing.T) {
+	tests := map[string]struct {
+		stat os.FileInfo
+		want string
+	}{
+		"directory":  {stat: &util.FileInfo{Name_: "d", Mode_: os.ModeDir | 0755}, want: "folder"},
+		"file":       {stat: &util.FileInfo{Name_: "f", Mode_: 0644}, want: "file"},
+		"executable": {stat: &util.FileInfo{Name_: "x", Mode_: 0755}, want: "executable"},
+		"symlink":    {stat: &util.FileInfo{Name_: "l", Mode_: os.ModeSymlink}, want: "symlink"},
+		"submodule":  {stat: &util.FileInfo{Name_: "s", Mode_: git.ModeSubmodule, Sys_: git.Submodule{URL: "https://example.com/r"}}, want: "submodule"},
+	}
+	for label, test := range tests {
+		r := &gitTreeEntryResolver{stat: test.stat}
+		if got := r.Icon(); got != test.want {
+			t.Errorf("%s: got %q, want %q", label, got, test.want)
+		}
+	}
+}
+
+func TestGitTreeEntry_GitMode(t *testing.T) {
+	// The stats are as returned by git.ReadDir for each Git mode.
+	tests := map[string]struct {
+		stat             os.FileInfo
+		wantExecutable   bool
+		wantSymlink      bool
+		wantGitModeOctal int32
+		wantGitMode      string
+	}{
+		"regular file": {stat: &util.FileInfo{Name_: "f", Mode_: 0100644 | 0644}, wantGitModeOctal: 0100644, wantGitMode: "100644"},
+		"executable":   {stat: &util.FileInfo{Name_: "x", Mode_: 0100755 | 0644}, wantExecutable: true, wantGitModeOctal: 0100755, wantGitMode: "100755"},
+		"symlink":      {stat: &util.FileInfo{Name_: "l", Mode_: os.ModeSymlink}, wantSymlink: true, wantGitModeOctal: 0120000, wantGitMode: "120000"},
+		"directory":    {stat: &util.FileInfo{Name_: "d", Mode_: 040000 | os.ModeDir}, wantGitModeOctal: 040000, wantGitMode: "040000"},
+		"submodule":    {stat: &util.FileInfo{Name_: "s", Mode_: git.ModeSubmodule, Sys_: git.Submodule{URL: "https://example.com/r"}}, wantGitModeOctal: 0160000, wantGitMode: "160000"},
+	}
+	for label, test := range tests {
+		r := &gitTreeEntryResolver{stat: test.stat}
+		if got := r.IsExecutable(); got != test.wantExecutable {
+			t.Errorf("%s: got isExecutable %v, want %v", label, got, test.wantExecutable)
+		}
+		if got := r.IsSymlink(); got != test.wantSymlink {
+			t.Errorf("%s: got isSymlink %v, want %v", label, got, test.wantSymlink)
+		}
+		if got := r.GitModeOctal(); got != test.wantGitModeOctal {
+			t.Errorf("%s: got gitModeOctal %o, want %o", label, got, test.wantGitModeOctal)
+		}
+		if got := r.GitMode(); got != test.wantGitMode {
+			t.Errorf("%s: got gitMode %q, want %q", label, got, test.wantGitMode)
+		}
+	}
+}
+
+func TestGitTreeEntry_GitModeSchema(t *testing.T) {
+	resetMocks()
+	db.Mocks.Repos.MockGetByName(t, "github.com/gorilla/mux", 2)
+	backend.Mocks.Repos.ResolveRev = func(ctx context.Context, repo *types.Repo, rev string) (api.CommitID, error) {
+		return exampleCommitSHA1, nil
+	}
+	backend.Mocks.Repos.MockGetCommit_Return_NoCheck(t, &git.Commit{ID: exampleCommitSHA1})
+
+	entries := []os.FileInfo{
+		&util.FileInfo{Name_: "d", Mode_: 040000 | os.ModeDir},
+		&util.FileInfo{Name_: "f", Mode_: 0100644 | 0644},
+		&util.FileInfo{Name_: "l", Mode_: os.ModeSymlink},
+		&util.FileInfo{Name_: "s", Mode_: git.ModeSubmodule, Sys_: git.Submodule{URL: "https://example.com/r"}},
+		&util.FileInfo{Name_: "x", Mode_: 0100755 | 0644},
+	}
+	git.Mocks.Stat = func(commit api.CommitID, path string) (os.FileInfo, error) {
+		if path == "" {
+			return &util.FileInfo{Name_: "", Mode_: os.ModeDir}, nil
+		}
+		for _, entry := range entries {
+			if entry.Name() == path {
+				return entry, nil
+			}
+		}
+		return nil, &os.PathError{Op: "stat", Path: path, Err: os.ErrNotExist}
+	}
+	git.Mocks.ReadDir = func(commit api.CommitID, name string, recurse bool) ([]os.FileInfo, error) {
+		return entries, nil
+	}
+	defer git.ResetMocks()
+
+	gqltesting.RunTests(t, []*gqltesting.Test{
+		{
+			Schema: GraphQLSchema,
+			Query: `
+				{
+					repository(name: "github.com/gorilla/mux") {
+						commit(rev: "` + exampleCommitSHA1 + `") {
+							tree(path: "") {
+								gitMode
+								entries {
+									path
+									gitMode
+								}
+							}
+							blob(path: "x") {
+								gitMode
+							}
+						}
+					}
+				}
+			`,
+			ExpectedResult: `
+				{
+					"repository": {
+						"commit": {
+							"tree": {
+								"gitMode": "040000",
+								"entries": [
+									{"path": "d", "gitMode": "040000"},
+									{"path": "f", "gitMode": "100644"},
+									{"path": "l", "gitMode": "120000"},
+									{"path": "s", "gitMode": "160000"},
+									{"path": "x", "gitMode": "100755"}
+								]
+							},
+							"blob": {
+								"gitMode": "100755"
+							}
+						}
+					}
+				}
+			`,
+		},
+	})
+}
+
+func TestGitTreeEntry_SubmoduleMemoized(t *testing.T) {
+	r := &gitTreeEntryResolver{
+		path: "s",
+		stat: &util.FileInfo{Name_: "s", Mode_: git.ModeSubmodule, Sys_: git.Submodule{URL: "https://github.com/gorilla/mux", CommitID: exampleCommitSHA1}},
+	}
+	submodule := r.Submodule()
+	if submodule == nil {
+		t.Fatal("got nil submodule")
+	}
+	if r.Submodule() != submodule {
+		t.Error("got a different submodule resolver from the second call")
+	}
+	if want := "/github.com/gorilla/mux@" + exampleCommitSHA1; r.URL() != want {
+		t.Errorf("got URL %q, want %q", r.URL(), want)
+	}
+
+	if r := (&gitTreeEntryResolver{stat: &util.FileInfo{Name_: "f", Mode_: 0644}}); r.Submodule() != nil {
+		t.Error("got non-nil submodule for a file")
+	}
+}
+
+type submoduleRepoNotFoundError struct{}
+
+func (submoduleRepoNotFoundError) Error() string  { return "repo not found" }
+func (submoduleRepoNotFoundError) NotFound() bool { return true }
+
+func TestGitSubmodule_Status(t *testing.T) {
+	ctx := context.Background()
+	tests := map[string]struct {
+		url           string
+		repoNotFound  bool
+		commitMissing bool
+		want          string
+		wantURL       string
+	}{
+		"resolved": {
+			url:     "https://github.com/gorilla/mux",
+			want:    submoduleStatusResolved,
+			wantURL: "/github.com/gorilla/mux@" + exampleCommitSHA1,
+		},
+		"commit not found": {
+			url:           "https://github.com/gorilla/mux",
+			commitMissing: true,
+			want:          submoduleStatusCommitNotFound,
+			wantURL:       "/github.com/gorilla/mux@" + exampleCommitSHA1,
+		},
+		"repo not found": {
+			url:          "https://github.com/gorilla/mux",
+			repoNotFound: true,
+			want:         submoduleStatusRepoNotFound,
+			wantURL:      "/github.com/gorilla/mux@" + exampleCommitSHA1,
+		},
+		"bad URL": {
+			url:     "https://user@example.com/owner/repo.git",
+			want:    submoduleStatusBadURL,
+			wantURL: "https://example.com/owner/repo",
+		},
+		"bad URL (SCP-style)": {
+			url:     "git@example.com:owner/repo.git",
+			want:    submoduleStatusBadURL,
+			wantURL: "https://example.com/owner/repo",
+		},
+		"bad URL (not a web URL)": {
+			url:     "file:///tmp/repo",
+			want:    submoduleStatusBadURL,
+			wantURL: "",
+		},
+	}
+	for label, test := range tests {
+		resetMocks()
+		db.Mocks.Repos.GetByName = func(ctx context.Context, name api.RepoName) (*types.Repo, error) {
+			if test.repoNotFound {
+				return nil, submoduleRepoNotFoundError{}
+			}
+			return &types.Repo{ID: 2, Name: name}, nil
+		}
+		backend.Mocks.Repos.ResolveRev = func(ctx context.Context, repo *types.Repo, rev string) (api.CommitID, error) {
+			if test.commitMissing {
+				return "", &git.RevisionNotFoundError{Repo: repo.Name, Spec: rev}
+			}
+			return api.CommitID(rev), nil
+		}
+
+		r := &gitTreeEntryResolver{
+			path: "s",
+			stat: &util.FileInfo{Name_: "s", Mode_: git.ModeSubmodule, Sys_: git.Submodule{URL: test.url, CommitID: exampleCommitSHA1}},
+		}
+		status, err := r.Submodule().Status(ctx)
+		if err != nil {
+			t.Fatalf("%s: %s", label, err)
+		}
+		if status != test.want {
+			t.Errorf("%s: got status %q, want %q", label, status, test.want)
+		}
+		if got := r.URL(); got != test.wantURL {
+			t.Errorf("%s: got URL %q, want %q", label, got, test.wantURL)
+		}
+	}
+}
+
+func TestGitSubmodule_RepoName(t *testing.T) {
+	resetMocks()
+	ctx := context.Background()
+	r := &gitSubmoduleResolver{submodule: git.Submodule{URL: "https://github.com/gorilla/mux", Path: "vendor/mux", CommitID: exampleCommitSHA1}}
+	if got, err := r.RepoName(ctx); err != nil {
+		t.Fatal(err)
+	} else if want := "github.com/gorilla/mux"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got, want := r.Commit(), string(exampleCommitSHA1); got != want {
+		t.Errorf("got commit %q, want %q", got, want)
+	}
+
+	r = &gitSubmoduleResolver{submodule: git.Submodule{URL: "https://user@example.com/owner/repo.git", Path: "vendor/repo"}}
+	if _, err := r.RepoName(ctx); err == nil || !strings.Contains(err.Error(), "vendor/repo") {
+		t.Errorf("got error %v, want an error for the submodule's unknown code host", err)
+	}
+}
+
+func TestGitTreeEntry_EnclosingSubmodule(t *testing.T) {
+	var statCalls []string
+	git.Mocks.Stat = func(commit api.CommitID, name string) (os.FileInfo, error) {
+		statCalls = append(statCalls, name)
+		switch name {
+		case "vendor", "docs", "docs/a":
+			return &util.FileInfo{Name_: name, Mode_: os.ModeDir}, nil
+		case "vendor/lib":
+			return &util.FileInfo{Name_: "lib", Mode_: git.ModeSubmodule, Sys_: git.Submodule{URL: "https://github.com/gorilla/mux", Path: "vendor/lib", CommitID: exampleCommitSHA1}}, nil
+		}
+		return nil, &os.PathError{Op: "ls-tree", Path: name, Err: os.ErrNotExist}
+	}
+	defer git.ResetMocks()
+
+	tests := map[string]struct {
+		path          string
+		wantPath      string // the path of the enclosing submodule, or "" for none
+		wantStatCalls []string
+	}{
+		"inside submodule": {path: "vendor/lib/src/a.go", wantPath: "vendor/lib", wantStatCalls: []string{"vendor/lib/src", "vendor/lib"}},
+		"in directory":     {path: "docs/a/b.md", wantStatCalls: []string{"docs/a"}},
+		"at root":          {path: "README.md"},
+	}
+	for label, test := range tests {
+		statCalls = nil
+		r := &gitTreeEntryResolver{
+			commit: &gitCommitResolver{repo: &repositoryResolver{repo: &types.Repo{ID: 2, Name: "github.com/gorilla/mux"}}, oid: exampleCommitSHA1},
+			path:   test.path,
+			stat:   createFileInfo(test.path, false),
+		}
+		submodule, err := r.EnclosingSubmodule(context.Background())
+		if err != nil {
+			t.Fatalf("%s: %s", label, err)
+		}
+		var gotPath string
+		if submodule != nil {
+			gotPath = submodule.Path()
+		}
+		if gotPath != test.wantPath {
+			t.Errorf("%s: got enclosing submodule %q, want %q", label, gotPath, test.wantPath)
+		}
+		if !reflect.DeepEqual(statCalls, test.wantStatCalls) {
+			t.Errorf("%s: got Stat calls %q, want %q", label, statCalls, test.wantStatCalls)
+		}
+	}
+}
+
+func TestGitTreeEntry_IsRootMatchesURL(t *testing.T) {
+	commit := &gitCommitResolver{repo: &repositoryResolver{repo: &types.Repo{Name: "github.com/gorilla/mux"}}, oid: exampleCommitSHA1}
+	repoRevURL := "/github.com/gorilla/mux@" + exampleCommitSHA1
+	tests := map[string]bool{
+		"":    true,
+		"/":   true,
+		".":   true,
+		"mux": false,
+		"a/b": false,
+	}
+	for p, wantRoot := range tests {
+		r := &gitTreeEntryResolver{commit: commit, path: p, stat: createFileInfo(p, true)}
+		if got := r.IsRoot(); got != wantRoot {
+			t.Errorf("%q: got isRoot %v, want %v", p, got, wantRoot)
+		}
+		// The URL of the root is the repository revision's URL, and only the root's is.
+		if got := r.URL() == repoRevURL; got != wantRoot {
+			t.Errorf("%q: got URL %q, which disagrees with isRoot %v", p, r.URL(), wantRoot)
+		}
+		if got := r.CanonicalURL() == repoRevURL; got != wantRoot {
+			t.Errorf("%q: got canonical URL %q, which disagrees with isRoot %v", p, r.CanonicalURL(), wantRoot)
+		}
+	}
+}
+
+func TestGitTreeEntry_ArchiveURL(t *testing.T) {
+	commit := &gitCommitResolver{repo: &repositoryResolver{repo: &types.Repo{Name: "github.com/gorilla/mux"}}, oid: exampleCommitSHA1}
+	tree := &gitTreeEntryResolver{commit: commit, path: "a/b", stat: createFileInfo("a/b", true)}
+	tests := map[string]string{
+		archiveFormatZip: "/github.com/gorilla/mux@" + exampleCommitSHA1 + "/-/raw/a/b?format=zip",
+		archiveFormatTar: "/github.com/gorilla/mux@" + exampleCommitSHA1 + "/-/raw/a/b?format=tar",
+		archiveFormatTgz: "/github.com/gorilla/mux@" + exampleCommitSHA1 + "/-/raw/a/b?format=tgz",
+	}
+	for format, want := range tests {
+		got, err := tree.ArchiveURL(&struct{ Format string }{Format: format})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("%s: got %q, want %q", format, got, want)
+		}
+	}
+
+	if _, err := tree.ArchiveURL(&struct{ Format string }{Format: "RAR"}); err == nil {
+		t.Error("got nil error for an unsupported format, want error")
+	}
+	blob := &gitTreeEntryResolver{commit: commit, path: "a/b/c.go", stat: createFileInfo("a/b/c.go", false)}
+	if _, err := blob.ArchiveURL(&struct{ Format string }{Format: archiveFormatZip}); err == nil {
+		t.Error("got nil error for a blob, want error")
+	}
+}
+
+func TestGitTreeEntry_ExternalURLs(t *testing.T) {
+	resetMocks()
+	repoupdater.MockRepoLookup = func(protocol.RepoLookupArgs) (*protocol.RepoLookupResult, error) {
+		return &protocol.RepoLookupResult{
+			Repo: &protocol.RepoInfo{
+				Links: &protocol.RepoLinks{
+					Root: "https://github.com/gorilla/mux",
+					Tree: "https://github.com/gorilla/mux/tree/{rev}/{path}",
+				},
+			},
+		}, nil
+	}
+	defer func() { repoupdater.MockRepoLookup = nil }()
+	db.Mocks.Phabricator.GetByName = func(repo api.RepoName) (*types.PhabricatorRepo, error) {
+		return nil, errors.New("x")
+	}
+
+	commit := &gitCommitResolver{repo: &repositoryResolver{repo: &types.Repo{Name: "github.com/gorilla/mux"}}, oid: exampleCommitSHA1}
+	tests := map[string]string{
+		"":    "https://github.com/gorilla/mux",
+		"/":   "https://github.com/gorilla/mux",
+		"a/b": "https://github.com/gorilla/mux/tree/" + exampleCommitSHA1 + "/a/b",
+	}
+	for path, want := range tests {
+		r := &gitTreeEntryResolver{commit: commit, path: path, stat: createFileInfo(path, true)}
+		links, err := r.ExternalURLs(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(links) != 1 || links[0].URL() != want {
+			t.Errorf("%q: got %v, want %s", path, links, want)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package graphqlbackend
+
+import (
+	"context"
+	"os"
+	"strings"
+	"sync"
+
+	"gopkg.in/src-d/go-git.v4/plumbing/format/gitignore"
+
+	"github.com/golang/groupcache/lru"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/gitserver"
+	"github.com/sourcegraph/sourcegraph/pkg/vcs/git"
+)
+
+// gitignoreRules are the ignore rules defined by the .gitignore files committed in a repository at
+// a commit. Only committed .gitignore files are considered (not .git/info/exclude or a user's
+// global excludes file), because there is no working copy. Each directory's .gitignore file is
+// read lazily, the first time a path in that directory is matched.
+type gitignoreRules struct {
+	repo   gitserver.Repo
+	commit api.CommitID
+
+	mu    sync.Mutex
+	byDir map[string][]gitignore.Pattern // directory path (relative to the root) -> its .gitignore patterns
+}
+
+// gitignoreRulesCache caches the gitignoreRules for recently listed (repo, commit) pairs. Commits
+// are immutable, so entries never need to be invalidated.
+var (
+	gitignoreRulesCacheMu sync.Mutex
+	gitignoreRulesCache   = lru.New(50)
+)
+
+func getGitignoreRules(repo gitserver.Repo, commit api.CommitID) *gitignoreRules {
+	key := string(repo.Name) + ":" + string(commit)
+	gitignoreRulesCacheMu.Lock()
+	defer gitignoreRulesCacheMu.Unlock()
+	if v, ok := gitignoreRulesCache.Get(key); ok {
+		return v.(*gitignoreRules)
+	}
+	rules := &gitignoreRules{repo: repo, commit: commit, byDir: map[string][]gitignore.Pattern{}}
+	gitignoreRulesCache.Add(key, rules)
+	return rules
+}
+
+// patterns returns the patterns in dir's .gitignore file (or nil if it has none).
+func (g *gitignoreRules) patterns(ctx context.Context, dir string) ([]gitignore.Pattern, error) {
+	g.mu.Lock()
+	ps, ok := g.byDir[dir]
+	g.mu.Unlock()
+	if ok {
+		return ps, nil
+	}
+
+	name := ".gitignore"
+	var domain []string
+	if dir != "" {
+		name = dir + "/" + name
+		domain = strings.Split(dir, "/")
+	}
+	var data []byte
+	err := withGitTimeout(ctx, "ReadFile", func(ctx context.Context) (err error) {
+		data, err = git.ReadFile(ctx, g.repo, g.commit, name)
+		return err
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		if strings.HasPrefix(line, "#") || strings.TrimSpace(line) == "" {
+			continue
+		}
+		ps = append(ps, gitignore.ParsePattern(line, domain))
+	}
+
+	g.mu.Lock()
+	g.byDir[dir] = ps
+	g.mu.Unlock()
+	return ps, nil
+}
+
+// ignored reports whether the entry at path (relative to the repository root) is ignored. As in
+// Git, an entry is also ignored if any of its parent directories is ignored.
+func (g *gitignoreRules) ignored(ctx context.Context, path string, isDir bool) (bool, error) {
+	parts := strings.Split(path, "/")
+	var ps []gitignore.Pattern
+	for i := range parts {
+		// The .gitignore files of the directories parts[:i] (and their parents) apply to parts[:i+1].
+		dirPatterns, err := g.patterns(ctx, strings.Join(parts[:i], "/"))
+		if err != nil {
+			return false, err
+		}
+		ps = append(ps, dirPatterns...)
+		if gitignore.NewMatcher(ps).Match(parts[:i+1], isDir || i < len(parts)-1) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// IsIgnored reports whether this tree entry is ignored by the .gitignore files committed at this
+// commit (including those of its parent directories, and negated patterns). A tracked file can
+// still be ignored, because ignore rules only affect untracked files. It is false for the root.
+func (r *gitTreeEntryResolver) IsIgnored(ctx context.Context) (bool, error) {
+	if r.IsRoot() {
+		return false, nil
+	}
+	cachedRepo, err := backend.CachedGitRepo(ctx, r.commit.repo.repo)
+	if err != nil {
+		return false, err
+	}
+	return getGitignoreRules(*cachedRepo, api.CommitID(r.commit.oid)).ignored(ctx, r.path, r.IsDirectory())
+}
+
+// filterGitignored returns the entries (whose names are relative to dir) that are not ignored by
+// the rules.
+func filterGitignored(ctx context.Context, rules *gitignoreRules, dir string, entries []os.FileInfo) ([]os.FileInfo, error) {
+	var prefix string
+	if dir != "" {
+		prefix = dir + "/"
+	}
+	var kept []os.FileInfo
+	for _, entry := range entries {
+		ignored, err := rules.ignored(ctx, prefix+entry.Name(), entry.IsDir())
+		if err != nil {
+			return nil, err
+		}
+		if !ignored {
+			kept = append(kept, entry)
+		}
+	}
+	return kept, nil
+}
@@ -0,0 +1,51 @@
+package graphqlbackend
+
+import (
+	"context"
+	"testing"
+
+	"gopkg.in/src-d/go-git.v4/plumbing/format/gitignore"
+)
+
+func TestGitignoreRules_Ignored(t *testing.T) {
+	// Prepopulate the rules for every directory that is matched against, so that no .gitignore
+	// files are read from the repository.
+	rules := &gitignoreRules{byDir: map[string][]gitignore.Pattern{
+		"": {
+			gitignore.ParsePattern("*.log", nil),
+			gitignore.ParsePattern("build/", nil),
+		},
+		"a": {
+			gitignore.ParsePattern("!keep.log", []string{"a"}),
+			gitignore.ParsePattern("tmp", []string{"a"}),
+		},
+		"a/b":   nil,
+		"build": nil,
+		"c":     nil,
+	}}
+
+	tests := []struct {
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{path: "main.go", want: false},
+		{path: "debug.log", want: true},
+		{path: "build", isDir: true, want: true},
+		{path: "build/out.go", want: true},
+		{path: "c/build", want: false}, // "build/" only matches directories
+		{path: "a/keep.log", want: false},
+		{path: "a/other.log", want: true},
+		{path: "a/b/tmp", want: true},
+		{path: "c/tmp", want: false}, // a/.gitignore doesn't apply outside of a
+	}
+	for _, test := range tests {
+		got, err := rules.ignored(context.Background(), test.path, test.isDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != test.want {
+			t.Errorf("%s: got ignored %v, want %v", test.path, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package graphqlbackend
+
+import (
+	"context"
+	"os"
+	"path"
+	"strings"
+
+	"gopkg.in/src-d/go-git.v4/plumbing/format/gitignore"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/gitserver"
+	"github.com/sourcegraph/sourcegraph/pkg/inventory"
+	"github.com/sourcegraph/sourcegraph/pkg/vcs/git"
+)
+
+type languageStatResolver struct {
+	lang *inventory.Lang
+}
+
+func (r *languageStatResolver) Name() string { return r.lang.Name }
+
+func (r *languageStatResolver) TotalBytes() float64 { return float64(r.lang.TotalBytes) }
+
+// LanguageStats returns the number of bytes of each language in the files in this directory and
+// all of its subdirectories, ordered by bytes descending. At most maxRecursiveTreeEntries entries
+// are examined. Files marked as vendored or generated (with the linguist-vendored or
+// linguist-generated attributes) in .gitattributes files are excluded.
+func (r *gitTreeEntryResolver) LanguageStats(ctx context.Context) ([]*languageStatResolver, error) {
+	if !r.IsDirectory() {
+		return nil, nil
+	}
+	cachedRepo, err := backend.CachedGitRepo(ctx, r.commit.repo.repo)
+	if err != nil {
+		return nil, err
+	}
+	commit := api.CommitID(r.commit.oid)
+	var entries []os.FileInfo
+	if err := withGitTimeout(ctx, "ReadDir", func(ctx context.Context) (err error) {
+		entries, err = readDirRecursive(ctx, *cachedRepo, commit, r.path)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	attrs, err := readLinguistAttributes(ctx, *cachedRepo, commit, r.path, entries)
+	if err != nil {
+		return nil, err
+	}
+	return languageStats(ctx, attrs, r.path, entries)
+}
+
+// languageStats returns the language statistics of the entries (whose names are relative to dir),
+// excluding directories, submodules, symlinks, and files that attrs excludes.
+func languageStats(ctx context.Context, attrs *linguistAttributes, dir string, entries []os.FileInfo) ([]*languageStatResolver, error) {
+	var prefix string
+	if dir != "" {
+		prefix = dir + "/"
+	}
+	files := make([]os.FileInfo, 0, len(entries))
+	for _, entry := range entries {
+		if entry.Mode().IsRegular() && !attrs.excluded(prefix+entry.Name()) {
+			files = append(files, entry)
+		}
+	}
+
+	inv, err := inventory.Get(ctx, files)
+	if err != nil {
+		return nil, err
+	}
+	stats := make([]*languageStatResolver, len(inv.Languages))
+	for i, lang := range inv.Languages {
+		stats[i] = &languageStatResolver{lang: lang}
+	}
+	return stats, nil
+}
+
+// linguistAttributes are the linguist-vendored and linguist-generated attributes assigned to paths
+// by the .gitattributes files in a repository at a commit.
+type linguistAttributes struct {
+	byDir map[string][]linguistAttributeRule // directory path (relative to the root) -> its .gitattributes rules
+}
+
+// linguistAttributeRule is a line of a .gitattributes file that sets or unsets an attribute.
+type linguistAttributeRule struct {
+	pattern gitignore.Pattern
+	attr    string // "linguist-vendored" or "linguist-generated"
+	set     bool
+}
+
+// readLinguistAttributes reads the .gitattributes files that apply to the entries (whose names are
+// relative to dir): those in dir and its parent directories, and those among the entries.
+func readLinguistAttributes(ctx context.Context, repo gitserver.Repo, commit api.CommitID, dir string, entries []os.FileInfo) (*linguistAttributes, error) {
+	dirs := []string{""}
+	if dir != "" {
+		parts := strings.Split(dir, "/")
+		for i := range parts {
+			dirs = append(dirs, strings.Join(parts[:i+1], "/"))
+		}
+	}
+	for _, entry := range entries {
+		if entry.Mode().IsRegular() && path.Base(entry.Name()) == ".gitattributes" {
+			if d := path.Dir(path.Join(dir, entry.Name())); d != dir && d != "." {
+				dirs = append(dirs, d)
+			}
+		}
+	}
+
+	attrs := &linguistAttributes{byDir: make(map[string][]linguistAttributeRule, len(dirs))}
+	for _, d := range dirs {
+		var domain []string
+		if d != "" {
+			domain = strings.Split(d, "/")
+		}
+		var data []byte
+		err := withGitTimeout(ctx, "ReadFile", func(ctx context.Context) (err error) {
+			data, err = git.ReadFile(ctx, repo, commit, path.Join(d, ".gitattributes"))
+			return err
+		})
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		attrs.byDir[d] = parseLinguistAttributes(string(data), domain)
+	}
+	return attrs, nil
+}
+
+// parseLinguistAttributes parses the linguist-vendored and linguist-generated attributes from the
+// contents of the .gitattributes file in the directory domain. Other attributes, and lines with
+// quoted or negated patterns (which Git doesn't allow in .gitattributes files), are ignored.
+func parseLinguistAttributes(data string, domain []string) []linguistAttributeRule {
+	var rules []linguistAttributeRule
+	for _, line := range strings.Split(data, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") || strings.HasPrefix(fields[0], `"`) || strings.HasPrefix(fields[0], "!") {
+			continue
+		}
+		var pattern gitignore.Pattern
+		for _, field := range fields[1:] {
+			name, set := field, true
+			switch {
+			case strings.HasPrefix(field, "-"), strings.HasPrefix(field, "!"):
+				name, set = field[1:], false
+			case strings.Contains(field, "="):
+				kv := strings.SplitN(field, "=", 2)
+				name, set = kv[0], kv[1] != "false"
+			}
+			if name != "linguist-vendored" && name != "linguist-generated" {
+				continue
+			}
+			if pattern == nil {
+				pattern = gitignore.ParsePattern(fields[0], domain)
+			}
+			rules = append(rules, linguistAttributeRule{pattern: pattern, attr: name, set: set})
+		}
+	}
+	return rules
+}
+
+// excluded reports whether the file at path (relative to the repository root) is marked as vendored
+// or generated. As in Git, the .gitattributes files of deeper directories take precedence over
+// those of their parents, and later lines take precedence over earlier lines.
+func (a *linguistAttributes) excluded(path string) bool {
+	parts := strings.Split(path, "/")
+	attrs := map[string]bool{}
+	for i := range parts {
+		for _, rule := range a.byDir[strings.Join(parts[:i], "/")] {
+			if rule.pattern.Match(parts, false) == gitignore.Exclude {
+				attrs[rule.attr] = rule.set
+			}
+		}
+	}
+	return attrs["linguist-vendored"] || attrs["linguist-generated"]
+}
@@ -0,0 +1,47 @@
+package graphqlbackend
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/pkg/vcs/util"
+)
+
+func TestLanguageStats(t *testing.T) {
+	// Prepopulate the attributes of every directory, so that no .gitattributes files are read from
+	// the repository.
+	attrs := &linguistAttributes{byDir: map[string][]linguistAttributeRule{
+		"":                parseLinguistAttributes("# comment\n**/third_party/** linguist-vendored\n*.pb.go linguist-generated=true\n*.md text\n", nil),
+		"src":             parseLinguistAttributes("keep.pb.go -linguist-generated\n", []string{"src"}),
+		"src/third_party": parseLinguistAttributes("ours.go linguist-vendored=false\n", []string{"src", "third_party"}),
+	}}
+
+	entries := []os.FileInfo{
+		&util.FileInfo{Name_: "third_party", Mode_: os.ModeDir | 0755},
+		&util.FileInfo{Name_: "third_party/lib.go", Mode_: 0644, Size_: 1000},
+		&util.FileInfo{Name_: "third_party/ours.go", Mode_: 0644, Size_: 20},
+		&util.FileInfo{Name_: "main.go", Mode_: 0644, Size_: 10},
+		&util.FileInfo{Name_: "api.pb.go", Mode_: 0644, Size_: 500},
+		&util.FileInfo{Name_: "keep.pb.go", Mode_: 0644, Size_: 5},
+		&util.FileInfo{Name_: "README.md", Mode_: 0644, Size_: 30},
+		&util.FileInfo{Name_: "link.go", Mode_: os.ModeSymlink, Size_: 10},
+	}
+	stats, err := languageStats(context.Background(), attrs, "src", entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]float64{}
+	var order []string
+	for _, s := range stats {
+		got[s.Name()] = s.TotalBytes()
+		order = append(order, s.Name())
+	}
+	if want := map[string]float64{"Go": 35, "Markdown": 30}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if want := []string{"Go", "Markdown"}; !reflect.DeepEqual(order, want) {
+		t.Errorf("got order %v, want %v", order, want)
+	}
+}
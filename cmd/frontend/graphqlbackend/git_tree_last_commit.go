@@ -0,0 +1,153 @@
+package graphqlbackend
+
+import (
+	"context"
+	"os"
+	"path"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/vcs/git"
+)
+
+// maxLastCommitTraversal is the maximum number of commits examined to find the last commits of the
+// entries in a directory. Entries that were last modified before that are resolved as null.
+const maxLastCommitTraversal = 1000
+
+// LastCommit returns the most recent commit that modified this tree entry, or nil if it wasn't
+// modified in the last maxLastCommitTraversal commits that modified its parent directory.
+//
+// The last commits of the entries from the same listing are resolved together (see
+// lastCommitBatch), so listing a directory with lastCommit requires one traversal of the
+// directory's history instead of one per entry.
+func (r *gitTreeEntryResolver) LastCommit(ctx context.Context) (*gitCommitResolver, error) {
+	commit, err := r.lastCommit(ctx)
+	if err != nil || commit == nil {
+		return nil, err
+	}
+	return toGitCommitResolver(r.commit.repo, commit), nil
+}
+
+// LastCommitMessage returns the subject (the first line of the message) of the commit that last
+// modified this tree entry, or the empty string if it is beyond the traversal limit (see
+// LastCommit). It is resolved in the same batch as LastCommit.
+func (r *gitTreeEntryResolver) LastCommitMessage(ctx context.Context) (string, error) {
+	commit, err := r.lastCommit(ctx)
+	if err != nil || commit == nil {
+		return "", err
+	}
+	return gitCommitSubject(commit.Message), nil
+}
+
+// lastCommit returns the most recent commit that modified this tree entry (see LastCommit). If
+// this entry is not part of a listing's batch, it gets a batch of its own, so that the fields that
+// need its last commit share a single traversal.
+func (r *gitTreeEntryResolver) lastCommit(ctx context.Context) (*git.Commit, error) {
+	if r.IsRoot() {
+		return nil, nil
+	}
+	r.lastCommitsOnce.Do(func() {
+		if r.lastCommits == nil {
+			r.lastCommits = newLastCommitBatch(r.commit)
+			r.lastCommits.add(r.path)
+		}
+	})
+	return r.lastCommits.get(ctx, r.path)
+}
+
+// lastCommitBatch resolves the last commits of a set of tree entries at a commit, using a single
+// git.LastCommitsForEntries call per parent directory.
+type lastCommitBatch struct {
+	commit *gitCommitResolver
+
+	mu   sync.Mutex
+	dirs map[string]*lastCommitDir // parent directory -> its entries
+}
+
+type lastCommitDir struct {
+	names []string // names of the entries (in the parent directory) to resolve
+
+	once    sync.Once
+	commits map[string]*git.Commit
+	err     error
+}
+
+func newLastCommitBatch(commit *gitCommitResolver) *lastCommitBatch {
+	return &lastCommitBatch{commit: commit, dirs: map[string]*lastCommitDir{}}
+}
+
+// add adds the entry at the path (relative to the repository root) to the batch. It must be called
+// before get is called for any entry in the same directory.
+func (b *lastCommitBatch) add(entryPath string) {
+	dir, name := path.Dir(entryPath), path.Base(entryPath)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	d, ok := b.dirs[dir]
+	if !ok {
+		d = &lastCommitDir{}
+		b.dirs[dir] = d
+	}
+	d.names = append(d.names, name)
+}
+
+// get returns the last commit of the entry at the path, which must have been added to the batch.
+func (b *lastCommitBatch) get(ctx context.Context, entryPath string) (*git.Commit, error) {
+	dir, name := path.Dir(entryPath), path.Base(entryPath)
+	b.mu.Lock()
+	d := b.dirs[dir]
+	b.mu.Unlock()
+	if d == nil {
+		return nil, nil
+	}
+
+	d.once.Do(func() {
+		cachedRepo, err := backend.CachedGitRepo(ctx, b.commit.repo.repo)
+		if err != nil {
+			d.err = err
+			return
+		}
+		var commits map[string]*git.Commit
+		d.err = withGitTimeout(ctx, "LastCommitsForEntries", func(ctx context.Context) (err error) {
+			commits, err = git.LastCommitsForEntries(ctx, *cachedRepo, api.CommitID(b.commit.oid), dir, d.names, maxLastCommitTraversal)
+			return err
+		})
+		if d.err == nil {
+			d.commits = commits
+		}
+	})
+	if d.err != nil {
+		return nil, d.err
+	}
+	return d.commits[name], nil
+}
+
+// sortByLastModified returns the entries (whose names are relative to the directory prefix)
+// ordered by the date of the commit that last modified each, most recent first. Entries whose last
+// commit is unknown (because it is beyond the traversal limit) are ordered last. Ties keep their
+// existing (name) order.
+func sortByLastModified(ctx context.Context, lastCommits *lastCommitBatch, prefix string, entries []os.FileInfo) ([]os.FileInfo, error) {
+	dates := make(map[string]time.Time, len(entries))
+	for _, entry := range entries {
+		commit, err := lastCommits.get(ctx, prefix+entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		if commit != nil {
+			date := commit.Author.Date
+			if commit.Committer != nil {
+				date = commit.Committer.Date
+			}
+			dates[entry.Name()] = date
+		}
+	}
+
+	// Copy entries so that the (possibly cached) listing is not reordered.
+	sorted := append([]os.FileInfo(nil), entries...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return dates[sorted[i].Name()].After(dates[sorted[j].Name()])
+	})
+	return sorted, nil
+}
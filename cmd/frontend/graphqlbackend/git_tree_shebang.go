@@ -0,0 +1,73 @@
+package graphqlbackend
+
+import (
+	"bytes"
+	"context"
+	"io"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/highlight"
+	"github.com/sourcegraph/sourcegraph/pkg/vcs/git"
+)
+
+// maxShebangLength is the maximum length of a shebang line (including the "#!"). Longer first lines
+// are not considered shebangs. (Kernels have a much lower limit, but other tools, such as env -S,
+// accept longer lines.)
+const maxShebangLength = 1024
+
+// Shebang returns the interpreter line of this blob (its first line, without the line ending) if the
+// line starts with "#!" (after any byte-order mark), such as "#!/usr/bin/env python3". It is nil for
+// directories, binary blobs, and blobs without a shebang. Only the beginning of the blob is read (not
+// its whole content).
+func (r *gitTreeEntryResolver) Shebang(ctx context.Context) (*string, error) {
+	if r.IsDirectory() {
+		return nil, nil
+	}
+	cachedRepo, err := backend.CachedGitRepo(ctx, r.commit.repo.repo)
+	if err != nil {
+		return nil, err
+	}
+	var line []byte
+	err = withGitTimeout(ctx, "ReadFile", func(ctx context.Context) error {
+		rc, err := git.NewFileReader(ctx, *cachedRepo, api.CommitID(r.commit.oid), r.path)
+		if err != nil {
+			return err
+		}
+		defer rc.Close()
+		line, err = readFirstLine(rc, maxShebangLength)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return shebang(stripBOM(line)), nil
+}
+
+// readFirstLine returns the first line read from rd (including its newline, if any), reading at most
+// max+1 bytes. If the first line is longer than max bytes, it returns nil.
+func readFirstLine(rd io.Reader, max int) ([]byte, error) {
+	buf := make([]byte, max+1)
+	n, err := io.ReadFull(rd, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+	buf = buf[:n]
+	if i := bytes.IndexByte(buf, '\n'); i >= 0 {
+		return buf[:i+1], nil
+	}
+	if n > max {
+		return nil, nil
+	}
+	return buf, nil
+}
+
+// shebang returns the first line (without its line ending) if it is a shebang, or nil otherwise.
+func shebang(line []byte) *string {
+	line = bytes.TrimRight(line, "\r\n")
+	if !bytes.HasPrefix(line, []byte("#!")) || highlight.IsBinary(line) || bytes.IndexByte(line, 0) >= 0 {
+		return nil
+	}
+	s := string(line)
+	return &s
+}
@@ -0,0 +1,82 @@
+package graphqlbackend
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/vcs/git"
+)
+
+func TestGitTreeEntry_Shebang(t *testing.T) {
+	str := func(s string) *string { return &s }
+	tests := map[string]struct {
+		content string
+		want    *string
+	}{
+		"env":          {"#!/usr/bin/env python3\nprint('hi')\n", str("#!/usr/bin/env python3")},
+		"crlf":         {"#!/bin/sh\r\necho hi\r\n", str("#!/bin/sh")},
+		"only line":    {"#!/bin/bash", str("#!/bin/bash")},
+		"no shebang":   {"echo hi\n#!/bin/sh\n", nil},
+		"comment":      {"# not a shebang\n", nil},
+		"empty":        {"", nil},
+		"binary":       {"#!\x00\xff\xfe\n", nil},
+		"too long":     {"#!/bin/sh " + strings.Repeat("x", maxShebangLength) + "\n", nil},
+		"leading line": {"\n#!/bin/sh\n", nil},
+		"utf-8 bom":    {"\xEF\xBB\xBF#!/bin/sh\n", str("#!/bin/sh")},
+	}
+	var read int
+	git.Mocks.NewFileReader = func(commit api.CommitID, name string) (io.ReadCloser, error) {
+		test, ok := tests[name]
+		if !ok {
+			return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+		}
+		return ioutil.NopCloser(&countingReader{r: strings.NewReader(test.content), n: &read}), nil
+	}
+	defer git.ResetMocks()
+
+	commit := &gitCommitResolver{repo: &repositoryResolver{repo: &types.Repo{ID: 2, Name: "example.com/repo"}}, oid: exampleCommitSHA1}
+	for name, test := range tests {
+		read = 0
+		r := &gitTreeEntryResolver{commit: commit, path: name, stat: createFileInfo(name, false)}
+		got, err := r.Shebang(context.Background())
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+		if (got == nil) != (test.want == nil) || (got != nil && *got != *test.want) {
+			t.Errorf("%s: got %v, want %v", name, stringPtrValue(got), stringPtrValue(test.want))
+		}
+		if read > maxShebangLength+1 {
+			t.Errorf("%s: read %d bytes, want at most %d", name, read, maxShebangLength+1)
+		}
+	}
+
+	dir := &gitTreeEntryResolver{commit: commit, path: "dir", stat: createFileInfo("dir", true)}
+	if got, err := dir.Shebang(context.Background()); err != nil || got != nil {
+		t.Errorf("directory: got %v, %v, want nil", got, err)
+	}
+}
+
+// countingReader counts the bytes read from r in n.
+type countingReader struct {
+	r io.Reader
+	n *int
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	*r.n += n
+	return n, err
+}
+
+func stringPtrValue(s *string) interface{} {
+	if s == nil {
+		return nil
+	}
+	return *s
+}
@@ -0,0 +1,87 @@
+package graphqlbackend
+
+import (
+	"context"
+	"errors"
+)
+
+// Snippet returns the line at args.StartLine (1-indexed) and up to args.ContextLines lines before and
+// after it, along with the absolute line numbers of the first and last lines included. The lines are
+// clamped to the bounds of the blob, so a StartLine past the end of the blob returns its last line.
+// The content is read with content (so it is shared with Content, Binary, and TotalLines). It is an
+// error to call it on a directory or a binary blob.
+func (r *gitTreeEntryResolver) Snippet(ctx context.Context, args *struct {
+	StartLine    int32
+	ContextLines int32
+}) (*snippetResolver, error) {
+	if r.IsDirectory() {
+		return nil, errors.New("snippet is not defined for a directory")
+	}
+	if args.ContextLines < 0 {
+		return nil, errors.New("contextLines must not be negative")
+	}
+	content, binary, err := r.content(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if binary {
+		return nil, errors.New("snippet is not defined for a binary blob")
+	}
+	return snippet(stripBOM(content), args.StartLine, args.ContextLines), nil
+}
+
+// snippet returns the lines of content from startLine-contextLines to startLine+contextLines
+// (1-indexed and inclusive), clamped to the lines of content (see countLines). The snippet of empty
+// content is empty, with first and last lines of 0.
+func snippet(content []byte, startLine, contextLines int32) *snippetResolver {
+	total := countLines(content)
+	if total == 0 {
+		return &snippetResolver{}
+	}
+	first, last := startLine-contextLines, startLine+contextLines
+	if first < 1 {
+		first = 1
+	}
+	if first > total {
+		first = total
+	}
+	if last > total {
+		last = total
+	}
+	if last < first {
+		last = first
+	}
+
+	// Find the byte offsets of the start of the first line and the end of the last line (including
+	// its newline, if any).
+	start, end := 0, len(content)
+	line := int32(1)
+	for i, b := range content {
+		if b != '\n' {
+			continue
+		}
+		line++
+		if line == first {
+			start = i + 1
+		}
+		if line == last+1 {
+			end = i + 1
+			break
+		}
+	}
+	return &snippetResolver{
+		content:   string(content[start:end]),
+		firstLine: first,
+		lastLine:  last,
+	}
+}
+
+// snippetResolver resolves a range of lines of a blob.
+type snippetResolver struct {
+	content             string
+	firstLine, lastLine int32
+}
+
+func (r *snippetResolver) Content() string  { return r.content }
+func (r *snippetResolver) FirstLine() int32 { return r.firstLine }
+func (r *snippetResolver) LastLine() int32  { return r.lastLine }
@@ -0,0 +1,71 @@
+package graphqlbackend
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSnippet(t *testing.T) {
+	const content = "1\n2\n3\n4\n5\n"
+	tests := map[string]struct {
+		content                 string
+		startLine, contextLines int32
+		want                    snippetResolver
+	}{
+		"middle":            {content, 3, 1, snippetResolver{content: "2\n3\n4\n", firstLine: 2, lastLine: 4}},
+		"no context":        {content, 3, 0, snippetResolver{content: "3\n", firstLine: 3, lastLine: 3}},
+		"first line":        {content, 1, 2, snippetResolver{content: "1\n2\n3\n", firstLine: 1, lastLine: 3}},
+		"last line":         {content, 5, 2, snippetResolver{content: "3\n4\n5\n", firstLine: 3, lastLine: 5}},
+		"whole blob":        {content, 3, 10, snippetResolver{content: content, firstLine: 1, lastLine: 5}},
+		"past end":          {content, 10, 1, snippetResolver{content: "5\n", firstLine: 5, lastLine: 5}},
+		"before start":      {content, -5, 1, snippetResolver{content: "1\n", firstLine: 1, lastLine: 1}},
+		"no final newline":  {"1\n2\n3", 3, 1, snippetResolver{content: "2\n3", firstLine: 2, lastLine: 3}},
+		"single line":       {"x", 1, 1, snippetResolver{content: "x", firstLine: 1, lastLine: 1}},
+		"empty lines":       {"\n\n\n", 2, 0, snippetResolver{content: "\n", firstLine: 2, lastLine: 2}},
+		"empty":             {"", 1, 1, snippetResolver{}},
+		"crlf line endings": {"a\r\nb\r\nc\r\n", 2, 0, snippetResolver{content: "b\r\n", firstLine: 2, lastLine: 2}},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := snippet([]byte(test.content), test.startLine, test.contextLines)
+			if *got != test.want {
+				t.Errorf("got %+v, want %+v", *got, test.want)
+			}
+		})
+	}
+}
+
+func TestGitTreeEntry_Snippet(t *testing.T) {
+	newEntry := func(content string, binary bool) *gitTreeEntryResolver {
+		r := &gitTreeEntryResolver{path: "f", stat: createFileInfo("f", false)}
+		// Populate the memoized content, so that Snippet doesn't read it from the repository.
+		r.contentOnce.Do(func() {
+			r.contentBytes = []byte(content)
+			r.contentBinary = binary
+		})
+		return r
+	}
+	type args = struct {
+		StartLine    int32
+		ContextLines int32
+	}
+
+	got, err := newEntry("a\nb\nc\n", false).Snippet(context.Background(), &args{StartLine: 2, ContextLines: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (snippetResolver{content: "a\nb\nc\n", firstLine: 1, lastLine: 3}); *got != want {
+		t.Errorf("got %+v, want %+v", *got, want)
+	}
+
+	if _, err := newEntry("\x00\x01", true).Snippet(context.Background(), &args{StartLine: 1}); err == nil {
+		t.Error("binary: got nil error, want error")
+	}
+	if _, err := newEntry("a\n", false).Snippet(context.Background(), &args{StartLine: 1, ContextLines: -1}); err == nil {
+		t.Error("negative contextLines: got nil error, want error")
+	}
+	dir := &gitTreeEntryResolver{path: "d", stat: createFileInfo("d", true)}
+	if _, err := dir.Snippet(context.Background(), &args{StartLine: 1}); err == nil {
+		t.Error("directory: got nil error, want error")
+	}
+}
@@ -0,0 +1,88 @@
+package graphqlbackend
+
+import (
+	"context"
+	"errors"
+
+	"github.com/sourcegraph/sourcegraph/pkg/vcs/git"
+)
+
+// StatsAgainst returns how the size and number of lines of this blob changed between the base
+// revision and this blob's commit. The content of each side is only read if the blob was changed
+// (according to git.DiffTree).
+func (r *gitTreeEntryResolver) StatsAgainst(ctx context.Context, args *struct{ Base string }) (*blobStatsDiffResolver, error) {
+	if r.IsDirectory() {
+		return nil, errors.New("statsAgainst is not defined for a directory")
+	}
+	base, changes, err := r.changesSince(ctx, args.Base)
+	if err != nil {
+		return nil, err
+	}
+	var change *git.TreeChange
+	for _, c := range changes {
+		if c.Path == r.path {
+			change = c
+			break
+		}
+	}
+	if change == nil {
+		return &blobStatsDiffResolver{lineDelta: new(int32)}, nil
+	}
+
+	var oldStats, newStats blobStats
+	if change.Kind != git.TreeChangeAdded {
+		oldEntry := &gitTreeEntryResolver{commit: base, path: r.path, stat: createFileInfo(r.path, false)}
+		if oldStats, err = oldEntry.blobStats(ctx); err != nil {
+			return nil, err
+		}
+	}
+	if change.Kind != git.TreeChangeRemoved {
+		if newStats, err = r.blobStats(ctx); err != nil {
+			return nil, err
+		}
+	}
+	return diffBlobStats(change.Kind, oldStats, newStats), nil
+}
+
+// blobStats are the size and number of lines of a blob. A blob that doesn't exist (on one side of a
+// diff) has zero stats.
+type blobStats struct {
+	bytes  int64
+	lines  int32
+	binary bool
+}
+
+func (r *gitTreeEntryResolver) blobStats(ctx context.Context) (blobStats, error) {
+	content, binary, err := r.content(ctx)
+	if err != nil {
+		return blobStats{}, err
+	}
+	stats := blobStats{bytes: int64(len(content)), binary: binary}
+	if !binary {
+		stats.lines = countLines(content)
+	}
+	return stats, nil
+}
+
+// diffBlobStats returns the changes from oldStats to newStats for a blob that changed in the way
+// given by changeKind (one of the git.TreeChange* constants).
+func diffBlobStats(changeKind string, oldStats, newStats blobStats) *blobStatsDiffResolver {
+	d := &blobStatsDiffResolver{changeKind: &changeKind, byteDelta: int32(newStats.bytes - oldStats.bytes)}
+	if !oldStats.binary && !newStats.binary {
+		lineDelta := newStats.lines - oldStats.lines
+		d.lineDelta = &lineDelta
+	}
+	return d
+}
+
+type blobStatsDiffResolver struct {
+	changeKind *string // nil if the blob is unchanged
+	byteDelta  int32
+	lineDelta  *int32 // nil if the blob is binary on either side
+}
+
+func (r *blobStatsDiffResolver) ChangeKind() *string { return r.changeKind }
+
+func (r *blobStatsDiffResolver) ByteDelta() int32 { return r.byteDelta }
+
+func (r *blobStatsDiffResolver) LineDelta() *int32 { return r.lineDelta }
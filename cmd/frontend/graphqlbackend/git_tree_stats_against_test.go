@@ -0,0 +1,133 @@
+package graphqlbackend
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/graph-gophers/graphql-go/gqltesting"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/vcs/git"
+	"github.com/sourcegraph/sourcegraph/pkg/vcs/util"
+)
+
+func TestDiffBlobStats(t *testing.T) {
+	int32Ptr := func(v int32) *int32 { return &v }
+	tests := map[string]struct {
+		kind               string
+		oldStats, newStats blobStats
+		wantByteDelta      int32
+		wantLineDelta      *int32
+	}{
+		"added": {
+			kind:          git.TreeChangeAdded,
+			newStats:      blobStats{bytes: 10, lines: 2},
+			wantByteDelta: 10,
+			wantLineDelta: int32Ptr(2),
+		},
+		"removed": {
+			kind:          git.TreeChangeRemoved,
+			oldStats:      blobStats{bytes: 10, lines: 2},
+			wantByteDelta: -10,
+			wantLineDelta: int32Ptr(-2),
+		},
+		"modified": {
+			kind:          git.TreeChangeModified,
+			oldStats:      blobStats{bytes: 10, lines: 2},
+			newStats:      blobStats{bytes: 25, lines: 5},
+			wantByteDelta: 15,
+			wantLineDelta: int32Ptr(3),
+		},
+		"became binary": {
+			kind:          git.TreeChangeModified,
+			oldStats:      blobStats{bytes: 10, lines: 2},
+			newStats:      blobStats{bytes: 4, binary: true},
+			wantByteDelta: -6,
+		},
+		"added binary": {
+			kind:          git.TreeChangeAdded,
+			newStats:      blobStats{bytes: 4, binary: true},
+			wantByteDelta: 4,
+		},
+	}
+	for label, test := range tests {
+		d := diffBlobStats(test.kind, test.oldStats, test.newStats)
+		if kind := d.ChangeKind(); kind == nil || *kind != test.kind {
+			t.Errorf("%s: got change kind %v, want %q", label, kind, test.kind)
+		}
+		if d.ByteDelta() != test.wantByteDelta {
+			t.Errorf("%s: got byte delta %d, want %d", label, d.ByteDelta(), test.wantByteDelta)
+		}
+		if !reflect.DeepEqual(d.LineDelta(), test.wantLineDelta) {
+			t.Errorf("%s: got line delta %v, want %v", label, d.LineDelta(), test.wantLineDelta)
+		}
+	}
+}
+
+func TestGitBlob_StatsAgainst_Unchanged(t *testing.T) {
+	resetMocks()
+	db.Mocks.Repos.MockGetByName(t, "github.com/gorilla/mux", 2)
+	backend.Mocks.Repos.ResolveRev = func(ctx context.Context, repo *types.Repo, rev string) (api.CommitID, error) {
+		return exampleCommitSHA1, nil
+	}
+	backend.Mocks.Repos.MockGetCommit_Return_NoCheck(t, &git.Commit{ID: exampleCommitSHA1})
+
+	git.Mocks.Stat = func(commit api.CommitID, path string) (os.FileInfo, error) {
+		return &util.FileInfo{Name_: path, Mode_: 0644}, nil
+	}
+	git.Mocks.ResolveRevision = func(spec string, opt *git.ResolveRevisionOptions) (api.CommitID, error) {
+		return "abcdefabcdefabcdefabcdefabcdefabcdefabcd", nil
+	}
+	git.Mocks.GetCommit = func(id api.CommitID) (*git.Commit, error) {
+		return &git.Commit{ID: id}, nil
+	}
+	git.Mocks.DiffTree = func(base, head api.CommitID, dir string) ([]*git.TreeChange, error) {
+		if dir != "foo/bar.go" {
+			t.Errorf("got DiffTree path %q, want %q", dir, "foo/bar.go")
+		}
+		// Another file whose path has this blob's path as a prefix must not match.
+		return []*git.TreeChange{{Path: "foo/bar.go.orig", Kind: git.TreeChangeAdded}}, nil
+	}
+	defer git.ResetMocks()
+
+	gqltesting.RunTests(t, []*gqltesting.Test{
+		{
+			Schema: GraphQLSchema,
+			Query: `
+				{
+					repository(name: "github.com/gorilla/mux") {
+						commit(rev: "` + exampleCommitSHA1 + `") {
+							blob(path: "foo/bar.go") {
+								statsAgainst(base: "v1.0") {
+									changeKind
+									byteDelta
+									lineDelta
+								}
+							}
+						}
+					}
+				}
+			`,
+			ExpectedResult: `
+				{
+					"repository": {
+						"commit": {
+							"blob": {
+								"statsAgainst": {
+									"changeKind": null,
+									"byteDelta": 0,
+									"lineDelta": 0
+								}
+							}
+						}
+					}
+				}
+			`,
+		},
+	})
+}
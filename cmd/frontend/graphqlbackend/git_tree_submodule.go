@@ -1,6 +1,21 @@
 package graphqlbackend
 
-import "github.com/sourcegraph/sourcegraph/pkg/vcs/git"
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/conf/reposource"
+	"github.com/sourcegraph/sourcegraph/pkg/errcode"
+	"github.com/sourcegraph/sourcegraph/pkg/vcs/git"
+)
 
 type gitSubmoduleResolver struct {
 	submodule git.Submodule
@@ -17,3 +32,155 @@ func (r *gitSubmoduleResolver) Commit() string {
 func (r *gitSubmoduleResolver) Path() string {
 	return r.submodule.Path
 }
+
+// RepoName returns the name of the submodule's repository, as derived from its clone URL (see
+// cloneURLToRepoName). It is an error if the clone URL doesn't match any configured code host.
+func (r *gitSubmoduleResolver) RepoName(ctx context.Context) (string, error) {
+	repoName, err := cloneURLToRepoName(r.submodule.URL)
+	if err != nil {
+		return "", fmt.Errorf("unable to determine the repository of submodule %q from its clone URL %q: %s", r.submodule.Path, r.submodule.URL, err)
+	}
+	return repoName, nil
+}
+
+// ExternalCommitURL returns the URL of the submodule's commit on the code host of its remote
+// repository, or the empty string if the code host is not known (see
+// reposource.CloneURLToCommitURL).
+func (r *gitSubmoduleResolver) ExternalCommitURL(ctx context.Context) (string, error) {
+	return reposource.CloneURLToCommitURL(r.submodule.URL, r.submodule.CommitID)
+}
+
+// Possible values of the SubmoduleStatus GraphQL enum.
+const (
+	submoduleStatusResolved       = "RESOLVED"
+	submoduleStatusCommitNotFound = "COMMIT_NOT_FOUND"
+	submoduleStatusRepoNotFound   = "REPO_NOT_FOUND"
+	submoduleStatusBadURL         = "BAD_URL"
+)
+
+// Status reports whether the submodule's commit can be viewed on Sourcegraph: whether its clone URL
+// maps to a repository name (see cloneURLToRepoName), the repository exists, and the commit exists
+// in the repository.
+func (r *gitSubmoduleResolver) Status(ctx context.Context) (string, error) {
+	repoName, err := cloneURLToRepoName(r.submodule.URL)
+	if err != nil {
+		return submoduleStatusBadURL, nil
+	}
+	repo, err := db.Repos.GetByName(ctx, api.RepoName(repoName))
+	if errcode.IsNotFound(err) {
+		return submoduleStatusRepoNotFound, nil
+	} else if err != nil {
+		return "", err
+	}
+	if _, err := backend.Repos.ResolveRev(ctx, repo, string(r.submodule.CommitID)); git.IsRevisionNotFound(err) {
+		return submoduleStatusCommitNotFound, nil
+	} else if err != nil {
+		return "", err
+	}
+	return submoduleStatusResolved, nil
+}
+
+// webURL returns a URL at which the submodule's commit (or, if that is unknown, its repository) can
+// be viewed on its code host, derived from the clone URL alone, or the empty string if the clone URL
+// is not an HTTP(S) or SCP-style SSH URL. It is for submodules whose repository is not on
+// Sourcegraph.
+func (r *gitSubmoduleResolver) webURL() string {
+	if commitURL, err := reposource.CloneURLToCommitURL(r.submodule.URL, r.submodule.CommitID); err == nil && commitURL != "" {
+		return commitURL
+	}
+	cloneURL := r.submodule.URL
+	if !strings.Contains(cloneURL, "://") {
+		// An SCP-style SSH URL, such as git@example.com:owner/repo.git.
+		if i := strings.Index(cloneURL, ":"); i > 0 {
+			cloneURL = "https://" + cloneURL[:i] + "/" + cloneURL[i+1:]
+		}
+	}
+	u, err := url.Parse(cloneURL)
+	if err != nil || u.Host == "" {
+		return ""
+	}
+	switch u.Scheme {
+	case "http", "https":
+	case "ssh", "git":
+		u.Scheme = "https"
+	default:
+		return ""
+	}
+	u.User = nil
+	u.Host = u.Hostname() // drop the port, which is usually the SSH or Git port
+	u.Path = strings.TrimSuffix(u.Path, ".git")
+	return u.String()
+}
+
+// EnclosingSubmodule returns the nearest submodule (gitlink) among the ancestors of this tree
+// entry's path, or nil if there is none. A path inside a submodule doesn't exist in this repository,
+// so this tells the caller to look for it in the submodule's repository instead.
+//
+// The ancestors are examined from the nearest up. Those inside the submodule don't exist, and the
+// first one that exists is either the submodule or a directory (whose ancestors are all directories).
+func (r *gitTreeEntryResolver) EnclosingSubmodule(ctx context.Context) (*gitSubmoduleResolver, error) {
+	cachedRepo, err := backend.CachedGitRepo(ctx, r.commit.repo.repo)
+	if err != nil {
+		return nil, err
+	}
+	for dir := path.Dir(path.Clean(r.path)); dir != "." && dir != "/"; dir = path.Dir(dir) {
+		var stat os.FileInfo
+		err := withGitTimeout(ctx, "Stat", func(ctx context.Context) (err error) {
+			stat, err = git.Stat(ctx, *cachedRepo, api.CommitID(r.commit.oid), dir)
+			return err
+		})
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		if submodule, ok := stat.Sys().(git.Submodule); ok {
+			return &gitSubmoduleResolver{submodule: submodule}, nil
+		}
+		return nil, nil
+	}
+	return nil, nil
+}
+
+// Submodules returns the submodules (gitlink entries) directly within this directory, or, if
+// args.Recursive, within this directory and all of its subdirectories (examining at most
+// maxRecursiveTreeEntries entries). They are ordered by path.
+func (r *gitTreeEntryResolver) Submodules(ctx context.Context, args *struct{ Recursive bool }) ([]*gitSubmoduleResolver, error) {
+	if !r.IsDirectory() {
+		return nil, nil
+	}
+	cachedRepo, err := backend.CachedGitRepo(ctx, r.commit.repo.repo)
+	if err != nil {
+		return nil, err
+	}
+	var entries []os.FileInfo
+	if err := withGitTimeout(ctx, "ReadDir", func(ctx context.Context) (err error) {
+		if args.Recursive {
+			entries, err = readDirRecursive(ctx, *cachedRepo, api.CommitID(r.commit.oid), r.path)
+		} else {
+			entries, err = git.ReadDir(ctx, *cachedRepo, api.CommitID(r.commit.oid), r.path, false)
+		}
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	var prefix string
+	if r.path != "" {
+		prefix = r.path + "/"
+	}
+	var submodules []*gitSubmoduleResolver
+	for _, entry := range entries {
+		submodule, ok := entry.Sys().(git.Submodule)
+		if !ok {
+			continue
+		}
+		if submodule.Path == "" {
+			// The submodule is not in .gitmodules, so use the path of its gitlink entry.
+			submodule.Path = prefix + entry.Name()
+		}
+		submodules = append(submodules, &gitSubmoduleResolver{submodule: submodule})
+	}
+	sort.Slice(submodules, func(i, j int) bool { return submodules[i].submodule.Path < submodules[j].submodule.Path })
+	return submodules, nil
+}
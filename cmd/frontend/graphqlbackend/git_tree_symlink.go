@@ -0,0 +1,122 @@
+package graphqlbackend
+
+import (
+	"context"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/vcs/git"
+)
+
+// maxSymlinkHops is the maximum number of symlinks followed when resolving a symlink's target
+// (the same limit as Linux's MAXSYMLINKS).
+const maxSymlinkHops = 40
+
+// Possible values of the SymlinkResolution GraphQL enum.
+const (
+	symlinkResolved          = "RESOLVED"
+	symlinkOutsideRepository = "OUTSIDE_REPOSITORY"
+	symlinkNotFound          = "NOT_FOUND"
+	symlinkCycle             = "CYCLE"
+	symlinkTooManyHops       = "TOO_MANY_HOPS"
+)
+
+// SymlinkTarget returns the target of this tree entry if it is a symlink (and nil otherwise).
+func (r *gitTreeEntryResolver) SymlinkTarget(ctx context.Context) (*symlinkTargetResolver, error) {
+	if r.stat.Mode()&os.ModeSymlink == 0 {
+		return nil, nil
+	}
+
+	cachedRepo, err := backend.CachedGitRepo(ctx, r.commit.repo.repo)
+	if err != nil {
+		return nil, err
+	}
+	commit := api.CommitID(r.commit.oid)
+	readlink := func(name string) (target string, err error) {
+		err = withGitTimeout(ctx, "ReadFile", func(ctx context.Context) error {
+			b, err := git.ReadFile(ctx, *cachedRepo, commit, name)
+			target = string(b)
+			return err
+		})
+		return target, err
+	}
+
+	target, err := readlink(r.path)
+	if err != nil {
+		return nil, err
+	}
+	result := &symlinkTargetResolver{target: target}
+
+	// Follow the chain of symlinks until it leaves the repository, reaches a non-symlink entry, or
+	// revisits a path (which means there's a cycle).
+	name, link := r.path, target
+	seen := map[string]bool{name: true}
+	for hops := 1; ; hops++ {
+		next, ok := resolveSymlinkPath(name, link)
+		switch {
+		case !ok:
+			result.resolution = symlinkOutsideRepository
+			return result, nil
+		case seen[next]:
+			result.resolution = symlinkCycle
+			return result, nil
+		case hops > maxSymlinkHops:
+			result.resolution = symlinkTooManyHops
+			return result, nil
+		}
+		seen[next] = true
+
+		var fi os.FileInfo
+		err := withGitTimeout(ctx, "Lstat", func(ctx context.Context) (err error) {
+			fi, err = git.Lstat(ctx, *cachedRepo, commit, next)
+			return err
+		})
+		if os.IsNotExist(err) {
+			result.resolution = symlinkNotFound
+			return result, nil
+		} else if err != nil {
+			return nil, err
+		}
+		if fi.Mode()&os.ModeSymlink == 0 {
+			if next == "." {
+				next = "" // the root tree's path
+			}
+			result.resolution = symlinkResolved
+			result.resolved = &gitTreeEntryResolver{commit: r.commit, path: next, stat: fi}
+			return result, nil
+		}
+
+		if link, err = readlink(next); err != nil {
+			return nil, err
+		}
+		name = next
+	}
+}
+
+// resolveSymlinkPath returns the repository-root-relative path that the symlink at name (which is
+// relative to the root) points to, given its target. It returns false if the target is an absolute
+// path or is outside of the repository.
+func resolveSymlinkPath(name, target string) (string, bool) {
+	if target == "" || path.IsAbs(target) {
+		return "", false
+	}
+	resolved := path.Join(path.Dir(name), target)
+	if resolved == ".." || strings.HasPrefix(resolved, "../") {
+		return "", false
+	}
+	return resolved, true
+}
+
+// symlinkTargetResolver resolves the target of a symlink.
+type symlinkTargetResolver struct {
+	target     string                // the symlink's raw target
+	resolution string                // a SymlinkResolution value
+	resolved   *gitTreeEntryResolver // the resolved entry (only if resolution == symlinkResolved)
+}
+
+func (r *symlinkTargetResolver) Target() string               { return r.target }
+func (r *symlinkTargetResolver) Resolution() string           { return r.resolution }
+func (r *symlinkTargetResolver) Entry() *gitTreeEntryResolver { return r.resolved }
@@ -0,0 +1,27 @@
+package graphqlbackend
+
+import "testing"
+
+func TestResolveSymlinkPath(t *testing.T) {
+	tests := []struct {
+		name, target string
+		want         string
+		wantOK       bool
+	}{
+		{name: "link", target: "file", want: "file", wantOK: true},
+		{name: "a/b/link", target: "c.go", want: "a/b/c.go", wantOK: true},
+		{name: "a/b/link", target: "../c.go", want: "a/c.go", wantOK: true},
+		{name: "a/link", target: "..", want: ".", wantOK: true},
+		{name: "a/link", target: "./b/../c", want: "a/c", wantOK: true},
+		{name: "link", target: "../outside", wantOK: false},
+		{name: "a/link", target: "../../outside", wantOK: false},
+		{name: "link", target: "/etc/passwd", wantOK: false},
+		{name: "link", target: "", wantOK: false},
+	}
+	for _, test := range tests {
+		got, ok := resolveSymlinkPath(test.name, test.target)
+		if ok != test.wantOK || got != test.want {
+			t.Errorf("resolveSymlinkPath(%q, %q): got (%q, %v), want (%q, %v)", test.name, test.target, got, ok, test.want, test.wantOK)
+		}
+	}
+}
@@ -2,8 +2,12 @@ package graphqlbackend
 
 import (
 	"context"
+	"fmt"
 	"os"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/graph-gophers/graphql-go/gqltesting"
 
@@ -81,3 +85,480 @@ func TestGitTree(t *testing.T) {
 		},
 	})
 }
+
+func TestGitTree_LastCommit(t *testing.T) {
+	resetMocks()
+	db.Mocks.Repos.MockGetByName(t, "github.com/gorilla/mux", 2)
+	backend.Mocks.Repos.ResolveRev = func(ctx context.Context, repo *types.Repo, rev string) (api.CommitID, error) {
+		return exampleCommitSHA1, nil
+	}
+	backend.Mocks.Repos.MockGetCommit_Return_NoCheck(t, &git.Commit{ID: exampleCommitSHA1})
+
+	git.Mocks.Stat = func(commit api.CommitID, path string) (os.FileInfo, error) {
+		return &util.FileInfo{Name_: "", Mode_: os.ModeDir}, nil
+	}
+	git.Mocks.ReadDir = func(commit api.CommitID, name string, recurse bool) ([]os.FileInfo, error) {
+		return []os.FileInfo{
+			&util.FileInfo{Name_: "a", Mode_: os.ModeDir},
+			&util.FileInfo{Name_: "b", Mode_: 0},
+			&util.FileInfo{Name_: "c", Mode_: 0},
+		}, nil
+	}
+	var calls int
+	git.Mocks.LastCommitsForEntries = func(commit api.CommitID, dir string, names []string, maxCommits int) (map[string]*git.Commit, error) {
+		calls++
+		if dir != "/foo" || len(names) != 3 {
+			t.Errorf("got LastCommitsForEntries(%q, %q), want all 3 entries of /foo", dir, names)
+		}
+		// "c" was not modified within the traversal limit.
+		return map[string]*git.Commit{
+			"a": {ID: "1111111111111111111111111111111111111111", Message: "Add a\n\nDetails."},
+			"b": {ID: "2222222222222222222222222222222222222222", Message: "Fix b"},
+		}, nil
+	}
+	defer git.ResetMocks()
+
+	gqltesting.RunTests(t, []*gqltesting.Test{
+		{
+			Schema: GraphQLSchema,
+			Query: `
+				{
+					repository(name: "github.com/gorilla/mux") {
+						commit(rev: "` + exampleCommitSHA1 + `") {
+							tree(path: "/foo") {
+								entries {
+									name
+									lastCommit {
+										oid
+									}
+									lastCommitMessage
+								}
+							}
+						}
+					}
+				}
+			`,
+			ExpectedResult: `
+				{
+					"repository": {
+						"commit": {
+							"tree": {
+								"entries": [
+									{"name": "a", "lastCommit": {"oid": "1111111111111111111111111111111111111111"}, "lastCommitMessage": "Add a"},
+									{"name": "b", "lastCommit": {"oid": "2222222222222222222222222222222222222222"}, "lastCommitMessage": "Fix b"},
+									{"name": "c", "lastCommit": null, "lastCommitMessage": ""}
+								]
+							}
+						}
+					}
+				}
+			`,
+		},
+	})
+	if calls != 1 {
+		t.Errorf("got %d LastCommitsForEntries calls, want 1 shared by all entries", calls)
+	}
+}
+
+// resolveLastCommits lists the entries of a directory (with the given number of files, using
+// git.Mocks) and resolves lastCommit for each of them. If batched is false, each entry is constructed
+// on its own (as if it were looked up with git.Stat), so its last commit is computed separately. It
+// returns the number of git.LastCommitsForEntries calls.
+func resolveLastCommits(tb testing.TB, numEntries int, batched bool) (lastCommitsCalls int) {
+	entries := make([]os.FileInfo, numEntries)
+	for i := range entries {
+		entries[i] = &util.FileInfo{Name_: fmt.Sprintf("file%d", i)}
+	}
+	git.Mocks.ReadDir = func(commit api.CommitID, name string, recurse bool) ([]os.FileInfo, error) {
+		return entries, nil
+	}
+	git.Mocks.LastCommitsForEntries = func(commit api.CommitID, dir string, names []string, maxCommits int) (map[string]*git.Commit, error) {
+		lastCommitsCalls++
+		commits := make(map[string]*git.Commit, len(names))
+		for _, name := range names {
+			commits[name] = &git.Commit{ID: exampleCommitSHA1}
+		}
+		return commits, nil
+	}
+	defer git.ResetMocks()
+
+	ctx := context.Background()
+	tree := &gitTreeEntryResolver{
+		commit: &gitCommitResolver{repo: &repositoryResolver{repo: &types.Repo{ID: 2, Name: "github.com/gorilla/mux"}}, oid: exampleCommitSHA1},
+		path:   "foo",
+		stat:   &util.FileInfo{Name_: "foo", Mode_: os.ModeDir},
+	}
+	children, err := tree.Entries(ctx, &gitTreeEntryConnectionArgs{})
+	if err != nil {
+		tb.Fatal(err)
+	}
+	for _, child := range children {
+		if !batched {
+			child = &gitTreeEntryResolver{commit: child.commit, path: child.path, stat: child.stat}
+		}
+		commit, err := child.LastCommit(ctx)
+		if err != nil {
+			tb.Fatal(err)
+		}
+		if commit == nil {
+			tb.Fatalf("%s: got no last commit", child.Path())
+		}
+	}
+	return lastCommitsCalls
+}
+
+func TestGitTree_LastCommitBatched(t *testing.T) {
+	resetMocks()
+	if calls := resolveLastCommits(t, 200, true); calls != 1 {
+		t.Errorf("batched: got %d LastCommitsForEntries calls, want 1", calls)
+	}
+	if calls := resolveLastCommits(t, 200, false); calls != 200 {
+		t.Errorf("per entry: got %d LastCommitsForEntries calls, want 200", calls)
+	}
+}
+
+// BenchmarkGitTree_LastCommit200 resolves lastCommit for every entry of a 200-file directory, with
+// the entries' last commits computed in one batch (as when they are listed) and per entry. Batching
+// makes 1 LastCommitsForEntries (gitserver) call per op instead of 200.
+func BenchmarkGitTree_LastCommit200(b *testing.B) {
+	resetMocks()
+	for _, batched := range []bool{true, false} {
+		name := "batched"
+		if !batched {
+			name = "per-entry"
+		}
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			var calls int
+			for i := 0; i < b.N; i++ {
+				calls += resolveLastCommits(b, 200, batched)
+			}
+			b.Logf("%.1f LastCommitsForEntries calls per op", float64(calls)/float64(b.N))
+		})
+	}
+}
+
+func TestFindReadme(t *testing.T) {
+	tests := map[string]struct {
+		names []string
+		want  string
+	}{
+		"none":              {names: []string{"main.go", "docs"}, want: ""},
+		"readme":            {names: []string{"main.go", "README"}, want: "README"},
+		"case-insensitive":  {names: []string{"Readme.MD"}, want: "Readme.MD"},
+		"prefer readme.md":  {names: []string{"README.txt", "README", "readme.md"}, want: "readme.md"},
+		"prefer readme":     {names: []string{"README.txt", "README"}, want: "README"},
+		"ignore directory":  {names: []string{"README.md/"}, want: ""},
+		"ignore other kind": {names: []string{"README.rst"}, want: ""},
+	}
+	for label, test := range tests {
+		var entries []os.FileInfo
+		for _, name := range test.names {
+			if strings.HasSuffix(name, "/") {
+				entries = append(entries, &util.FileInfo{Name_: strings.TrimSuffix(name, "/"), Mode_: os.ModeDir})
+			} else {
+				entries = append(entries, &util.FileInfo{Name_: name})
+			}
+		}
+		var got string
+		if readme := findReadme(entries); readme != nil {
+			got = readme.Name()
+		}
+		if got != test.want {
+			t.Errorf("%s: got %q, want %q", label, got, test.want)
+		}
+	}
+}
+
+// listDirectoryWithIsSingleChild lists the entries of a directory (with the given number of
+// entries, using git.Mocks) and resolves isSingleChild for each of them. It returns the number of
+// git.ReadDir calls.
+func listDirectoryWithIsSingleChild(tb testing.TB, numEntries int) (readDirCalls int) {
+	entries := make([]os.FileInfo, numEntries)
+	for i := range entries {
+		entries[i] = &util.FileInfo{Name_: fmt.Sprintf("dir%d", i), Mode_: os.ModeDir}
+	}
+	git.Mocks.ReadDir = func(commit api.CommitID, name string, recurse bool) ([]os.FileInfo, error) {
+		readDirCalls++
+		return entries, nil
+	}
+	defer git.ResetMocks()
+
+	ctx := context.Background()
+	tree := &gitTreeEntryResolver{
+		commit: &gitCommitResolver{repo: &repositoryResolver{repo: &types.Repo{ID: 2, Name: "github.com/gorilla/mux"}}, oid: exampleCommitSHA1},
+		path:   "foo",
+		stat:   &util.FileInfo{Name_: "foo", Mode_: os.ModeDir},
+	}
+	children, err := tree.Entries(ctx, &gitTreeEntryConnectionArgs{})
+	if err != nil {
+		tb.Fatal(err)
+	}
+	for _, child := range children {
+		isSingleChild, err := child.IsSingleChild(ctx, &gitTreeEntryConnectionArgs{})
+		if err != nil {
+			tb.Fatal(err)
+		}
+		if want := numEntries == 1; isSingleChild != want {
+			tb.Fatalf("%s: got isSingleChild %v, want %v", child.Path(), isSingleChild, want)
+		}
+	}
+	return readDirCalls
+}
+
+func TestGitTree_EntriesReuseListing(t *testing.T) {
+	resetMocks()
+	for _, numEntries := range []int{1, 500} {
+		if calls := listDirectoryWithIsSingleChild(t, numEntries); calls != 1 {
+			t.Errorf("%d entries: got %d ReadDir calls, want 1", numEntries, calls)
+		}
+	}
+}
+
+// BenchmarkGitTree_Entries500 lists a 500-entry directory and resolves isSingleChild for every
+// entry. Each entry is constructed from the parent's listing, so this makes 1 ReadDir (gitserver)
+// call per op instead of 501.
+func BenchmarkGitTree_Entries500(b *testing.B) {
+	resetMocks()
+	b.ReportAllocs()
+	var calls int
+	for i := 0; i < b.N; i++ {
+		calls += listDirectoryWithIsSingleChild(b, 500)
+	}
+	b.Logf("%.1f ReadDir calls per op", float64(calls)/float64(b.N))
+}
+
+func TestGitTree_OrderByLastModified(t *testing.T) {
+	resetMocks()
+	git.Mocks.ReadDir = func(commit api.CommitID, name string, recurse bool) ([]os.FileInfo, error) {
+		return []os.FileInfo{
+			&util.FileInfo{Name_: "a", Mode_: os.ModeDir},
+			&util.FileInfo{Name_: "b"},
+			&util.FileInfo{Name_: "c"},
+			&util.FileInfo{Name_: "d"},
+		}, nil
+	}
+	day := func(d int) *git.Signature {
+		return &git.Signature{Date: time.Date(2018, 1, d, 0, 0, 0, 0, time.UTC)}
+	}
+	git.Mocks.LastCommitsForEntries = func(commit api.CommitID, dir string, names []string, maxCommits int) (map[string]*git.Commit, error) {
+		// "d" was not modified within the traversal limit.
+		return map[string]*git.Commit{
+			"a": {ID: "1111111111111111111111111111111111111111", Committer: day(1)},
+			"b": {ID: "2222222222222222222222222222222222222222", Committer: day(3)},
+			"c": {ID: "3333333333333333333333333333333333333333", Committer: day(2)},
+		}, nil
+	}
+	defer git.ResetMocks()
+
+	tree := &gitTreeEntryResolver{
+		commit: &gitCommitResolver{repo: &repositoryResolver{repo: &types.Repo{ID: 2, Name: "github.com/gorilla/mux"}}, oid: exampleCommitSHA1},
+		path:   "foo",
+		stat:   &util.FileInfo{Name_: "foo", Mode_: os.ModeDir},
+	}
+	names := func(args *gitTreeEntryConnectionArgs) []string {
+		t.Helper()
+		entries, err := tree.Entries(context.Background(), args)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, e := range entries {
+			names = append(names, e.Name())
+		}
+		return names
+	}
+
+	if got, want := names(&gitTreeEntryConnectionArgs{OrderBy: treeEntryOrderByLastModified}), []string{"b", "c", "a", "d"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	first := int32(2)
+	args := &gitTreeEntryConnectionArgs{OrderBy: treeEntryOrderByLastModified}
+	args.First = &first
+	if got, want := names(args), []string{"b", "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("with first: got %v, want %v", got, want)
+	}
+	if got, want := names(&gitTreeEntryConnectionArgs{OrderBy: treeEntryOrderByName}), []string{"a", "b", "c", "d"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("by name: got %v, want %v", got, want)
+	}
+}
+
+func TestGitTree_Submodules(t *testing.T) {
+	resetMocks()
+	git.Mocks.ReadDir = func(commit api.CommitID, name string, recurse bool) ([]os.FileInfo, error) {
+		if name != "foo" {
+			t.Errorf("got ReadDir of %q, want foo", name)
+		}
+		entries := []os.FileInfo{
+			&util.FileInfo{Name_: "z", Mode_: git.ModeSubmodule, Sys_: git.Submodule{URL: "https://example.com/z", Path: "foo/z", CommitID: "1111111111111111111111111111111111111111"}},
+			&util.FileInfo{Name_: "f"},
+			&util.FileInfo{Name_: "d", Mode_: os.ModeDir},
+		}
+		if recurse {
+			entries = append(entries,
+				&util.FileInfo{Name_: "d/a", Mode_: git.ModeSubmodule, Sys_: git.Submodule{URL: "https://example.com/a", CommitID: "2222222222222222222222222222222222222222"}},
+			)
+		}
+		return entries, nil
+	}
+	defer git.ResetMocks()
+
+	tree := &gitTreeEntryResolver{
+		commit: &gitCommitResolver{repo: &repositoryResolver{repo: &types.Repo{ID: 2, Name: "github.com/gorilla/mux"}}, oid: exampleCommitSHA1},
+		path:   "foo",
+		stat:   &util.FileInfo{Name_: "foo", Mode_: os.ModeDir},
+	}
+	paths := func(recursive bool) []string {
+		t.Helper()
+		submodules, err := tree.Submodules(context.Background(), &struct{ Recursive bool }{Recursive: recursive})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var paths []string
+		for _, s := range submodules {
+			paths = append(paths, s.Path()+"@"+s.Commit())
+		}
+		return paths
+	}
+
+	if got, want := paths(false), []string{"foo/z@1111111111111111111111111111111111111111"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	// The path of a submodule that is not in .gitmodules is its entry's path.
+	if got, want := paths(true), []string{"foo/d/a@2222222222222222222222222222222222222222", "foo/z@1111111111111111111111111111111111111111"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("recursive: got %v, want %v", got, want)
+	}
+}
+
+func TestGitTree_ChildCounts(t *testing.T) {
+	resetMocks()
+	db.Mocks.Repos.MockGetByName(t, "github.com/gorilla/mux", 2)
+	backend.Mocks.Repos.ResolveRev = func(ctx context.Context, repo *types.Repo, rev string) (api.CommitID, error) {
+		return exampleCommitSHA1, nil
+	}
+	backend.Mocks.Repos.MockGetCommit_Return_NoCheck(t, &git.Commit{ID: exampleCommitSHA1})
+
+	git.Mocks.Stat = func(commit api.CommitID, path string) (os.FileInfo, error) {
+		return &util.FileInfo{Name_: "", Mode_: os.ModeDir}, nil
+	}
+	var calls int
+	git.Mocks.ReadDir = func(commit api.CommitID, name string, recurse bool) ([]os.FileInfo, error) {
+		calls++
+		if recurse {
+			t.Error("got recursive ReadDir, want non-recursive")
+		}
+		return []os.FileInfo{
+			&util.FileInfo{Name_: "a", Mode_: os.ModeDir},
+			&util.FileInfo{Name_: "b", Mode_: os.ModeDir},
+			&util.FileInfo{Name_: "c", Mode_: 0},
+			&util.FileInfo{Name_: "d", Mode_: os.ModeSymlink},
+			&util.FileInfo{Name_: "e", Mode_: git.ModeSubmodule, Sys_: git.Submodule{URL: "https://example.com/e"}},
+		}, nil
+	}
+	defer git.ResetMocks()
+
+	gqltesting.RunTests(t, []*gqltesting.Test{
+		{
+			Schema: GraphQLSchema,
+			Query: `
+				{
+					repository(name: "github.com/gorilla/mux") {
+						commit(rev: "` + exampleCommitSHA1 + `") {
+							tree(path: "/foo") {
+								subdirectoryCount
+								fileCountImmediate
+								entries {
+									name
+								}
+							}
+						}
+					}
+				}
+			`,
+			ExpectedResult: `
+				{
+					"repository": {
+						"commit": {
+							"tree": {
+								"subdirectoryCount": 2,
+								"fileCountImmediate": 3,
+								"entries": [
+									{"name": "a"},
+									{"name": "b"},
+									{"name": "c"},
+									{"name": "d"},
+									{"name": "e"}
+								]
+							}
+						}
+					}
+				}
+			`,
+		},
+	})
+	if calls != 1 {
+		t.Errorf("got %d ReadDir calls, want 1 shared by the counts and entries", calls)
+	}
+}
+
+func TestGitTree_IsRoot(t *testing.T) {
+	resetMocks()
+	db.Mocks.Repos.MockGetByName(t, "github.com/gorilla/mux", 2)
+	backend.Mocks.Repos.ResolveRev = func(ctx context.Context, repo *types.Repo, rev string) (api.CommitID, error) {
+		return exampleCommitSHA1, nil
+	}
+	backend.Mocks.Repos.MockGetCommit_Return_NoCheck(t, &git.Commit{ID: exampleCommitSHA1})
+
+	git.Mocks.Stat = func(commit api.CommitID, path string) (os.FileInfo, error) {
+		return &util.FileInfo{Name_: path, Mode_: os.ModeDir}, nil
+	}
+	git.Mocks.ReadDir = func(commit api.CommitID, name string, recurse bool) ([]os.FileInfo, error) {
+		return []os.FileInfo{
+			&util.FileInfo{Name_: "mux", Mode_: os.ModeDir},
+			&util.FileInfo{Name_: "README.md", Mode_: 0644},
+		}, nil
+	}
+	defer git.ResetMocks()
+
+	gqltesting.RunTests(t, []*gqltesting.Test{
+		{
+			Schema: GraphQLSchema,
+			Query: `
+				{
+					repository(name: "github.com/gorilla/mux") {
+						commit(rev: "` + exampleCommitSHA1 + `") {
+							root: tree(path: "") {
+								isRoot
+								entries {
+									path
+									isRoot
+								}
+							}
+							nested: tree(path: "mux") {
+								isRoot
+							}
+						}
+					}
+				}
+			`,
+			ExpectedResult: `
+				{
+					"repository": {
+						"commit": {
+							"root": {
+								"isRoot": true,
+								"entries": [
+									{"path": "mux", "isRoot": false},
+									{"path": "README.md", "isRoot": false}
+								]
+							},
+							"nested": {
+								"isRoot": false
+							}
+						}
+					}
+				}
+			`,
+		},
+	})
+}
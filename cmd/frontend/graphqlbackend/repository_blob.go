@@ -0,0 +1,55 @@
+package graphqlbackend
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"os"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/pkg/vcs/git"
+)
+
+// BlobByOID returns the content of the blob with the OID in this repository, or nil if there is no
+// such blob. Blobs larger than maxRenderedBlobSize are rejected (without reading their content).
+func (r *repositoryResolver) BlobByOID(ctx context.Context, args *struct {
+	OID gitObjectID
+}) (*string, error) {
+	// Asking gitserver may trigger a clone of the repo, so ensure it is
+	// enabled.
+	if !r.repo.Enabled {
+		return nil, nil
+	}
+
+	var oid git.OID
+	if _, err := hex.Decode(oid[:], []byte(args.OID)); err != nil {
+		return nil, err
+	}
+	cachedRepo, err := backend.CachedGitRepo(ctx, r.repo)
+	if err != nil {
+		return nil, err
+	}
+
+	var size int64
+	if err := withGitTimeout(ctx, "BlobSize", func(ctx context.Context) (err error) {
+		size, err = git.BlobSize(ctx, *cachedRepo, oid)
+		return err
+	}); os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if max := maxRenderedBlobSize(); size > max {
+		return nil, fmt.Errorf("blob %s is too large (%d bytes, and the maximum is %d bytes)", args.OID, size, max)
+	}
+
+	var content []byte
+	if err := withGitTimeout(ctx, "ReadBlob", func(ctx context.Context) (err error) {
+		content, err = git.ReadBlob(ctx, *cachedRepo, oid)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	s := string(content)
+	return &s, nil
+}
@@ -0,0 +1,74 @@
+package graphqlbackend
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/graph-gophers/graphql-go/gqltesting"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/conf"
+	"github.com/sourcegraph/sourcegraph/pkg/vcs/git"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+func TestRepository_BlobByOID(t *testing.T) {
+	const (
+		blobOID    = "1111111111111111111111111111111111111111"
+		missingOID = "2222222222222222222222222222222222222222"
+		largeOID   = "3333333333333333333333333333333333333333"
+	)
+	resetMocks()
+	db.Mocks.Repos.MockGetByName(t, "github.com/gorilla/mux", 2)
+	conf.Mock(&schema.SiteConfiguration{MaxRenderedBlobSize: 100})
+	defer conf.Mock(nil)
+
+	var read []string
+	git.Mocks.BlobSize = func(oid git.OID) (int64, error) {
+		switch oid.String() {
+		case blobOID:
+			return 5, nil
+		case largeOID:
+			return 101, nil
+		}
+		return 0, &os.PathError{Op: "cat-file", Path: oid.String(), Err: os.ErrNotExist}
+	}
+	git.Mocks.ReadBlob = func(oid git.OID) ([]byte, error) {
+		read = append(read, oid.String())
+		return []byte("hello"), nil
+	}
+	defer git.ResetMocks()
+
+	gqltesting.RunTests(t, []*gqltesting.Test{
+		{
+			Schema: GraphQLSchema,
+			Query: `
+				{
+					repository(name: "github.com/gorilla/mux") {
+						blob: blobByOID(oid: "` + blobOID + `")
+						missing: blobByOID(oid: "` + missingOID + `")
+					}
+				}
+			`,
+			ExpectedResult: `
+				{
+					"repository": {
+						"blob": "hello",
+						"missing": null
+					}
+				}
+			`,
+		},
+	})
+
+	// Blobs larger than maxRenderedBlobSize are rejected without reading their content.
+	r := &repositoryResolver{repo: &types.Repo{ID: 2, Name: "github.com/gorilla/mux", Enabled: true}}
+	if _, err := r.BlobByOID(context.Background(), &struct{ OID gitObjectID }{OID: largeOID}); err == nil {
+		t.Error("got nil error for a blob larger than maxRenderedBlobSize")
+	}
+	if len(read) != 1 || read[0] != blobOID {
+		t.Errorf("got content read for %q, want only %s", read, blobOID)
+	}
+}
@@ -665,6 +665,10 @@ type Query {
     externalServices(
         # Returns the first n repositories from the list.
         first: Int
+        # Only include external services created after this time (an RFC 3339 timestamp).
+        createdAfter: String
+        # Only include external services updated after this time (an RFC 3339 timestamp).
+        updatedAfter: String
     ): ExternalServiceConnection!
     # List all repositories.
     repositories(
@@ -1137,6 +1141,11 @@ type Repository implements Node & GenericSearchResultInterface {
         # SHAs) but also preserve the user input rev (for user friendliness).
         inputRevspec: String
     ): GitCommit
+    # The content of the blob with the given Git object ID in this repository, or null if the repository has no
+    # such blob. Blobs with the same content have the same object ID (regardless of their path or commit), so
+    # clients can cache content by object ID. It is an error if the blob is larger than the maxRenderedBlobSize
+    # site configuration property.
+    blobByOID(oid: GitObjectID!): String
     # Information and status related to mirroring, if this repository is a mirror of another repository (e.g., on
     # some code host). In this case, the remote source repository is external to Sourcegraph and the mirror is
     # maintained by the Sourcegraph site (not the other way around).
@@ -1719,6 +1728,9 @@ type GitCommit implements Node {
     #
     # See "File" documentation for the difference between this field and the "blob" field.
     file(path: String!): File2
+    # The Git blobs in this commit at the given paths, in the same order. The entry for a path that doesn't exist
+    # or isn't a blob is null. This is more efficient than requesting the "blob" field for each path.
+    files(paths: [String!]!): [GitBlob]!
     # Lists the programming languages present in the tree at this commit.
     languages: [String!]!
     # The log of commits consisting of this commit and its ancestors.
@@ -1779,20 +1791,118 @@ type Submodule {
     commit: String!
     # The path to which the submodule is checked out.
     path: String!
+    # The name of the submodule's repository (e.g., github.com/gorilla/mux), derived from its url. It is an
+    # error if the url doesn't match any configured code host.
+    repoName: String!
+    # The URL of the submodule's commit on the code host of its remote repository (e.g.,
+    # https://github.com/gorilla/mux/commit/abc123). Only GitHub and GitLab code hosts are supported;
+    # for other code hosts, this is the empty string.
+    externalCommitURL: String!
+    # Whether the submodule's commit can be viewed on Sourcegraph. If not, the tree entry's url links to the
+    # submodule on its code host (derived from its clone URL) instead, if possible.
+    status: SubmoduleStatus!
+}
+
+# Whether a submodule's commit can be viewed on Sourcegraph.
+enum SubmoduleStatus {
+    # The submodule's repository and commit exist on Sourcegraph.
+    RESOLVED
+    # The submodule's repository exists on Sourcegraph, but it doesn't have the submodule's commit.
+    COMMIT_NOT_FOUND
+    # The submodule's clone URL maps to a repository name, but the repository doesn't exist on Sourcegraph.
+    REPO_NOT_FOUND
+    # The submodule's clone URL doesn't match any configured code host.
+    BAD_URL
+}
+
+# The target of a symlink.
+type SymlinkTarget {
+    # The raw target of the symlink (i.e., the path it points to, which is relative to the
+    # directory containing the symlink unless it is absolute).
+    target: String!
+    # How the target was resolved. This determines whether entry is set.
+    resolution: SymlinkResolution!
+    # The tree entry that the symlink (after following any chain of symlinks) points to. This is
+    # only set if resolution is RESOLVED.
+    entry: TreeEntry
+}
+
+# The result of resolving a symlink's target to an entry in the same repository and commit.
+enum SymlinkResolution {
+    # The target is an entry in the repository at the same commit (see SymlinkTarget.entry).
+    RESOLVED
+    # The target is an absolute path or is outside of the repository.
+    OUTSIDE_REPOSITORY
+    # The target does not exist at the commit. Targets whose path contains a symlinked directory
+    # are also reported as not found, because only the final path component is followed.
+    NOT_FOUND
+    # The chain of symlinks contains a cycle.
+    CYCLE
+    # The chain of symlinks is too long to follow.
+    TOO_MANY_HOPS
+}
+
+# Orderings of tree entries.
+enum TreeEntryOrderBy {
+    # By name, with directories before files.
+    NAME
+    # By the date of the commit that last modified each entry, most recent first. Entries that were
+    # not modified in the last 1,000 commits that modified their parent directory are ordered last
+    # (by name).
+    LAST_MODIFIED
 }
 
 # A file, directory, or other tree entry.
 interface TreeEntry {
     # The full path (relative to the repository root) of this tree entry.
     path: String!
+    # The path of this tree entry relative to the given base directory (which is relative to the
+    # repository root). It is an error if base is not this tree entry or one of its ancestors.
+    relativePath(base: String!): String!
+    # Whether this tree entry is the repository root (the top-level tree). A directory nested in the
+    # repository is never the root, even if it has the same name as the repository.
+    isRoot: Boolean!
     # The base name (i.e., file name only) of this tree entry.
     name: String!
+    # The number of components of the path of this tree entry (0 for the repository root). The path is
+    # cleaned first, so redundant slashes are not counted.
+    depth: Int!
+    # The components of the cleaned path of this tree entry (e.g., ["a", "b"] for "a//b/"), for building
+    # breadcrumbs and links. It is empty for the repository root.
+    pathComponents: [String!]!
     # Whether this tree entry is a directory.
     isDirectory: Boolean!
+    # A hint for which icon to display for this tree entry, derived from its mode: "folder", "file",
+    # "symlink", "submodule", or "executable".
+    icon: String!
+    # Whether this entry is an executable file (Git mode 0100755).
+    isExecutable: Boolean!
+    # Whether this entry is a symlink (Git mode 0120000).
+    isSymlink: Boolean!
+    # The Git mode of this entry, as a number whose octal representation is the mode in "git ls-tree"
+    # output: 0100644 (a regular file), 0100755 (an executable file), 0120000 (a symlink), 040000 (a
+    # directory), or 0160000 (a submodule).
+    gitModeOctal: Int!
+    # The Git mode of this entry exactly as it appears in "git ls-tree" output (an octal string, such as
+    # "100644", "100755", "120000", "040000", or "160000"), for tools that recreate a working tree.
+    gitMode: String!
+    # The size of this tree entry formatted for display, in decimal units (such as "512 B", "1.2 KB", or
+    # "3.4 MB"). It is empty for a directory.
+    byteSizeHuman: String!
     # The URL to this tree entry (using the input revision specifier, which may not be immutable).
     url: String!
     # The canonical URL to this tree entry (using an immutable revision specifier).
     canonicalURL: String!
+    # An absolute, short-lived URL from which this entry (a file, or a zip archive of a tree) can be
+    # downloaded as the current user, without a session or access token (so that it can be used in a
+    # browser or with curl). It expires after the number of seconds in the signedURLs.ttlSeconds site
+    # configuration property. It is null if the current user is anonymous or signed URLs are disabled
+    # (the signedURLs.secret site configuration property is not set).
+    signedDownloadURL: String
+    # Whether this entry is ignored by the .gitignore files committed at this commit (including those
+    # of its parent directories). Tracked files may be ignored, because ignore rules only affect
+    # untracked files. Local (uncommitted) ignore rules, such as .git/info/exclude, are not applied.
+    isIgnored: Boolean!
     # The URLs to this tree entry on external services.
     externalURLs: [ExternalLink!]!
     # Symbols defined in this file or directory.
@@ -1804,6 +1914,18 @@ interface TreeEntry {
     ): SymbolConnection!
     # Submodule metadata if this tree points to a submodule
     submodule: Submodule
+    # The nearest submodule that contains this entry's path (for a path that is inside a submodule's
+    # contents, which are in the submodule's repository), or null if there is none.
+    enclosingSubmodule: Submodule
+    # The target of this tree entry if it is a symlink, or null otherwise.
+    symlinkTarget: SymlinkTarget
+    # The most recent commit that modified this tree entry, or null if it was not modified in the
+    # last 1,000 commits that modified its parent directory. When this is requested for many entries in
+    # a listing, it is computed efficiently for all entries at once.
+    lastCommit: GitCommit
+    # The subject (first line of the message) of lastCommit, or the empty string if lastCommit is null.
+    # It is computed together with lastCommit.
+    lastCommitMessage: String!
     # Whether this tree entry is a single child
     isSingleChild(
         # Returns the first n files in the tree.
@@ -1817,13 +1939,39 @@ interface TreeEntry {
 type GitTree implements TreeEntry {
     # The full path (relative to the root) of this tree.
     path: String!
+    # The path of this tree relative to the given base directory (which is relative to the
+    # repository root). It is an error if base is not this tree or one of its ancestors.
+    relativePath(base: String!): String!
     # Whether this tree is the root (top-level) tree.
     isRoot: Boolean!
     # The base name (i.e., last path component only) of this tree.
     name: String!
+    # The number of components of the path of this tree (0 for the repository root). The path is
+    # cleaned first, so redundant slashes are not counted.
+    depth: Int!
+    # The components of the cleaned path of this tree (e.g., ["a", "b"] for "a//b/"), for building
+    # breadcrumbs and links. It is empty for the repository root.
+    pathComponents: [String!]!
     # True because this is a directory. (The value differs for other TreeEntry interface implementations, such as
     # File.)
     isDirectory: Boolean!
+    # A hint for which icon to display for this tree entry, derived from its mode: "folder", "file",
+    # "symlink", "submodule", or "executable".
+    icon: String!
+    # Whether this entry is an executable file (Git mode 0100755).
+    isExecutable: Boolean!
+    # Whether this entry is a symlink (Git mode 0120000).
+    isSymlink: Boolean!
+    # The Git mode of this entry, as a number whose octal representation is the mode in "git ls-tree"
+    # output: 0100644 (a regular file), 0100755 (an executable file), 0120000 (a symlink), 040000 (a
+    # directory), or 0160000 (a submodule).
+    gitModeOctal: Int!
+    # The Git mode of this entry exactly as it appears in "git ls-tree" output (an octal string, such as
+    # "100644", "100755", "120000", "040000", or "160000"), for tools that recreate a working tree.
+    gitMode: String!
+    # The empty string, because this is a directory, which has no size. (The value differs for other
+    # TreeEntry interface implementations, such as GitBlob.)
+    byteSizeHuman: String!
     # The Git commit containing this tree.
     commit: GitCommit!
     # The repository containing this tree.
@@ -1832,16 +1980,50 @@ type GitTree implements TreeEntry {
     url: String!
     # The canonical URL to this tree (using an immutable revision specifier).
     canonicalURL: String!
-    # The URLs to this tree on external services.
+    # An absolute, short-lived URL from which this tree (as a zip archive) can be downloaded as the
+    # current user, without a session or access token (so that it can be used in a browser or with
+    # curl). It expires after the number of seconds in the signedURLs.ttlSeconds site configuration
+    # property. It is null if the current user is anonymous or signed URLs are disabled (the
+    # signedURLs.secret site configuration property is not set).
+    signedDownloadURL: String
+    # The URL from which an archive of this tree at this commit can be downloaded. Executable bits are
+    # preserved, and symlinks are stored as symlink entries (in zip archives, as entries with the Unix
+    # symlink mode in their external attributes). Downloads of archives larger than 2 GiB are aborted
+    # once that much has been sent.
+    archiveURL(format: ArchiveFormat = ZIP): String!
+    # Whether this entry is ignored by the .gitignore files committed at this commit (including those
+    # of its parent directories). Tracked files may be ignored, because ignore rules only affect
+    # untracked files. Local (uncommitted) ignore rules, such as .git/info/exclude, are not applied.
+    isIgnored: Boolean!
+    # The URLs to this tree on external services. For the root tree, these are the URLs to the
+    # repository's home page on external services (the same as Repository.externalURLs).
     externalURLs: [ExternalLink!]!
     # Submodule metadata if this tree points to a submodule
     submodule: Submodule
+    # The nearest submodule that contains this entry's path (for a path that is inside a submodule's
+    # contents, which are in the submodule's repository), or null if there is none.
+    enclosingSubmodule: Submodule
+    # The target of this tree entry if it is a symlink, or null otherwise.
+    symlinkTarget: SymlinkTarget
+    # The most recent commit that modified this tree entry, or null if it was not modified in the
+    # last 1,000 commits that modified its parent directory. When this is requested for many entries in
+    # a listing, it is computed efficiently for all entries at once.
+    lastCommit: GitCommit
+    # The subject (first line of the message) of lastCommit, or the empty string if lastCommit is null.
+    # It is computed together with lastCommit.
+    lastCommitMessage: String!
     # A list of directories in this tree.
     directories(
         # Returns the first n files in the tree.
         first: Int
         # Recurse into sub-trees.
         recursive: Boolean = false
+        # Omit entries that are ignored by the .gitignore files committed at this commit. Local
+        # (uncommitted) ignore rules, such as .git/info/exclude, are not applied.
+        respectGitignore: Boolean = false
+        # The order of the entries. Ordering by LAST_MODIFIED is applied before first, so it can be used
+        # to get the n most recently modified entries.
+        orderBy: TreeEntryOrderBy = NAME
     ): [GitTree!]!
     # A list of files in this tree.
     files(
@@ -1849,6 +2031,12 @@ type GitTree implements TreeEntry {
         first: Int
         # Recurse into sub-trees.
         recursive: Boolean = false
+        # Omit entries that are ignored by the .gitignore files committed at this commit. Local
+        # (uncommitted) ignore rules, such as .git/info/exclude, are not applied.
+        respectGitignore: Boolean = false
+        # The order of the entries. Ordering by LAST_MODIFIED is applied before first, so it can be used
+        # to get the n most recently modified entries.
+        orderBy: TreeEntryOrderBy = NAME
     ): [File!]!
     # A list of entries in this tree.
     entries(
@@ -1860,7 +2048,47 @@ type GitTree implements TreeEntry {
         # every directory that is a single child, and any directories or files that are
         # nested in a single child.
         recursiveSingleChild: Boolean = false
+        # Omit entries that are ignored by the .gitignore files committed at this commit. Local
+        # (uncommitted) ignore rules, such as .git/info/exclude, are not applied.
+        respectGitignore: Boolean = false
+        # The order of the entries. Ordering by LAST_MODIFIED is applied before first, so it can be used
+        # to get the n most recently modified entries.
+        orderBy: TreeEntryOrderBy = NAME
     ): [TreeEntry!]!
+    # The number of directories directly within this tree (not in its subtrees). This is cheaper than
+    # counting the directories field's entries, and it shares the listing with the entries fields.
+    subdirectoryCount(
+        # Omit entries that are ignored by the .gitignore files committed at this commit.
+        respectGitignore: Boolean = false
+    ): Int!
+    # The number of files (including symlinks and submodules) directly within this tree (not in its
+    # subtrees). This is cheaper than counting the files field's entries, and it shares the listing with
+    # the entries fields.
+    fileCountImmediate(
+        # Omit entries that are ignored by the .gitignore files committed at this commit.
+        respectGitignore: Boolean = false
+    ): Int!
+    # The README file directly within this tree (README.md, README, or README.txt, in that order of
+    # preference, compared case-insensitively), or null if there is none.
+    readme: GitBlob
+    # The number of bytes of each language in the files in this tree and all of its subtrees, ordered
+    # by bytes descending. Files marked as vendored or generated (with the linguist-vendored or
+    # linguist-generated attributes) in .gitattributes files are excluded.
+    languageStats: [LanguageStatistics!]!
+    # The submodules in this tree, ordered by path.
+    submodules(
+        # Include the submodules in all subtrees (not just those directly within this tree).
+        recursive: Boolean = false
+    ): [Submodule!]!
+    # The files in this tree and all of its subtrees that were added, removed, or modified between the
+    # base revision and this tree's commit, ordered by path. A renamed file is reported as removed
+    # (at its old path) and added (at its new path).
+    changes(
+        # The revision to compare this tree's commit against (e.g., an earlier release tag).
+        base: String!
+        # Returns the first n changes from the list.
+        first: Int
+    ): TreeEntryChangeConnection!
     # Symbols defined in this tree.
     symbols(
         # Returns the first n symbols from the list.
@@ -1933,18 +2161,146 @@ type File {
     repository: Repository!
 }
 
+# A list of changes to the files in a tree between two commits.
+type TreeEntryChangeConnection {
+    # A list of changes.
+    nodes: [TreeEntryChange!]!
+    # The total number of changes in the connection.
+    totalCount: Int!
+    # Pagination information.
+    pageInfo: PageInfo!
+}
+
+# A file that was added, removed, or modified between two commits.
+type TreeEntryChange {
+    # The full path (relative to the repository root) of the file.
+    path: String!
+    # How the file changed.
+    changeKind: TreeEntryChangeKind!
+    # The file at the base commit, or null if it was added.
+    oldEntry: TreeEntry
+    # The file at the head commit, or null if it was removed.
+    newEntry: TreeEntry
+}
+
+# The ways in which a file can change between two commits.
+enum TreeEntryChangeKind {
+    # The file doesn't exist at the base commit.
+    ADDED
+    # The file doesn't exist at the head commit.
+    REMOVED
+    # The file's content, mode, or type changed.
+    MODIFIED
+}
+
+# The changes to the size and number of lines of a blob between two revisions.
+type BlobStatsDiff {
+    # How the blob changed, or null if it is unchanged.
+    changeKind: TreeEntryChangeKind
+    # The change in the size of the blob, in bytes.
+    byteDelta: Int!
+    # The change in the number of lines of the blob, or null if the blob is binary at either revision.
+    lineDelta: Int
+}
+
+# A range of lines of a blob.
+type BlobSnippet {
+    # The content of the lines (including the newline at the end of each, if any).
+    content: String!
+    # The 1-indexed line number of the first line in the snippet, or 0 if the blob is empty.
+    firstLine: Int!
+    # The 1-indexed line number of the last line in the snippet, or 0 if the blob is empty.
+    lastLine: Int!
+}
+
+# The format of an archive of a Git tree.
+enum ArchiveFormat {
+    # A zip archive.
+    ZIP
+    # A tar archive.
+    TAR
+    # A gzipped tar archive (.tar.gz).
+    TGZ
+}
+
+# The number of bytes of a language in a tree.
+type LanguageStatistics {
+    # The name of the language.
+    name: String!
+    # The total number of bytes in files of the language.
+    totalBytes: Float!
+}
+
 # A Git blob in a repository.
 type GitBlob implements TreeEntry & File2 {
     # The full path (relative to the repository root) of this blob.
     path: String!
+    # The path of this blob relative to the given base directory (which is relative to the
+    # repository root). It is an error if base is not this blob or one of its ancestors.
+    relativePath(base: String!): String!
+    # False because this is a blob (file), not the root tree.
+    isRoot: Boolean!
     # The base name (i.e., file name only) of this blob's path.
     name: String!
+    # The number of components of the path of this blob (0 for the repository root). The path is
+    # cleaned first, so redundant slashes are not counted.
+    depth: Int!
+    # The components of the cleaned path of this blob (e.g., ["a", "b"] for "a//b/"), for building
+    # breadcrumbs and links. It is empty for the repository root.
+    pathComponents: [String!]!
     # False because this is a blob (file), not a directory.
     isDirectory: Boolean!
-    # The content of this blob.
+    # A hint for which icon to display for this tree entry, derived from its mode: "folder", "file",
+    # "symlink", "submodule", or "executable".
+    icon: String!
+    # Whether this entry is an executable file (Git mode 0100755).
+    isExecutable: Boolean!
+    # Whether this entry is a symlink (Git mode 0120000).
+    isSymlink: Boolean!
+    # The Git mode of this entry, as a number whose octal representation is the mode in "git ls-tree"
+    # output: 0100644 (a regular file), 0100755 (an executable file), 0120000 (a symlink), 040000 (a
+    # directory), or 0160000 (a submodule).
+    gitModeOctal: Int!
+    # The Git mode of this entry exactly as it appears in "git ls-tree" output (an octal string, such as
+    # "100644", "100755", "120000", "040000", or "160000"), for tools that recreate a working tree.
+    gitMode: String!
+    # The size of this blob formatted for display, in decimal units (such as "512 B", "1.2 KB", or
+    # "3.4 MB").
+    byteSizeHuman: String!
+    # The content of this blob, without its byte-order mark (see hasBOM).
     content: String!
     # Whether or not it is binary.
     binary: Boolean!
+    # Whether this blob begins with a UTF-8 or UTF-16 byte-order mark (BOM). The BOM is omitted from
+    # content, highlight, snippet, and shebang (but not from contentBase64).
+    hasBOM: Boolean!
+    # The content of this blob, base64-encoded (e.g., for downloading a binary blob, whose content can't
+    # be represented in content). It is an error if the blob is larger than the maxRenderedBlobSize site
+    # configuration property.
+    contentBase64: String!
+    # The size of this blob in bytes.
+    byteSize: Int!
+    # The number of lines in this blob. A final line without a trailing newline is counted (so "a\nb"
+    # has 2 lines), and an empty blob has 0 lines. It is 0 for binary blobs.
+    totalLines: Int!
+    # The hex-encoded SHA-256 digest of the content of this blob. Unlike the blob's Git object ID, it can be
+    # computed from the content alone (e.g., to check the integrity of a downloaded copy).
+    contentSHA256: String!
+    # How the size and number of lines of this blob changed between the base revision and this blob's
+    # commit. If the blob didn't exist at the base revision, its size and number of lines there are
+    # considered to be zero.
+    statsAgainst(base: String!): BlobStatsDiff!
+    # The line at startLine (1-indexed) and up to contextLines lines before and after it, clamped to the
+    # lines of this blob (e.g., for rendering a search result). It is an error if this blob is binary.
+    snippet(startLine: Int!, contextLines: Int!): BlobSnippet!
+    # The first line of this blob (without its line ending) if it is a shebang (starts with "#!"), such
+    # as "#!/usr/bin/env python3", or null otherwise (including if this blob is binary). It identifies the
+    # interpreter of a script that has no file extension.
+    shebang: String
+    # How a client should display this blob: "text" or "image" (inline), "binary-download" (as a
+    # download link, because it is binary), or "too-large" (as a message that it is too large to display
+    # inline; see the maxRenderedBlobSize site configuration property).
+    renderMode: String!
     # The blob contents rendered as rich HTML, or an empty string if it is not a supported
     # rich file type.
     #
@@ -1958,6 +2314,16 @@ type GitBlob implements TreeEntry & File2 {
     url: String!
     # The canonical URL to this blob (using an immutable revision specifier).
     canonicalURL: String!
+    # An absolute, short-lived URL from which this blob's raw content can be downloaded as the current
+    # user, without a session or access token (so that it can be used in a browser or with curl). It
+    # expires after the number of seconds in the signedURLs.ttlSeconds site configuration property. It
+    # is null if the current user is anonymous or signed URLs are disabled (the signedURLs.secret site
+    # configuration property is not set).
+    signedDownloadURL: String
+    # Whether this entry is ignored by the .gitignore files committed at this commit (including those
+    # of its parent directories). Tracked files may be ignored, because ignore rules only affect
+    # untracked files. Local (uncommitted) ignore rules, such as .git/info/exclude, are not applied.
+    isIgnored: Boolean!
     # The URLs to this blob on its repository's external services.
     externalURLs: [ExternalLink!]!
     # Blame the blob.
@@ -1966,6 +2332,18 @@ type GitBlob implements TreeEntry & File2 {
     highlight(disableTimeout: Boolean!, isLightTheme: Boolean!): HighlightedFile!
     # Submodule metadata if this tree points to a submodule
     submodule: Submodule
+    # The nearest submodule that contains this entry's path (for a path that is inside a submodule's
+    # contents, which are in the submodule's repository), or null if there is none.
+    enclosingSubmodule: Submodule
+    # The target of this tree entry if it is a symlink, or null otherwise.
+    symlinkTarget: SymlinkTarget
+    # The most recent commit that modified this tree entry, or null if it was not modified in the
+    # last 1,000 commits that modified its parent directory. When this is requested for many entries in
+    # a listing, it is computed efficiently for all entries at once.
+    lastCommit: GitCommit
+    # The subject (first line of the message) of lastCommit, or the empty string if lastCommit is null.
+    # It is computed together with lastCommit.
+    lastCommitMessage: String!
     # Symbols defined in this blob.
     symbols(
         # Returns the first n symbols from the list.
@@ -1990,6 +2368,9 @@ type HighlightedFile {
     aborted: Boolean!
     # The HTML.
     html: String!
+    # Whether the HTML was served from the cache of recently highlighted files. This is for debugging, so it is
+    # only reported to site admins (it is always false for other users).
+    servedFromCache: Boolean!
 }
 
 # A file match.
@@ -15,6 +15,7 @@ import (
 	"github.com/golang/gddo/httputil"
 	"github.com/gorilla/mux"
 	"github.com/sourcegraph/sourcegraph/pkg/vfsutil"
+	log15 "gopkg.in/inconshreveable/log15.v2"
 )
 
 // Examples:
@@ -32,6 +33,9 @@ import (
 // Get a tar archive of a repository:
 //     curl -H 'Accept: application/x-tar' http://localhost:3080/github.com/gorilla/mux/-/raw/ -o repo.tar
 //
+// Get a gzipped tar archive of a repository:
+//     curl -H 'Accept: application/gzip' http://localhost:3080/github.com/gorilla/mux/-/raw/ -o repo.tar.gz
+//
 // Get a zip/tar archive of a _subdirectory_ of a repository:
 //     curl -H 'Accept: application/zip' http://localhost:3080/github.com/gorilla/mux/-/raw/.github -o repo-subdir.zip
 //
@@ -49,8 +53,32 @@ import (
 // - For security reasons, all non-archive files (e.g. code, images, binaries) are served with a Content-Type of text/plain.
 // - Symlinks probably do not work well in the text/plain code path (i.e. when not requesting a zip/tar archive).
 // - This route would ideally be using strict slashes, in order for us to support symlinks via HTTP redirects.
+// - Downloads of archives larger than maxArchiveBytes are aborted (the connection is closed) once
+//   the limit is reached, because the HTTP status has already been sent by then.
 //
 
+// maxArchiveBytes is the maximum size of an archive served by serveRaw, so that a single request
+// can't make gitserver stream an arbitrarily large archive.
+const maxArchiveBytes = 2 << 30 // 2 GiB
+
+var errArchiveTooLarge = fmt.Errorf("archive is too large (the limit is %d bytes)", maxArchiveBytes)
+
+// copyArchive copies the archive from r to w. If the archive is larger than max bytes, it stops
+// after copying max bytes and returns errArchiveTooLarge. The size is only known once the archive
+// has been read, so the archive isn't listed (or buffered) in advance.
+func copyArchive(w io.Writer, r io.Reader, max int64) error {
+	if _, err := io.Copy(w, io.LimitReader(r, max)); err != nil {
+		return err
+	}
+	// Check whether the archive continues past the limit.
+	if _, err := io.ReadFull(r, make([]byte, 1)); err == nil {
+		return errArchiveTooLarge
+	} else if err != io.EOF {
+		return err
+	}
+	return nil
+}
+
 func serveRaw(w http.ResponseWriter, r *http.Request) error {
 	var (
 		common *Common
@@ -88,10 +116,11 @@ func serveRaw(w http.ResponseWriter, r *http.Request) error {
 		textPlain       = "text/plain"
 		applicationZip  = "application/zip"
 		applicationXTar = "application/x-tar"
+		applicationGzip = "application/gzip"
 	)
 
 	// Negotiate the content type.
-	contentTypeOffers := []string{textPlain, applicationZip, applicationXTar}
+	contentTypeOffers := []string{textPlain, applicationZip, applicationXTar, applicationGzip}
 	defaultOffer := textPlain
 	contentType := httputil.NegotiateContentType(r, contentTypeOffers, defaultOffer)
 
@@ -103,23 +132,24 @@ func serveRaw(w http.ResponseWriter, r *http.Request) error {
 		contentType = applicationZip
 	case "tar":
 		contentType = applicationXTar
+	case "tgz":
+		contentType = applicationGzip
 	}
 
 	switch contentType {
-	case applicationZip, applicationXTar:
+	case applicationZip, applicationXTar, applicationGzip:
 		// Set the proper filename field, so that downloading "/github.com/gorilla/mux/-/raw"
 		// gives us a "mux.zip" file (e.g. when downloading via a browser).
-		ext := ".zip"
-		if contentType == applicationXTar {
-			ext = ".tar"
+		ext, format := ".zip", vfsutil.ArchiveFormatZip
+		switch contentType {
+		case applicationXTar:
+			ext, format = ".tar", vfsutil.ArchiveFormatTar
+		case applicationGzip:
+			ext, format = ".tar.gz", vfsutil.ArchiveFormatTgz
 		}
 		downloadName := path.Base(string(common.Repo.Name)) + ext
 		w.Header().Set("Content-Disposition", mime.FormatMediaType("Attachment", map[string]string{"filename": downloadName}))
 
-		format := vfsutil.ArchiveFormatZip
-		if contentType == applicationXTar {
-			format = vfsutil.ArchiveFormatTar
-		}
 		relativePath := strings.TrimPrefix(requestedPath, "/")
 		if relativePath == "" {
 			relativePath = "."
@@ -134,8 +164,15 @@ func serveRaw(w http.ResponseWriter, r *http.Request) error {
 			return err
 		}
 		defer f.Close()
-		_, err = io.Copy(w, f)
-		return err
+		if err := copyArchive(w, f, maxArchiveBytes); err == errArchiveTooLarge {
+			// Part of the archive has been sent, so abort the response instead of ending it, so
+			// that the client sees a failed download instead of a truncated archive.
+			log15.Warn("Aborted the download of an archive that is too large.", "repo", common.Repo.Name, "commit", common.CommitID, "path", relativePath, "limit", int64(maxArchiveBytes))
+			panic(http.ErrAbortHandler)
+		} else if err != nil {
+			return err
+		}
+		return nil
 
 	default:
 		// This case also applies for defaultOffer. Note that this is preferred
@@ -0,0 +1,28 @@
+package ui
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestCopyArchive(t *testing.T) {
+	tests := map[string]struct {
+		archive string
+		want    string
+		wantErr error
+	}{
+		"under the limit": {archive: "abc", want: "abc"},
+		"at the limit":    {archive: "abcd", want: "abcd"},
+		"over the limit":  {archive: "abcde", want: "abcd", wantErr: errArchiveTooLarge},
+	}
+	for name, test := range tests {
+		var buf bytes.Buffer
+		if err := copyArchive(&buf, strings.NewReader(test.archive), 4); err != test.wantErr {
+			t.Errorf("%s: got error %v, want %v", name, err, test.wantErr)
+		}
+		if got := buf.String(); got != test.want {
+			t.Errorf("%s: copied %q, want %q", name, got, test.want)
+		}
+	}
+}
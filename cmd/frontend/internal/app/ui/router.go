@@ -354,6 +354,9 @@ func handler(f func(w http.ResponseWriter, r *http.Request) error) http.Handler
 	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		defer func() {
 			if rec := recover(); rec != nil {
+				if rec == http.ErrAbortHandler {
+					panic(rec) // let net/http abort the response
+				}
 				serveError(w, r, recoverError{recover: rec, stack: debug.Stack()}, http.StatusInternalServerError)
 			}
 		}()
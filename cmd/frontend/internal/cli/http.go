@@ -19,6 +19,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/httpapi"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/httpapi/router"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/handlerutil"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/signedurl"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/session"
 	"github.com/sourcegraph/sourcegraph/pkg/actor"
 	"github.com/sourcegraph/sourcegraph/pkg/conf"
@@ -48,6 +49,7 @@ func newExternalHTTPHandler(ctx context.Context) (http.Handler, error) {
 	appHandler = authMiddlewares.App(appHandler)                                               // 🚨 SECURITY: auth middleware
 	appHandler = session.CookieMiddleware(appHandler)                                          // app accepts cookies
 	appHandler = httpapi.AccessTokenAuthMiddleware(appHandler)                                 // app accepts access tokens
+	appHandler = signedurl.Middleware(appHandler)                                              // app accepts signed URLs (for downloads)
 
 	// Mount handlers and assets.
 	sm := http.NewServeMux()
@@ -143,6 +143,11 @@ func Main() error {
 	}
 
 	goroutine.Go(mailreply.StartWorker)
+	goroutine.Go(func() {
+		if err := db.ExternalServices.RedactConfigHistory(context.Background()); err != nil {
+			log15.Error("Redacting secrets from external service config history failed.", "error", err)
+		}
+	})
 	go updatecheck.Start()
 	if hooks.AfterDBInit != nil {
 		hooks.AfterDBInit()
@@ -100,15 +100,16 @@ func servePhabricatorRepoCreate(w http.ResponseWriter, r *http.Request) error {
 	return nil
 }
 
-// serveExternalServiceConfigs serves a JSON response that is an array of all
-// external service configs that match the requested kind.
+// serveExternalServiceConfigs serves a JSON response that is an array of all site-wide
+// external service configs that match the requested kind. Users' external services are
+// excluded, so that they are never synced for the whole site.
 func serveExternalServiceConfigs(w http.ResponseWriter, r *http.Request) error {
 	var req api.ExternalServiceConfigsRequest
 	err := json.NewDecoder(r.Body).Decode(&req)
 	if err != nil {
 		return err
 	}
-	services, err := db.ExternalServices.List(r.Context(), db.ExternalServicesListOptions{Kind: req.Kind})
+	services, err := db.ExternalServices.List(r.Context(), db.ExternalServicesListOptions{Kind: req.Kind, SiteWideOnly: true})
 	if err != nil {
 		return err
 	}
@@ -119,10 +120,21 @@ func serveExternalServiceConfigs(w http.ResponseWriter, r *http.Request) error {
 	// a slice of connection configurations for this external service kind.
 	configs := make([]map[string]interface{}, 0, len(services))
 	for _, service := range services {
+		expanded, err := db.ExpandConfigTemplate(service.Config)
+		if err != nil {
+			log15.Error(
+				"ignoring external service config with unexpandable variable references",
+				"id", service.ID,
+				"displayName", service.DisplayName,
+				"err", err,
+			)
+			continue
+		}
+
 		var config map[string]interface{}
 		// Raw configs may have comments in them so we have to use a json parser
 		// that supports comments in json.
-		if err := jsonc.Unmarshal(service.Config, &config); err != nil {
+		if err := jsonc.Unmarshal(expanded, &config); err != nil {
 			log15.Error(
 				"ignoring external service config that has invalid json",
 				"id", service.ID,
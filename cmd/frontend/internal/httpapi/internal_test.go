@@ -0,0 +1,41 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+)
+
+func TestServeExternalServiceConfigs(t *testing.T) {
+	db.Mocks.ExternalServices.List = func(ctx context.Context, opt db.ExternalServicesListOptions) ([]*types.ExternalService, error) {
+		if opt.Kind != "GITHUB" {
+			t.Errorf("got kind %q, want GITHUB", opt.Kind)
+		}
+		services := []*types.ExternalService{{ID: 1, Kind: "GITHUB", Config: `{"url": "https://github.com"}`}}
+		// A user's external service, which must never be synced for the whole site.
+		if !opt.SiteWideOnly {
+			services = append(services, &types.ExternalService{ID: 2, Kind: "GITHUB", Config: `{"url": "https://github.example.com"}`})
+		}
+		return services, nil
+	}
+	defer func() { db.Mocks.ExternalServices.List = nil }()
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "/external-services/configs", strings.NewReader(`{"kind": "GITHUB"}`))
+	if err := serveExternalServiceConfigs(rec, req); err != nil {
+		t.Fatal(err)
+	}
+	var configs []map[string]interface{}
+	if err := json.NewDecoder(rec.Body).Decode(&configs); err != nil {
+		t.Fatal(err)
+	}
+	if want := []map[string]interface{}{{"url": "https://github.com"}}; !reflect.DeepEqual(configs, want) {
+		t.Errorf("got configs %v, want %v", configs, want)
+	}
+}
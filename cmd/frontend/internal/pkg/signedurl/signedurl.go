@@ -0,0 +1,144 @@
+// Package signedurl creates and verifies short-lived signed URLs. A signed URL authenticates a
+// request for its path as the user who created it, without a session or access token, so that
+// it can be handed to a browser or curl (e.g., as a download link).
+package signedurl
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	log15 "gopkg.in/inconshreveable/log15.v2"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/pkg/actor"
+	"github.com/sourcegraph/sourcegraph/pkg/conf"
+	"github.com/sourcegraph/sourcegraph/pkg/errcode"
+)
+
+// The query parameters of a signed URL.
+const (
+	paramUserID    = "uid"
+	paramExpires   = "expires"
+	paramSignature = "signature"
+)
+
+var (
+	// ErrDisabled is returned by Sign when no signing secret is configured.
+	ErrDisabled = errors.New("signed URLs are disabled (the signedURLs.secret site configuration property is not set)")
+
+	// ErrInvalid is returned by Verify when a URL's signature is missing or doesn't match.
+	ErrInvalid = errors.New("invalid signed URL")
+
+	// ErrExpired is returned by Verify when a URL's signature is valid but has expired.
+	ErrExpired = errors.New("signed URL has expired")
+)
+
+func secret() []byte { return []byte(conf.Get().SignedURLsSecret) }
+
+func ttl() time.Duration {
+	if seconds := conf.Get().SignedURLsTtlSeconds; seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	return 5 * time.Minute
+}
+
+// Sign returns the query parameters that, when added to a URL with the given path, authenticate
+// requests for the URL as the user until the configured TTL elapses.
+//
+// 🚨 SECURITY: The caller must ensure that userID is the ID of the current actor.
+func Sign(path string, userID int32) (url.Values, error) {
+	secret := secret()
+	if len(secret) == 0 {
+		return nil, ErrDisabled
+	}
+	expires := time.Now().Add(ttl()).Unix()
+	return url.Values{
+		paramUserID:    []string{strconv.FormatInt(int64(userID), 10)},
+		paramExpires:   []string{strconv.FormatInt(expires, 10)},
+		paramSignature: []string{signature(secret, path, userID, expires)},
+	}, nil
+}
+
+// IsSigned reports whether the query has a signature (which may or may not be valid).
+func IsSigned(query url.Values) bool {
+	return query.Get(paramSignature) != ""
+}
+
+// Verify checks the signature in the query of a request for the path. It returns the ID of the
+// user who signed the URL if the signature is valid, ErrExpired if it is valid but has expired, and
+// ErrInvalid otherwise.
+func Verify(path string, query url.Values, now time.Time) (userID int32, err error) {
+	secret := secret()
+	if len(secret) == 0 {
+		return 0, ErrInvalid
+	}
+	uid, err := strconv.ParseInt(query.Get(paramUserID), 10, 32)
+	if err != nil {
+		return 0, ErrInvalid
+	}
+	expires, err := strconv.ParseInt(query.Get(paramExpires), 10, 64)
+	if err != nil {
+		return 0, ErrInvalid
+	}
+	// 🚨 SECURITY: Check the signature before the expiry, so that a URL whose expiry was tampered
+	// with is reported as invalid.
+	if !hmac.Equal([]byte(query.Get(paramSignature)), []byte(signature(secret, path, int32(uid), expires))) {
+		return 0, ErrInvalid
+	}
+	if now.Unix() > expires {
+		return 0, ErrExpired
+	}
+	return int32(uid), nil
+}
+
+func signature(secret []byte, path string, userID int32, expires int64) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(path + "\n" + strconv.FormatInt(int64(userID), 10) + "\n" + strconv.FormatInt(expires, 10)))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Middleware authenticates GET and HEAD requests that have a signed URL as the user who signed the
+// URL. It responds with HTTP 401 if the signature has expired (so the client should get a new URL)
+// and HTTP 403 if it is invalid or the user no longer exists. Requests without a signature are
+// passed through unchanged.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !IsSigned(r.URL.Query()) || (r.Method != "GET" && r.Method != "HEAD") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		userID, err := Verify(r.URL.Path, r.URL.Query(), time.Now())
+		switch err {
+		case nil:
+		case ErrExpired:
+			http.Error(w, "Signed URL has expired.", http.StatusUnauthorized)
+			return
+		default:
+			log15.Error("Invalid signed URL.", "path", r.URL.Path, "err", err)
+			http.Error(w, "Invalid signed URL.", http.StatusForbidden)
+			return
+		}
+
+		// 🚨 SECURITY: Check that the user still exists, so that URLs signed by a user who has since
+		// been deleted don't authenticate as them.
+		if _, err := db.Users.GetByID(r.Context(), userID); err != nil {
+			if errcode.IsNotFound(err) {
+				http.Error(w, "Invalid signed URL.", http.StatusForbidden)
+			} else {
+				log15.Error("Error looking up user for signed URL.", "uid", userID, "error", err)
+				http.Error(w, "Error looking up user for signed URL.", http.StatusInternalServerError)
+			}
+			return
+		}
+
+		r = r.WithContext(actor.WithActor(r.Context(), &actor.Actor{UID: userID}))
+		next.ServeHTTP(w, r)
+	})
+}
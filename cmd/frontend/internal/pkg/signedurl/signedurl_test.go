@@ -0,0 +1,141 @@
+package signedurl
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/actor"
+	"github.com/sourcegraph/sourcegraph/pkg/conf"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+const testPath = "/github.com/gorilla/mux@1234567890123456789012345678901234567890/-/raw/mux.go"
+
+func TestSignVerify(t *testing.T) {
+	conf.Mock(&schema.SiteConfiguration{SignedURLsSecret: "s3cret", SignedURLsTtlSeconds: 60})
+	defer conf.Mock(nil)
+
+	query, err := Sign(testPath, 123)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsSigned(query) {
+		t.Fatal("got unsigned query")
+	}
+
+	if userID, err := Verify(testPath, query, time.Now()); err != nil {
+		t.Fatal(err)
+	} else if userID != 123 {
+		t.Errorf("got user ID %d, want 123", userID)
+	}
+	if _, err := Verify(testPath, query, time.Now().Add(2*time.Minute)); err != ErrExpired {
+		t.Errorf("after the TTL: got error %v, want %v", err, ErrExpired)
+	}
+	if _, err := Verify("/github.com/gorilla/mux/-/raw/other.go", query, time.Now()); err != ErrInvalid {
+		t.Errorf("other path: got error %v, want %v", err, ErrInvalid)
+	}
+
+	tampered := func(param, value string) url.Values {
+		q := url.Values{}
+		for k, v := range query {
+			q[k] = v
+		}
+		q.Set(param, value)
+		return q
+	}
+	if _, err := Verify(testPath, tampered(paramUserID, "1"), time.Now()); err != ErrInvalid {
+		t.Errorf("tampered user ID: got error %v, want %v", err, ErrInvalid)
+	}
+	// Extending the expiry of an expired URL makes it invalid, not unexpired.
+	later := strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10)
+	if _, err := Verify(testPath, tampered(paramExpires, later), time.Now().Add(2*time.Minute)); err != ErrInvalid {
+		t.Errorf("tampered expiry: got error %v, want %v", err, ErrInvalid)
+	}
+
+	// Signatures made with another secret are invalid.
+	conf.Mock(&schema.SiteConfiguration{SignedURLsSecret: "other"})
+	if _, err := Verify(testPath, query, time.Now()); err != ErrInvalid {
+		t.Errorf("other secret: got error %v, want %v", err, ErrInvalid)
+	}
+
+	conf.Mock(&schema.SiteConfiguration{})
+	if _, err := Sign(testPath, 123); err != ErrDisabled {
+		t.Errorf("no secret: got error %v, want %v", err, ErrDisabled)
+	}
+}
+
+type userNotFoundError struct{ error }
+
+func (userNotFoundError) NotFound() bool { return true }
+
+func TestMiddleware(t *testing.T) {
+	conf.Mock(&schema.SiteConfiguration{SignedURLsSecret: "s3cret", SignedURLsTtlSeconds: 60})
+	defer conf.Mock(nil)
+	db.Mocks.Users.GetByID = func(_ context.Context, userID int32) (*types.User, error) {
+		if userID != 123 {
+			return nil, userNotFoundError{errors.New("user not found")}
+		}
+		return &types.User{ID: userID}, nil
+	}
+	defer func() { db.Mocks.Users.GetByID = nil }()
+
+	var gotUID int32
+	h := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotUID = actor.FromContext(r.Context()).UID
+	}))
+	serve := func(rawQuery string) int {
+		gotUID = 0
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest("GET", testPath+"?"+rawQuery, nil))
+		return rec.Code
+	}
+
+	query, err := Sign(testPath, 123)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code := serve(query.Encode()); code != http.StatusOK || gotUID != 123 {
+		t.Errorf("valid: got status %d and actor %d, want 200 and 123", code, gotUID)
+	}
+	if code := serve(""); code != http.StatusOK || gotUID != 0 {
+		t.Errorf("unsigned: got status %d and actor %d, want 200 and unauthenticated", code, gotUID)
+	}
+
+	invalid := url.Values{}
+	for k, v := range query {
+		invalid[k] = v
+	}
+	invalid.Set(paramSignature, "x")
+	if code := serve(invalid.Encode()); code != http.StatusForbidden {
+		t.Errorf("invalid: got status %d, want 403", code)
+	}
+
+	// URLs signed by a user who has since been deleted are invalid.
+	deleted, err := Sign(testPath, 456)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code := serve(deleted.Encode()); code != http.StatusForbidden || gotUID != 0 {
+		t.Errorf("deleted user: got status %d and actor %d, want 403 and unauthenticated", code, gotUID)
+	}
+
+	conf.Mock(&schema.SiteConfiguration{SignedURLsSecret: "s3cret", SignedURLsTtlSeconds: 1})
+	expired, err := Sign(testPath, 123)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expires, _ := strconv.ParseInt(expired.Get(paramExpires), 10, 64)
+	expired.Set(paramExpires, strconv.FormatInt(expires-10, 10))
+	expired.Set(paramSignature, signature([]byte("s3cret"), testPath, 123, expires-10))
+	if code := serve(expired.Encode()); code != http.StatusUnauthorized {
+		t.Errorf("expired: got status %d, want 401", code)
+	}
+}
@@ -44,6 +44,42 @@ type ExternalService struct {
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
 	DeletedAt   *time.Time
+
+	// DeletionReason is the reason given when the external service was deleted, if any.
+	DeletionReason *string
+
+	// Disabled is whether syncing from this external service is paused.
+	Disabled bool
+	// Health is the health of the connection to the external service, as determined by the
+	// outcome of the most recent sync (e.g., "healthy" or "failing").
+	Health string
+	// LastSyncAt is when the most recent sync of this external service finished, or nil if it has
+	// never been synced.
+	LastSyncAt *time.Time
+	// LastSyncError is the error from the most recent sync, or nil if it succeeded.
+	LastSyncError *string
+	// ReadOnly is whether this external service is managed by automation, in which case it can't be
+	// updated or deleted (except by the automation, with Upsert).
+	ReadOnly bool
+}
+
+// ExternalServiceAuditEvent is an entry in an external service's audit log.
+type ExternalServiceAuditEvent struct {
+	ID                int64
+	ExternalServiceID int64
+	ActorUserID       *int32 // nil if the change was not made by an authenticated user
+	Action            string
+	Details           string
+	CreatedAt         time.Time
+}
+
+// ExternalServiceConfigVersion is a past (or the current) version of an external service's config.
+type ExternalServiceConfigVersion struct {
+	ID                int64
+	ExternalServiceID int64
+	Config            string
+	ActorUserID       *int32 // nil if the version was not created by an authenticated user
+	CreatedAt         time.Time
 }
 
 type GlobalState struct {
@@ -0,0 +1,4 @@
+ALTER TABLE external_services DROP COLUMN disabled;
+ALTER TABLE external_services DROP COLUMN health;
+ALTER TABLE external_services DROP COLUMN last_sync_at;
+ALTER TABLE external_services DROP COLUMN last_sync_error;
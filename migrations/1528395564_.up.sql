@@ -0,0 +1,4 @@
+ALTER TABLE external_services ADD COLUMN disabled boolean NOT NULL DEFAULT false;
+ALTER TABLE external_services ADD COLUMN health text NOT NULL DEFAULT 'unknown';
+ALTER TABLE external_services ADD COLUMN last_sync_at timestamp with time zone;
+ALTER TABLE external_services ADD COLUMN last_sync_error text;
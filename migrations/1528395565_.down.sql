@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS external_service_audit_log;
+ALTER TABLE external_services DROP COLUMN deletion_reason;
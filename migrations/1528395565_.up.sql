@@ -0,0 +1,11 @@
+ALTER TABLE external_services ADD COLUMN deletion_reason text;
+
+CREATE TABLE external_service_audit_log (
+	id bigserial NOT NULL PRIMARY KEY,
+	external_service_id bigint NOT NULL REFERENCES external_services(id) ON DELETE CASCADE,
+	actor_user_id integer,
+	action text NOT NULL,
+	details text NOT NULL DEFAULT '',
+	created_at timestamp with time zone NOT NULL DEFAULT now()
+);
+CREATE INDEX external_service_audit_log_external_service_id ON external_service_audit_log(external_service_id);
@@ -0,0 +1,12 @@
+CREATE TABLE external_service_configs_history (
+	id bigserial NOT NULL PRIMARY KEY,
+	external_service_id bigint NOT NULL REFERENCES external_services(id) ON DELETE CASCADE,
+	config text NOT NULL,
+	actor_user_id integer,
+	created_at timestamp with time zone NOT NULL DEFAULT now()
+);
+CREATE INDEX external_service_configs_history_external_service_id ON external_service_configs_history(external_service_id);
+
+-- Record the current config of each existing external service as its first version.
+INSERT INTO external_service_configs_history(external_service_id, config, created_at)
+	SELECT id, config, updated_at FROM external_services;
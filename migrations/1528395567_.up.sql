@@ -0,0 +1,3 @@
+-- Normalize the kinds of external services to uppercase, so that they match the exact-match kind
+-- filter. This is idempotent.
+UPDATE external_services SET kind=upper(kind) WHERE kind<>upper(kind);
@@ -0,0 +1,2 @@
+ALTER TABLE external_services ADD COLUMN namespace_user_id integer REFERENCES users(id) ON DELETE CASCADE;
+CREATE INDEX external_services_namespace_user_id_idx ON external_services(namespace_user_id);
@@ -0,0 +1 @@
+ALTER TABLE external_services DROP COLUMN IF EXISTS url_host;
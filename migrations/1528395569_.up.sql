@@ -0,0 +1,2 @@
+ALTER TABLE external_services ADD COLUMN url_host text;
+CREATE INDEX external_services_url_host_idx ON external_services(url_host);
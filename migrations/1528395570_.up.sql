@@ -0,0 +1 @@
+ALTER TABLE external_services ADD COLUMN read_only boolean NOT NULL DEFAULT false;
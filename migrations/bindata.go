@@ -190,6 +190,20 @@
 // 1528395562_.up.sql (420B)
 // 1528395563_.down.sql (133B)
 // 1528395563_.up.sql (181B)
+// 1528395564_.down.sql (217B)
+// 1528395564_.up.sql (306B)
+// 1528395565_.down.sql (108B)
+// 1528395565_.up.sql (488B)
+// 1528395566_.down.sql (55B)
+// 1528395566_.up.sql (634B)
+// 1528395567_.down.sql (0B)
+// 1528395567_.up.sql (200B)
+// 1528395568_.down.sql (71B)
+// 1528395568_.up.sql (201B)
+// 1528395569_.down.sql (62B)
+// 1528395569_.up.sql (132B)
+// 1528395570_.down.sql (63B)
+// 1528395570_.up.sql (83B)
 
 package migrations
 
@@ -4058,6 +4072,286 @@ func _1528395563_UpSql() (*asset, error) {
 	return a, nil
 }
 
+var __1528395564_DownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x48\xad\x28\x49\x2d\xca\x4b\xcc\x89\x2f\x4e\x2d\x2a\xcb\x4c\x4e\x2d\x56\x70\x09\xf2\x0f\x50\x70\xf6\xf7\x09\xf5\xf5\x53\x48\xc9\x2c\x4e\x4c\xca\x49\x4d\xb1\xe6\x22\x5e\x4f\x46\x6a\x62\x4e\x49\x06\x29\x3a\x72\x12\x8b\x4b\xe2\x8b\x2b\xf3\x92\xe3\x13\x4b\xc8\xd3\x97\x5a\x54\x94\x5f\x64\xcd\x05\x08\x00\x00\xff\xff\x3f\x9c\x4c\xa8\xd9\x00\x00\x00")
+
+func _1528395564_DownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395564_DownSql,
+		"1528395564_.down.sql",
+	)
+}
+
+func _1528395564_DownSql() (*asset, error) {
+	bytes, err := _1528395564_DownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395564_.down.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x11, 0xb4, 0xfc, 0x93, 0xb5, 0x5a, 0xee, 0xe7, 0xfd, 0x16, 0x3b, 0x83, 0xfb, 0x4c, 0xfe, 0xd8, 0x4b, 0x86, 0xbe, 0xfe, 0x1c, 0x42, 0x9e, 0x40, 0xd4, 0xc9, 0x62, 0x3d, 0x7e, 0xdf, 0xf5, 0x20}}
+	return a, nil
+}
+
+var __1528395564_UpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x9c\xcd\x41\x8e\x82\x30\x14\x06\xe0\x3d\xa7\xf8\x77\x1c\x82\x55\x67\x60\x56\x1d\x48\x4c\x59\x93\x07\x3c\x43\x63\x69\x4d\xdf\x53\xd0\xd3\x1b\xd7\x6e\xd4\x0b\x7c\x9f\xb1\xae\x39\xc0\x99\x1f\xdb\x80\x77\xe5\x1c\x29\x0c\xc2\xf9\xea\x27\x16\x98\xba\xc6\x6f\x67\xfb\xff\x16\xb3\x17\x1a\x03\xcf\x18\x53\x0a\x4c\x11\x6d\xe7\xd0\xf6\xd6\xa2\x6e\xfe\x4c\x6f\x1d\x8e\x14\x84\xab\xe2\x6d\x71\x61\x0a\xba\x40\x79\xd7\x57\xac\xbc\xc4\x53\x4c\x5b\x2c\x3f\x00\x03\x89\x0e\x72\x8b\xd3\x40\x0a\xf5\x2b\x8b\xd2\x7a\xc6\xe6\x9f\x8b\x5f\x19\xf7\x14\xf9\x2b\x8f\x73\x4e\x19\xca\xbb\x56\xc5\x23\x00\x00\xff\xff\x8e\x7f\xc8\x39\x32\x01\x00\x00")
+
+func _1528395564_UpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395564_UpSql,
+		"1528395564_.up.sql",
+	)
+}
+
+func _1528395564_UpSql() (*asset, error) {
+	bytes, err := _1528395564_UpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395564_.up.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x54, 0x75, 0x25, 0x68, 0xb, 0x58, 0xc8, 0x2c, 0x2c, 0xa5, 0x46, 0xd2, 0x40, 0xb5, 0xa4, 0x8e, 0x32, 0xa5, 0xf3, 0x71, 0xbf, 0x67, 0x7f, 0xa8, 0x4b, 0xa1, 0x83, 0x28, 0x7d, 0x2c, 0x15, 0x91}}
+	return a, nil
+}
+
+var __1528395565_DownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x6c\x00\x93\xff\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x65\x78\x74\x65\x72\x6e\x61\x6c\x5f\x73\x65\x72\x76\x69\x63\x65\x5f\x61\x75\x64\x69\x74\x5f\x6c\x6f\x67\x3b\x0a\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x65\x78\x74\x65\x72\x6e\x61\x6c\x5f\x73\x65\x72\x76\x69\x63\x65\x73\x20\x44\x52\x4f\x50\x20\x43\x4f\x4c\x55\x4d\x4e\x20\x64\x65\x6c\x65\x74\x69\x6f\x6e\x5f\x72\x65\x61\x73\x6f\x6e\x3b\x0a\x01\x00\x00\xff\xff\xbf\xfc\x68\xb5\x6c\x00\x00\x00")
+
+func _1528395565_DownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395565_DownSql,
+		"1528395565_.down.sql",
+	)
+}
+
+func _1528395565_DownSql() (*asset, error) {
+	bytes, err := _1528395565_DownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395565_.down.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x4b, 0x10, 0x60, 0x3f, 0xcd, 0x71, 0xa0, 0xb7, 0x27, 0xeb, 0xd3, 0xb, 0x29, 0x36, 0xa5, 0x61, 0xe7, 0x51, 0x6f, 0x6a, 0xb9, 0x6d, 0x24, 0x2b, 0x21, 0xc2, 0x7c, 0xe7, 0xe, 0xed, 0xb8, 0x69}}
+	return a, nil
+}
+
+var __1528395565_UpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x7c\x90\xdf\x6a\xf3\x30\x0c\xc5\xaf\xe3\xa7\xd0\x5d\x13\xc8\x1b\xf4\xca\x5f\xa2\x42\xf9\xdc\x74\xa4\x29\xac\x57\xc6\xab\x45\x27\x48\xed\x61\xab\x6b\xd9\xd3\x8f\xad\xfb\xc3\xc8\xba\x4b\x1d\xf1\x3b\xd2\x39\xda\x0c\xd8\xc3\xa0\xff\x19\x04\xba\x08\xa5\xe0\x46\x9b\x29\x3d\xf3\x9e\x32\xe8\xb6\x85\x66\x6d\xb6\xab\x0e\x3c\x8d\x24\x1c\x83\x4d\xe4\x72\x0c\x20\x74\x91\xb9\x52\x4d\x8f\x7a\xc0\x1b\x06\xd6\x9d\x3c\x8b\x1d\xe3\x01\x4a\x55\xb0\x87\x07\x3e\x64\x4a\xec\x46\xe8\xd6\x03\x74\x5b\x63\xe0\xae\x5f\xae\x74\xbf\x83\xff\xb8\xab\x55\x31\x71\xb8\x42\x1c\xe4\x9b\xe8\x71\x81\x3d\x76\x0d\x6e\xa6\x1f\x97\xec\x2b\x58\x77\xd0\xa2\xc1\x01\xa1\xd1\x9b\x46\xb7\x58\xab\xc2\xed\x25\x26\x7b\xca\x94\x2c\x7b\xe0\x20\x74\xa0\x74\xd5\xf9\x23\xcd\xd7\x85\x5a\x15\x9e\xc4\xf1\x98\x7f\xea\xd0\xe2\x42\x6f\xcd\x00\xb3\x59\xad\x8a\x7d\x22\x27\xe4\xad\x13\x10\x3e\x52\x16\x77\x7c\x82\x33\xcb\xe3\xfb\x08\x2f\x31\xd0\x94\x0c\xf1\x5c\x56\xaa\x9a\x7f\xf6\xb6\xec\x5a\xbc\xff\xa3\x37\x3b\x59\xb1\x7f\xcb\x77\x9b\x28\x7f\x21\xaa\xb9\x7a\x0d\x00\x00\xff\xff\x42\xcf\xd6\x73\xe8\x01\x00\x00")
+
+func _1528395565_UpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395565_UpSql,
+		"1528395565_.up.sql",
+	)
+}
+
+func _1528395565_UpSql() (*asset, error) {
+	bytes, err := _1528395565_UpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395565_.up.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xf8, 0xaa, 0x3b, 0xb1, 0x60, 0x93, 0x35, 0xc5, 0xbf, 0x27, 0xc8, 0xed, 0xb6, 0x90, 0x36, 0x8a, 0xd1, 0x6c, 0xaf, 0xa1, 0xdb, 0x93, 0x0, 0x23, 0xaf, 0x24, 0x95, 0xca, 0xe1, 0xa0, 0xc3, 0x40}}
+	return a, nil
+}
+
+var __1528395566_DownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x37\x00\xc8\xff\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x65\x78\x74\x65\x72\x6e\x61\x6c\x5f\x73\x65\x72\x76\x69\x63\x65\x5f\x63\x6f\x6e\x66\x69\x67\x73\x5f\x68\x69\x73\x74\x6f\x72\x79\x3b\x0a\x01\x00\x00\xff\xff\x74\xe8\xb0\x06\x37\x00\x00\x00")
+
+func _1528395566_DownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395566_DownSql,
+		"1528395566_.down.sql",
+	)
+}
+
+func _1528395566_DownSql() (*asset, error) {
+	bytes, err := _1528395566_DownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395566_.down.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x2f, 0xc5, 0x4e, 0xa, 0x0, 0xf6, 0xa3, 0x5f, 0x64, 0xe9, 0x13, 0xae, 0x62, 0x68, 0x8a, 0xa5, 0x72, 0xdf, 0x8a, 0xd6, 0x9d, 0xee, 0x54, 0x64, 0xe2, 0xaf, 0xdd, 0x7, 0x93, 0xb8, 0x95, 0x45}}
+	return a, nil
+}
+
+var __1528395566_UpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x9c\x90\xcd\x6e\x9c\x30\x10\xc7\xcf\xf8\x29\xe6\x08\x12\xe9\x0b\xec\x89\xc2\xac\x84\x4a\xa0\x32\x8e\xd4\x9c\x90\x0b\xb3\x30\x52\x62\x47\xf6\x6c\xb2\xed\xd3\x57\xe9\xd2\x52\x89\x4a\x2b\xe5\x68\x79\xfe\x5f\xbf\x52\x63\x61\x10\x4c\xf1\xb9\x41\xa0\x8b\x50\x70\xf6\x69\x88\x14\x5e\x79\xa4\x61\xf4\xee\xc4\x73\x1c\x16\x8e\xe2\xc3\x0f\x48\x55\xc2\x13\x7c\xe7\x39\x52\x60\xfb\x04\x6d\x67\xa0\x7d\x68\x1a\xf8\xaa\xeb\xfb\x42\x3f\xc2\x17\x7c\xcc\x55\xb2\xf3\xb9\x8a\xd8\xc9\xa6\xd0\x78\x44\x8d\x6d\x89\xfd\x2e\x36\xa6\x3c\x65\xd0\xb5\x50\x61\x83\x06\xa1\x2c\xfa\xb2\xa8\x30\x57\xc9\xb5\x0f\x08\x5d\x36\xa7\x5c\x25\x76\x14\x1f\x86\x73\xa4\x30\xf0\x04\xec\x84\x66\x0a\xef\xe7\x81\xac\xd0\x34\x58\x01\xe1\x67\x8a\x62\x9f\x5f\xe0\x8d\x65\xf9\xfd\x84\x9f\xde\xd1\x56\xa8\xc2\x63\xf1\xd0\x18\x70\xfe\x2d\xcd\x54\x76\x50\x2b\x9a\xba\xad\xf0\xdb\x4d\x34\xc3\xee\x80\xa7\xf7\x09\xb7\x74\xe9\x7f\x74\xd9\x41\xa9\xbb\x3b\xd0\x34\xfa\x30\x81\x2c\x04\xe3\x39\x04\x72\x02\xeb\x7e\x7f\x02\xb2\xe3\x02\x74\xe1\x28\xec\xe6\xbf\x29\xb0\x9a\x80\x8d\xc0\x12\xe1\xc4\x21\x0a\xbc\x52\x88\xec\xdd\x27\x55\xb7\x3d\x6a\x03\x75\x6b\xba\x0f\x15\xcb\xd7\x02\x39\x6c\x64\x33\x95\xf4\xd8\x60\x69\xe0\xdf\xff\xf3\xcb\xf4\x87\xfc\x51\x77\xf7\xbb\xb4\x78\x50\xbf\x02\x00\x00\xff\xff\x1b\x7b\xa2\x78\x7a\x02\x00\x00")
+
+func _1528395566_UpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395566_UpSql,
+		"1528395566_.up.sql",
+	)
+}
+
+func _1528395566_UpSql() (*asset, error) {
+	bytes, err := _1528395566_UpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395566_.up.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x56, 0xce, 0x29, 0x72, 0x1, 0x9e, 0x24, 0x60, 0x2f, 0xbe, 0xed, 0xf1, 0x31, 0xcb, 0x3a, 0x3, 0xf0, 0x67, 0x15, 0xa, 0x8, 0xe0, 0x8a, 0x98, 0xa3, 0xa9, 0xe4, 0x82, 0x16, 0xa1, 0x8e, 0x31}}
+	return a, nil
+}
+
+var __1528395567_DownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x01\x00\x00\xff\xff\x00\x00\x00\x00\x00\x00\x00\x00")
+
+func _1528395567_DownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395567_DownSql,
+		"1528395567_.down.sql",
+	)
+}
+
+func _1528395567_DownSql() (*asset, error) {
+	bytes, err := _1528395567_DownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395567_.down.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xe3, 0xb0, 0xc4, 0x42, 0x98, 0xfc, 0x1c, 0x14, 0x9a, 0xfb, 0xf4, 0xc8, 0x99, 0x6f, 0xb9, 0x24, 0x27, 0xae, 0x41, 0xe4, 0x64, 0x9b, 0x93, 0x4c, 0xa4, 0x95, 0x99, 0x1b, 0x78, 0x52, 0xb8, 0x55}}
+	return a, nil
+}
+
+var __1528395567_UpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x4c\x8e\xcd\xaa\xc2\x30\x10\x85\xf7\x7d\x8a\xb3\xbc\x17\x4c\x5f\xc0\x1f\x10\x0c\xb8\x12\xd1\x8a\x4b\x09\xe9\x29\x09\xb6\x4d\x49\x46\xa9\x3e\xbd\xa4\x42\x11\x66\x71\xe6\x83\x99\xef\x28\x85\x43\x88\x9d\x69\xfd\x9b\x10\x47\xdc\x7d\x5f\x27\x84\x06\x1c\x85\xb1\x37\x2d\x12\xe3\xd3\x5b\x26\x48\xc0\x63\x18\x18\xad\x49\x5c\x20\x05\x88\x33\x92\x8f\x5e\xe8\x8c\x58\x97\x23\x38\x1a\x2b\xea\xbb\xe7\x5f\x85\x52\x68\x7c\x2b\x8c\x25\x2a\xe7\x13\xf2\xd4\xec\x86\x20\xec\xa5\x2c\x2e\xc7\xdd\xb6\xd2\xb3\xed\x36\xdb\xce\xba\x9a\xca\xac\x27\xe7\x5f\x8e\xff\xb8\xee\xf5\x49\x4f\x78\xb5\xf9\xe1\xcb\xe2\x13\x00\x00\xff\xff\xe9\x50\x1e\xca\xc8\x00\x00\x00")
+
+func _1528395567_UpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395567_UpSql,
+		"1528395567_.up.sql",
+	)
+}
+
+func _1528395567_UpSql() (*asset, error) {
+	bytes, err := _1528395567_UpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395567_.up.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x88, 0xe1, 0xaf, 0x5b, 0xdf, 0xe7, 0x76, 0xde, 0xab, 0xca, 0x98, 0xe9, 0x39, 0x3a, 0xc5, 0xe2, 0xaf, 0x34, 0x6e, 0xf4, 0x1a, 0xe8, 0x5c, 0x23, 0xed, 0x40, 0xba, 0xa7, 0x5f, 0x11, 0xef, 0x17}}
+	return a, nil
+}
+
+var __1528395568_DownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x47\x00\xb8\xff\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x65\x78\x74\x65\x72\x6e\x61\x6c\x5f\x73\x65\x72\x76\x69\x63\x65\x73\x20\x44\x52\x4f\x50\x20\x43\x4f\x4c\x55\x4d\x4e\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x6e\x61\x6d\x65\x73\x70\x61\x63\x65\x5f\x75\x73\x65\x72\x5f\x69\x64\x3b\x0a\x01\x00\x00\xff\xff\x6d\xdd\xd1\x26\x47\x00\x00\x00")
+
+func _1528395568_DownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395568_DownSql,
+		"1528395568_.down.sql",
+	)
+}
+
+func _1528395568_DownSql() (*asset, error) {
+	bytes, err := _1528395568_DownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395568_.down.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x95, 0xc0, 0x7a, 0x5c, 0x49, 0x2d, 0xd7, 0x32, 0x11, 0xcb, 0x4a, 0x2c, 0x5, 0x4b, 0xc8, 0x69, 0x56, 0x63, 0x57, 0x73, 0x22, 0xd9, 0x81, 0xd9, 0x96, 0xc4, 0x9c, 0x6c, 0xb5, 0xdc, 0x73, 0xaf}}
+	return a, nil
+}
+
+var __1528395568_UpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x64\xcd\x41\xaa\xc3\x20\x18\xc4\xf1\x7d\x4e\x31\xcb\xe4\x0c\x59\xf9\x74\x1e\x14\xac\x01\x63\xa1\x3b\x09\xc9\x47\x11\x5a\x29\x9a\x96\x1c\xbf\x64\xed\x7a\xfe\xcc\x4f\xd9\x40\x8f\xa0\xfe\x2c\x21\xc7\x2e\x25\x2f\xcf\x58\xa5\x7c\xd3\x2a\x15\xca\x18\xe8\xc9\xde\xae\x0e\x79\x79\x49\x7d\x2f\xab\xc4\x4f\x95\x12\xd3\x86\x94\x77\x79\x48\x81\xe7\x3f\x3d\x9d\xe6\x8c\x73\xaa\x7d\xda\x06\x4c\x0e\x86\x96\x81\xd0\x6a\xd6\xca\x70\xec\xb4\xa7\x0a\xc4\xc5\x19\xde\x5b\x2b\x36\x40\x4c\xdb\x71\xfe\x34\x69\xdf\xa4\xc3\xd8\xfd\x02\x00\x00\xff\xff\xbf\x56\x48\xfa\xc9\x00\x00\x00")
+
+func _1528395568_UpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395568_UpSql,
+		"1528395568_.up.sql",
+	)
+}
+
+func _1528395568_UpSql() (*asset, error) {
+	bytes, err := _1528395568_UpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395568_.up.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x70, 0x40, 0x65, 0x3c, 0x8d, 0x8a, 0xb1, 0xcc, 0xb1, 0x53, 0x33, 0x7f, 0xc3, 0x92, 0xb4, 0x95, 0x95, 0xa6, 0x14, 0x3b, 0x29, 0xc5, 0xf7, 0x88, 0x44, 0xcd, 0xdc, 0x86, 0x3b, 0xe8, 0xe8, 0x9c}}
+	return a, nil
+}
+
+var __1528395569_DownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x3e\x00\xc1\xff\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x65\x78\x74\x65\x72\x6e\x61\x6c\x5f\x73\x65\x72\x76\x69\x63\x65\x73\x20\x44\x52\x4f\x50\x20\x43\x4f\x4c\x55\x4d\x4e\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x75\x72\x6c\x5f\x68\x6f\x73\x74\x3b\x0a\x01\x00\x00\xff\xff\x38\x39\x7d\xbd\x3e\x00\x00\x00")
+
+func _1528395569_DownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395569_DownSql,
+		"1528395569_.down.sql",
+	)
+}
+
+func _1528395569_DownSql() (*asset, error) {
+	bytes, err := _1528395569_DownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395569_.down.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x70, 0xe0, 0x66, 0xe, 0x31, 0x60, 0x3, 0xbb, 0x87, 0xd3, 0x63, 0x2d, 0xa8, 0xf4, 0x13, 0x66, 0xec, 0x51, 0x97, 0x26, 0xb9, 0x56, 0x92, 0x1f, 0x6c, 0xd6, 0x66, 0xa6, 0xb1, 0x68, 0xf2, 0xb4}}
+	return a, nil
+}
+
+var __1528395569_UpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x48\xad\x28\x49\x2d\xca\x4b\xcc\x89\x2f\x4e\x2d\x2a\xcb\x4c\x4e\x2d\x56\x70\x74\x71\x51\x70\xf6\xf7\x09\xf5\xf5\x53\x28\x2d\xca\x89\xcf\xc8\x2f\x2e\x51\x28\x49\xad\x28\xb1\xe6\x72\x0e\x72\x75\x0c\x71\x55\xf0\xf4\x73\x71\x8d\xc0\xd4\x18\x0f\x53\x1d\x9f\x99\x52\xa1\xe0\xef\x87\xa9\x42\xa3\xb4\x28\x27\x3e\x23\xbf\xb8\x44\xd3\x9a\x0b\x10\x00\x00\xff\xff\x41\x8c\xff\x58\x84\x00\x00\x00")
+
+func _1528395569_UpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395569_UpSql,
+		"1528395569_.up.sql",
+	)
+}
+
+func _1528395569_UpSql() (*asset, error) {
+	bytes, err := _1528395569_UpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395569_.up.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xe3, 0x70, 0xc6, 0x72, 0x35, 0xce, 0xc1, 0xc5, 0x3d, 0x84, 0x76, 0x6e, 0xba, 0xc6, 0xfa, 0x52, 0xcd, 0x32, 0xc, 0xe0, 0x4d, 0xaa, 0xe5, 0x77, 0xa2, 0x34, 0x9c, 0x31, 0x1b, 0x74, 0xc9, 0xa1}}
+	return a, nil
+}
+
+var __1528395570_DownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x3f\x00\xc0\xff\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x65\x78\x74\x65\x72\x6e\x61\x6c\x5f\x73\x65\x72\x76\x69\x63\x65\x73\x20\x44\x52\x4f\x50\x20\x43\x4f\x4c\x55\x4d\x4e\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x72\x65\x61\x64\x5f\x6f\x6e\x6c\x79\x3b\x0a\x01\x00\x00\xff\xff\xd9\x0d\x93\x0a\x3f\x00\x00\x00")
+
+func _1528395570_DownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395570_DownSql,
+		"1528395570_.down.sql",
+	)
+}
+
+func _1528395570_DownSql() (*asset, error) {
+	bytes, err := _1528395570_DownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395570_.down.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x90, 0xeb, 0xf0, 0xb, 0x25, 0x1a, 0x81, 0x4c, 0xf2, 0xea, 0xbf, 0xc1, 0xea, 0x8a, 0x4c, 0x29, 0x5, 0x91, 0x3c, 0x7f, 0xb2, 0x94, 0x68, 0x90, 0x78, 0x40, 0x87, 0x16, 0x83, 0xc7, 0x2d, 0xdb}}
+	return a, nil
+}
+
+var __1528395570_UpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x53\x00\xac\xff\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x65\x78\x74\x65\x72\x6e\x61\x6c\x5f\x73\x65\x72\x76\x69\x63\x65\x73\x20\x41\x44\x44\x20\x43\x4f\x4c\x55\x4d\x4e\x20\x72\x65\x61\x64\x5f\x6f\x6e\x6c\x79\x20\x62\x6f\x6f\x6c\x65\x61\x6e\x20\x4e\x4f\x54\x20\x4e\x55\x4c\x4c\x20\x44\x45\x46\x41\x55\x4c\x54\x20\x66\x61\x6c\x73\x65\x3b\x0a\x01\x00\x00\xff\xff\x5e\xbb\xb1\xcd\x53\x00\x00\x00")
+
+func _1528395570_UpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395570_UpSql,
+		"1528395570_.up.sql",
+	)
+}
+
+func _1528395570_UpSql() (*asset, error) {
+	bytes, err := _1528395570_UpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395570_.up.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xe0, 0x43, 0x6e, 0xa0, 0x36, 0xcf, 0x11, 0x8f, 0x75, 0x51, 0xb4, 0x2f, 0x79, 0x8, 0xad, 0x4, 0x21, 0x60, 0x37, 0x6e, 0xb7, 0x1b, 0xda, 0x5c, 0xe8, 0x2a, 0x6e, 0x5c, 0xec, 0xb2, 0x6d, 0x5e}}
+	return a, nil
+}
+
 // Asset loads and returns the asset for the given name.
 // It returns an error if the asset could not be found or
 // could not be loaded.
@@ -4528,6 +4822,34 @@ var _bindata = map[string]func() (*asset, error){
 	"1528395563_.down.sql": _1528395563_DownSql,
 
 	"1528395563_.up.sql": _1528395563_UpSql,
+
+	"1528395564_.down.sql": _1528395564_DownSql,
+
+	"1528395564_.up.sql": _1528395564_UpSql,
+
+	"1528395565_.down.sql": _1528395565_DownSql,
+
+	"1528395565_.up.sql": _1528395565_UpSql,
+
+	"1528395566_.down.sql": _1528395566_DownSql,
+
+	"1528395566_.up.sql": _1528395566_UpSql,
+
+	"1528395567_.down.sql": _1528395567_DownSql,
+
+	"1528395567_.up.sql": _1528395567_UpSql,
+
+	"1528395568_.down.sql": _1528395568_DownSql,
+
+	"1528395568_.up.sql": _1528395568_UpSql,
+
+	"1528395569_.down.sql": _1528395569_DownSql,
+
+	"1528395569_.up.sql": _1528395569_UpSql,
+
+	"1528395570_.down.sql": _1528395570_DownSql,
+
+	"1528395570_.up.sql": _1528395570_UpSql,
 }
 
 // AssetDir returns the file names below a certain
@@ -4761,6 +5083,20 @@ var _bintree = &bintree{nil, map[string]*bintree{
 	"1528395562_.up.sql":                                          &bintree{_1528395562_UpSql, map[string]*bintree{}},
 	"1528395563_.down.sql":                                        &bintree{_1528395563_DownSql, map[string]*bintree{}},
 	"1528395563_.up.sql":                                          &bintree{_1528395563_UpSql, map[string]*bintree{}},
+	"1528395564_.down.sql":                                        &bintree{_1528395564_DownSql, map[string]*bintree{}},
+	"1528395564_.up.sql":                                          &bintree{_1528395564_UpSql, map[string]*bintree{}},
+	"1528395565_.down.sql":                                        &bintree{_1528395565_DownSql, map[string]*bintree{}},
+	"1528395565_.up.sql":                                          &bintree{_1528395565_UpSql, map[string]*bintree{}},
+	"1528395566_.down.sql":                                        &bintree{_1528395566_DownSql, map[string]*bintree{}},
+	"1528395566_.up.sql":                                          &bintree{_1528395566_UpSql, map[string]*bintree{}},
+	"1528395567_.down.sql":                                        &bintree{_1528395567_DownSql, map[string]*bintree{}},
+	"1528395567_.up.sql":                                          &bintree{_1528395567_UpSql, map[string]*bintree{}},
+	"1528395568_.down.sql":                                        &bintree{_1528395568_DownSql, map[string]*bintree{}},
+	"1528395568_.up.sql":                                          &bintree{_1528395568_UpSql, map[string]*bintree{}},
+	"1528395569_.down.sql":                                        &bintree{_1528395569_DownSql, map[string]*bintree{}},
+	"1528395569_.up.sql":                                          &bintree{_1528395569_UpSql, map[string]*bintree{}},
+	"1528395570_.down.sql":                                        &bintree{_1528395570_DownSql, map[string]*bintree{}},
+	"1528395570_.up.sql":                                          &bintree{_1528395570_UpSql, map[string]*bintree{}},
 }}
 
 // RestoreAsset restores an asset under the given directory.
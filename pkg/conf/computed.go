@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	"github.com/sourcegraph/sourcegraph/pkg/env"
+	"github.com/sourcegraph/sourcegraph/schema"
 )
 
 func init() {
@@ -43,9 +44,15 @@ func UpdateScheduler2Enabled() bool {
 
 // ExternalServicesEnabled returns true if the ExternalService experiment is enabled.
 func ExternalServicesEnabled() bool {
-	p := Get().ExperimentalFeatures.ExternalServices
+	return ExternalServicesEnabledIn(Get())
+}
+
+// ExternalServicesEnabledIn is like ExternalServicesEnabled, but it checks the given configuration
+// instead of the current one. Callers that also read other properties use it so that all of their
+// reads come from the same configuration, even if it is reloaded in the meantime.
+func ExternalServicesEnabledIn(c *schema.SiteConfiguration) bool {
 	// default is disabled
-	return p == "enabled"
+	return c.ExperimentalFeatures != nil && c.ExperimentalFeatures.ExternalServices == "enabled"
 }
 
 type AccessTokAllow string
@@ -0,0 +1,51 @@
+package reposource
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/conf"
+)
+
+// CloneURLToCommitURL returns the URL of the commit on the web interface of the code host of the
+// repository with the clone URL (e.g., https://github.com/gorilla/mux/commit/abc123). Only GitHub and
+// GitLab are supported: those configured in the site configuration, and github.com and gitlab.com.
+// It returns the empty string and a nil error if the clone URL's code host is not one of them.
+func CloneURLToCommitURL(cloneURL string, commit api.CommitID) (string, error) {
+	cfg := conf.Get()
+
+	type codeHost struct {
+		baseURL    string
+		commitPath string // the path of a commit relative to the repository's URL
+	}
+	codeHosts := make([]codeHost, 0, len(cfg.Github)+len(cfg.Gitlab)+2)
+	for _, c := range cfg.Github {
+		codeHosts = append(codeHosts, codeHost{c.Url, "/commit/"})
+	}
+	for _, c := range cfg.Gitlab {
+		codeHosts = append(codeHosts, codeHost{c.Url, "/-/commit/"})
+	}
+	codeHosts = append(codeHosts, codeHost{"https://github.com", "/commit/"}, codeHost{"https://gitlab.com", "/-/commit/"})
+
+	for _, h := range codeHosts {
+		parsedCloneURL, baseURL, match, err := parseURLs(cloneURL, h.baseURL)
+		if err != nil {
+			return "", err
+		}
+		if !match {
+			continue
+		}
+		// HTTP(S) clone URLs include the base URL's path (if the code host is not at the root of its
+		// host), but SCP-style clone URLs don't.
+		repoPath := strings.TrimPrefix(strings.TrimSuffix(parsedCloneURL.Path, ".git"), "/")
+		if basePath := strings.Trim(baseURL.Path, "/"); basePath != "" {
+			repoPath = strings.TrimPrefix(repoPath, basePath+"/")
+		}
+		if repoPath == "" {
+			continue
+		}
+		return baseURL.ResolveReference(&url.URL{Path: repoPath + h.commitPath + string(commit)}).String(), nil
+	}
+	return "", nil
+}
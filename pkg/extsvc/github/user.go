@@ -1,6 +1,8 @@
 package github
 
 import (
+	"context"
+
 	"github.com/google/go-github/github"
 	"github.com/sourcegraph/sourcegraph/pkg/extsvc"
 	"golang.org/x/oauth2"
@@ -31,3 +33,12 @@ func SetExternalAccountData(data *extsvc.ExternalAccountData, user *github.User,
 	data.SetAccountData(user)
 	data.SetAuthData(token)
 }
+
+// GetAuthenticatedUser returns the user authenticated by the client's default token.
+func (c *Client) GetAuthenticatedUser(ctx context.Context) (*github.User, error) {
+	var u github.User
+	if err := c.requestGet(ctx, "", "/user", &u); err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
@@ -3,6 +3,7 @@ package jsonc
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	"github.com/sourcegraph/jsonx"
 )
@@ -30,6 +31,101 @@ func Parse(text string) ([]byte, error) {
 	return data, nil
 }
 
+// ParseValue is like Parse, except that it also returns an error if the text contains anything
+// other than whitespace and comments after the first JSON value (which Parse ignores or reports
+// confusingly), such as `{} {}`.
+func ParseValue(text string) ([]byte, error) {
+	if i := skipSpaceAndComments(text, endOfValue(text)); i < len(text) {
+		rest := text[i:]
+		if max := 20; len(rest) > max {
+			rest = rest[:max] + "..."
+		}
+		return nil, fmt.Errorf("failed to parse JSON: unexpected %q after the JSON value (at offset %d)", rest, i)
+	}
+	return Parse(text)
+}
+
+// endOfValue returns the offset just past the first JSON value in text (after any leading
+// whitespace and comments), or len(text) if there is no value. It only finds the extent of the
+// value (by matching brackets outside of strings and comments); it doesn't validate the value.
+func endOfValue(text string) int {
+	i := skipSpaceAndComments(text, 0)
+	if i == len(text) {
+		return i
+	}
+	switch text[i] {
+	case '{', '[':
+		depth := 0
+		for i < len(text) {
+			switch c := text[i]; {
+			case c == '"':
+				i = endOfString(text, i)
+				continue
+			case c == '/' && i+1 < len(text) && (text[i+1] == '/' || text[i+1] == '*'):
+				i = skipSpaceAndComments(text, i)
+				continue
+			case c == '{' || c == '[':
+				depth++
+			case c == '}' || c == ']':
+				depth--
+				if depth == 0 {
+					return i + 1
+				}
+			}
+			i++
+		}
+		return i
+	case '"':
+		return endOfString(text, i)
+	default:
+		// A literal (number, true, false, or null).
+		for i < len(text) && !strings.ContainsRune(" \t\r\n,:[]{}\"/", rune(text[i])) {
+			i++
+		}
+		return i
+	}
+}
+
+// endOfString returns the offset just past the JSON string that starts (with a double quote) at
+// the offset i in text.
+func endOfString(text string, i int) int {
+	for i++; i < len(text); i++ {
+		switch text[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1
+		}
+	}
+	return i
+}
+
+// skipSpaceAndComments returns the offset of the first character at or after the offset i in text
+// that is not whitespace or part of a comment.
+func skipSpaceAndComments(text string, i int) int {
+	for i < len(text) {
+		switch {
+		case strings.ContainsRune(" \t\r\n", rune(text[i])):
+			i++
+		case strings.HasPrefix(text[i:], "//"):
+			if n := strings.IndexByte(text[i:], '\n'); n >= 0 {
+				i += n + 1
+			} else {
+				i = len(text)
+			}
+		case strings.HasPrefix(text[i:], "/*"):
+			if n := strings.Index(text[i+2:], "*/"); n >= 0 {
+				i += 2 + n + 2
+			} else {
+				i = len(text)
+			}
+		default:
+			return i
+		}
+	}
+	return i
+}
+
 // Normalize is like Parse, except it ignores errors and always returns valid JSON, even if that
 // JSON is a subset of the input.
 func Normalize(input string) []byte {
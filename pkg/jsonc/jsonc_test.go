@@ -35,3 +35,40 @@ func TestNormalize(t *testing.T) {
 		t.Errorf("got %s, want %s", got, want)
 	}
 }
+
+func TestParseValue(t *testing.T) {
+	tests := map[string]struct {
+		input   string
+		want    string
+		wantErr string
+	}{
+		"object":              {input: `{"a": [1, "}"]}`, want: `{"a":[1,"}"]}`},
+		"trailing comments":   {input: "{\"a\": \"b\"} // comment\n/* another\ncomment */\n", want: `{"a":"b"}`},
+		"comment with braces": {input: "{\n// }\n\"a\": \"/* } */\"}", want: `{"a":"/* } */"}`},
+		"trailing garbage": {
+			input:   `{}trailing`,
+			wantErr: `failed to parse JSON: unexpected "trailing" after the JSON value (at offset 2)`,
+		},
+		"two values": {
+			input:   `{} {}`,
+			wantErr: `failed to parse JSON: unexpected "{}" after the JSON value (at offset 3)`,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := ParseValue(test.input)
+			if test.wantErr != "" {
+				if err == nil || err.Error() != test.wantErr {
+					t.Errorf("got error %v, want %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != test.want {
+				t.Errorf("got %s, want %s", got, test.want)
+			}
+		})
+	}
+}
@@ -4,7 +4,9 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"os"
+	"strconv"
 
 	opentracing "github.com/opentracing/opentracing-go"
 	"github.com/pkg/errors"
@@ -31,6 +33,84 @@ func ReadFile(ctx context.Context, repo gitserver.Repo, commit api.CommitID, nam
 	return b, nil
 }
 
+// NewFileReader returns a reader of the content of the named file at commit. Unlike ReadFile, it
+// streams the content from gitserver instead of reading it all into memory. If the command fails
+// (e.g., because the file doesn't exist), Read returns the error. The caller must close the reader.
+func NewFileReader(ctx context.Context, repo gitserver.Repo, commit api.CommitID, name string) (io.ReadCloser, error) {
+	if Mocks.NewFileReader != nil {
+		return Mocks.NewFileReader(commit, name)
+	}
+
+	span, ctx := opentracing.StartSpanFromContext(ctx, "Git: NewFileReader")
+	span.SetTag("Name", name)
+	defer span.Finish()
+
+	if err := checkSpecArgSafety(string(commit)); err != nil {
+		return nil, err
+	}
+	ensureAbsCommit(commit)
+
+	cmd := gitserver.DefaultClient.Command("git", "show", string(commit)+":"+util.Rel(name))
+	cmd.Repo = repo
+	return gitserver.StdoutReader(ctx, cmd)
+}
+
+// BlobSize returns the size in bytes of the blob with the OID, without reading its content. If the
+// repository has no blob with the OID (including if the OID is of another type of object), it
+// returns an error that satisfies os.IsNotExist.
+func BlobSize(ctx context.Context, repo gitserver.Repo, oid OID) (int64, error) {
+	if Mocks.BlobSize != nil {
+		return Mocks.BlobSize(oid)
+	}
+
+	span, ctx := opentracing.StartSpanFromContext(ctx, "Git: BlobSize")
+	span.SetTag("OID", oid.String())
+	defer span.Finish()
+
+	out, err := catFile(ctx, repo, "-t", oid)
+	if err != nil {
+		return 0, err
+	}
+	if ObjectType(bytes.TrimSpace(out)) != ObjectTypeBlob {
+		return 0, &os.PathError{Op: "cat-file", Path: oid.String(), Err: os.ErrNotExist}
+	}
+	out, err = catFile(ctx, repo, "-s", oid)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(string(bytes.TrimSpace(out)), 10, 64)
+}
+
+// ReadBlob returns the content of the blob with the OID. If the repository has no blob with the OID,
+// it returns an error that satisfies os.IsNotExist. Callers that need to limit the size of the
+// content should check it with BlobSize first.
+func ReadBlob(ctx context.Context, repo gitserver.Repo, oid OID) ([]byte, error) {
+	if Mocks.ReadBlob != nil {
+		return Mocks.ReadBlob(oid)
+	}
+
+	span, ctx := opentracing.StartSpanFromContext(ctx, "Git: ReadBlob")
+	span.SetTag("OID", oid.String())
+	defer span.Finish()
+
+	return catFile(ctx, repo, "blob", oid)
+}
+
+// catFile runs `git cat-file` with the flag (such as -t, or an object type) and the OID, and
+// returns its output.
+func catFile(ctx context.Context, repo gitserver.Repo, flag string, oid OID) ([]byte, error) {
+	cmd := gitserver.DefaultClient.Command("git", "cat-file", flag, oid.String())
+	cmd.Repo = repo
+	stdout, stderr, err := cmd.DividedOutput(ctx)
+	if err != nil {
+		if bytes.Contains(stderr, []byte("Not a valid object name")) || bytes.Contains(stderr, []byte("could not get object info")) || bytes.Contains(stderr, []byte("bad file")) {
+			return nil, &os.PathError{Op: "cat-file", Path: oid.String(), Err: os.ErrNotExist}
+		}
+		return nil, errors.WithMessage(err, fmt.Sprintf("git command %v failed (stderr: %q)", cmd.Args, stderr))
+	}
+	return stdout, nil
+}
+
 func readFileBytes(ctx context.Context, repo gitserver.Repo, commit api.CommitID, name string) ([]byte, error) {
 	ensureAbsCommit(commit)
 
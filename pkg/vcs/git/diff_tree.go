@@ -0,0 +1,92 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path"
+
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/gitserver"
+	"github.com/sourcegraph/sourcegraph/pkg/vcs/util"
+)
+
+// Possible values of TreeChange.Kind.
+const (
+	TreeChangeAdded    = "ADDED"
+	TreeChangeRemoved  = "REMOVED"
+	TreeChangeModified = "MODIFIED"
+)
+
+// TreeChange is a file (or symlink or submodule) that differs between two commits.
+type TreeChange struct {
+	Path string // relative to the repository root
+	Kind string // TreeChangeAdded, TreeChangeRemoved, or TreeChangeModified
+}
+
+// DiffTree returns the files under dir (recursively) that were added, removed, or modified between
+// the base and head commits, ordered by path. It is the equivalent of `git diff --name-status`,
+// except that renames are reported as a removal and an addition, and a change of type (such as a
+// file replaced by a symlink) is reported as a modification.
+func DiffTree(ctx context.Context, repo gitserver.Repo, base, head api.CommitID, dir string) ([]*TreeChange, error) {
+	if Mocks.DiffTree != nil {
+		return Mocks.DiffTree(base, head, dir)
+	}
+
+	span, ctx := opentracing.StartSpanFromContext(ctx, "Git: DiffTree")
+	span.SetTag("Base", base)
+	span.SetTag("Head", head)
+	span.SetTag("Dir", dir)
+	defer span.Finish()
+
+	if err := checkSpecArgSafety(string(base)); err != nil {
+		return nil, err
+	}
+	if err := checkSpecArgSafety(string(head)); err != nil {
+		return nil, err
+	}
+	ensureAbsCommit(base)
+	ensureAbsCommit(head)
+
+	args := []string{"diff-tree", "-r", "-z", "--name-status", "--no-renames", string(base), string(head)}
+	if dir = path.Clean(util.Rel(dir)); dir != "." {
+		args = append(args, "--", dir)
+	}
+	cmd := gitserver.DefaultClient.Command("git", args...)
+	cmd.Repo = repo
+	out, err := cmd.CombinedOutput(ctx)
+	if err != nil {
+		return nil, errors.WithMessage(err, fmt.Sprintf("git command %v failed (output: %q)", cmd.Args, out))
+	}
+	return parseDiffTreeNameStatus(out)
+}
+
+// parseDiffTreeNameStatus parses the output of `git diff-tree -z --name-status --no-renames`, which
+// is a NUL-separated list of alternating status letters and paths.
+func parseDiffTreeNameStatus(out []byte) ([]*TreeChange, error) {
+	fields := bytes.Split(bytes.TrimSuffix(out, []byte{'\x00'}), []byte{'\x00'})
+	if len(fields) == 1 && len(fields[0]) == 0 {
+		return nil, nil
+	}
+	if len(fields)%2 != 0 {
+		return nil, fmt.Errorf("invalid `git diff-tree` output: %q", out)
+	}
+	changes := make([]*TreeChange, 0, len(fields)/2)
+	for i := 0; i < len(fields); i += 2 {
+		change := &TreeChange{Path: string(fields[i+1])}
+		switch string(fields[i]) {
+		case "A":
+			change.Kind = TreeChangeAdded
+		case "D":
+			change.Kind = TreeChangeRemoved
+		case "M", "T":
+			change.Kind = TreeChangeModified
+		default:
+			return nil, fmt.Errorf("invalid `git diff-tree` status %q for %q", fields[i], fields[i+1])
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
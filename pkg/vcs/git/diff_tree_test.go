@@ -0,0 +1,65 @@
+package git_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/pkg/vcs/git"
+)
+
+func TestDiffTree(t *testing.T) {
+	t.Parallel()
+
+	repo := makeGitRepository(t,
+		"mkdir -p dir/sub",
+		"echo 1 > dir/a && echo 1 > dir/sub/b && echo 1 > dir/c && echo 1 > top",
+		"git add -A",
+		"GIT_COMMITTER_NAME=a GIT_COMMITTER_EMAIL=a@a.com GIT_COMMITTER_DATE=2006-01-02T15:04:05Z git commit -m commit1 --author='a <a@a.com>' --date 2006-01-02T15:04:05Z",
+		"git tag base",
+		"echo 2 > dir/sub/b && echo 2 > top && git rm -q dir/c && echo 1 > 'dir/d e' && git mv dir/a dir/f",
+		"git add -A",
+		"GIT_COMMITTER_NAME=a GIT_COMMITTER_EMAIL=a@a.com GIT_COMMITTER_DATE=2006-01-02T15:04:06Z git commit -m commit2 --author='a <a@a.com>' --date 2006-01-02T15:04:06Z",
+	)
+	ctx := context.Background()
+	base, err := git.ResolveRevision(ctx, repo, nil, "base", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	head, err := git.ResolveRevision(ctx, repo, nil, "HEAD", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string][]*git.TreeChange{
+		"": {
+			{Path: "dir/a", Kind: git.TreeChangeRemoved},
+			{Path: "dir/c", Kind: git.TreeChangeRemoved},
+			{Path: "dir/d e", Kind: git.TreeChangeAdded},
+			{Path: "dir/f", Kind: git.TreeChangeAdded},
+			{Path: "dir/sub/b", Kind: git.TreeChangeModified},
+			{Path: "top", Kind: git.TreeChangeModified},
+		},
+		"dir/sub": {
+			{Path: "dir/sub/b", Kind: git.TreeChangeModified},
+		},
+	}
+	for dir, want := range tests {
+		changes, err := git.DiffTree(ctx, repo, base, head, dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(changes, want) {
+			t.Errorf("%q: got %v, want %v", dir, changes, want)
+		}
+	}
+
+	// There are no changes between a commit and itself.
+	changes, err := git.DiffTree(ctx, repo, head, head, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 0 {
+		t.Errorf("got %d changes, want none", len(changes))
+	}
+}
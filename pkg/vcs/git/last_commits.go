@@ -0,0 +1,112 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/gitserver"
+	"github.com/sourcegraph/sourcegraph/pkg/vcs/util"
+)
+
+// LastCommitsForEntries returns the most recent commit (reachable from commit) that modified each
+// of the named direct children of the directory dir. The returned map is keyed by name.
+//
+// All of the entries are resolved in a single traversal of the history of dir (instead of one `git
+// log` per entry), which stops as soon as every entry's last commit is found or after maxCommits
+// commits have been examined. Entries that were not modified in the examined commits are absent
+// from the result.
+func LastCommitsForEntries(ctx context.Context, repo gitserver.Repo, commit api.CommitID, dir string, names []string, maxCommits int) (map[string]*Commit, error) {
+	if Mocks.LastCommitsForEntries != nil {
+		return Mocks.LastCommitsForEntries(commit, dir, names, maxCommits)
+	}
+
+	span, ctx := opentracing.StartSpanFromContext(ctx, "Git: LastCommitsForEntries")
+	span.SetTag("Commit", commit)
+	span.SetTag("Dir", dir)
+	span.SetTag("Names", len(names))
+	defer span.Finish()
+
+	if err := checkSpecArgSafety(string(commit)); err != nil {
+		return nil, err
+	}
+	ensureAbsCommit(commit)
+
+	dir = path.Clean(util.Rel(dir))
+	if err := checkSpecArgSafety(dir); err != nil {
+		return nil, err
+	}
+
+	remaining := make(map[string]struct{}, len(names))
+	for _, name := range names {
+		remaining[name] = struct{}{}
+	}
+	commits := make(map[string]*Commit, len(names))
+	if len(remaining) == 0 {
+		return commits, nil
+	}
+
+	// Each commit is preceded by a record separator (\x1e) and followed by the NUL-separated list
+	// of the files it modified.
+	args := []string{
+		"log",
+		"-z",
+		"--name-only",
+		"--format=format:\x1e" + strings.TrimPrefix(logFormatWithoutRefs, "--format=format:"),
+		"-n", strconv.Itoa(maxCommits),
+		string(commit),
+	}
+	var prefix string
+	if dir != "." {
+		args = append(args, "--", dir)
+		prefix = dir + "/"
+	}
+
+	// Cancel the command if all entries are resolved before the history is exhausted.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	cmd := gitserver.DefaultClient.Command("git", args...)
+	cmd.Repo = repo
+	rc, err := gitserver.StdoutReader(ctx, cmd)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	br := bufio.NewReader(rc)
+	for len(remaining) > 0 {
+		record, err := br.ReadBytes('\x1e')
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		record = bytes.TrimSuffix(record, []byte{'\x1e'})
+		if len(record) > 0 {
+			c, _, files, parseErr := parseCommitFromLog(record)
+			if parseErr != nil {
+				return nil, fmt.Errorf("parsing commit for last commits of entries in %q: %s", dir, parseErr)
+			}
+			for _, file := range bytes.Split(bytes.TrimPrefix(files, []byte{'\n'}), []byte{'\x00'}) {
+				name := strings.TrimPrefix(string(file), prefix)
+				if i := strings.IndexByte(name, '/'); i != -1 {
+					name = name[:i]
+				}
+				if _, ok := remaining[name]; ok {
+					commits[name] = c
+					delete(remaining, name)
+				}
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+	}
+	return commits, nil
+}
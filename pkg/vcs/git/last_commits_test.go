@@ -0,0 +1,54 @@
+package git_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/pkg/vcs/git"
+)
+
+func TestLastCommitsForEntries(t *testing.T) {
+	t.Parallel()
+
+	repo := makeGitRepository(t,
+		"mkdir -p dir/sub",
+		"echo 1 > dir/a && echo 1 > dir/sub/b && echo 1 > 'dir/c d' && echo 1 > top",
+		"git add -A",
+		"GIT_COMMITTER_NAME=a GIT_COMMITTER_EMAIL=a@a.com GIT_COMMITTER_DATE=2006-01-02T15:04:05Z git commit -m commit1 --author='a <a@a.com>' --date 2006-01-02T15:04:05Z",
+		"echo 2 > dir/sub/b && echo 2 > top",
+		"GIT_COMMITTER_NAME=a GIT_COMMITTER_EMAIL=a@a.com GIT_COMMITTER_DATE=2006-01-02T15:04:06Z git commit -am commit2 --author='a <a@a.com>' --date 2006-01-02T15:04:06Z",
+		"echo 3 > dir/a",
+		"GIT_COMMITTER_NAME=a GIT_COMMITTER_EMAIL=a@a.com GIT_COMMITTER_DATE=2006-01-02T15:04:07Z git commit -am commit3 --author='a <a@a.com>' --date 2006-01-02T15:04:07Z",
+	)
+	ctx := context.Background()
+	head, err := git.ResolveRevision(ctx, repo, nil, "HEAD", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	commits, err := git.LastCommitsForEntries(ctx, repo, head, "dir", []string{"a", "sub", "c d", "nonexistent"}, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"a": "commit3", "sub": "commit2", "c d": "commit1"}
+	if len(commits) != len(want) {
+		t.Errorf("got %d commits, want %d", len(commits), len(want))
+	}
+	for name, wantMessage := range want {
+		if c := commits[name]; c == nil || c.Message != wantMessage {
+			t.Errorf("%s: got commit %+v, want message %q", name, c, wantMessage)
+		}
+	}
+
+	// Only the most recent commit is examined.
+	commits, err = git.LastCommitsForEntries(ctx, repo, head, "", []string{"dir", "top"}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := commits["dir"]; c == nil || c.Message != "commit3" {
+		t.Errorf("dir: got commit %+v, want message %q", c, "commit3")
+	}
+	if c, ok := commits["top"]; ok {
+		t.Errorf("top: got commit %+v beyond the traversal limit", c)
+	}
+}
@@ -1,6 +1,7 @@
 package git
 
 import (
+	"io"
 	"os"
 
 	"github.com/sourcegraph/sourcegraph/pkg/api"
@@ -11,12 +12,17 @@ import (
 //
 // (The emptyMocks is used by ResetMocks to zero out Mocks without needing to use a named type.)
 var Mocks, emptyMocks struct {
-	GetCommit        func(api.CommitID) (*Commit, error)
-	ExecSafe         func(params []string) (stdout, stderr []byte, exitCode int, err error)
-	RawLogDiffSearch func(opt RawLogDiffSearchOptions) ([]*LogCommitSearchResult, bool, error)
-	ReadDir          func(commit api.CommitID, name string, recurse bool) ([]os.FileInfo, error)
-	ResolveRevision  func(spec string, opt *ResolveRevisionOptions) (api.CommitID, error)
-	Stat             func(commit api.CommitID, name string) (os.FileInfo, error)
+	BlobSize              func(oid OID) (int64, error)
+	DiffTree              func(base, head api.CommitID, dir string) ([]*TreeChange, error)
+	GetCommit             func(api.CommitID) (*Commit, error)
+	ExecSafe              func(params []string) (stdout, stderr []byte, exitCode int, err error)
+	LastCommitsForEntries func(commit api.CommitID, dir string, names []string, maxCommits int) (map[string]*Commit, error)
+	NewFileReader         func(commit api.CommitID, name string) (io.ReadCloser, error)
+	RawLogDiffSearch      func(opt RawLogDiffSearchOptions) ([]*LogCommitSearchResult, bool, error)
+	ReadBlob              func(oid OID) ([]byte, error)
+	ReadDir               func(commit api.CommitID, name string, recurse bool) ([]os.FileInfo, error)
+	ResolveRevision       func(spec string, opt *ResolveRevisionOptions) (api.CommitID, error)
+	Stat                  func(commit api.CommitID, name string) (os.FileInfo, error)
 }
 
 // ResetMocks clears the mock functions set on Mocks (so that subsequent tests don't inadvertently
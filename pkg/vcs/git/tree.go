@@ -1,9 +1,11 @@
 package git
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"os"
 	stdlibpath "path"
 	"path/filepath"
@@ -115,6 +117,119 @@ func ReadDir(ctx context.Context, repo gitserver.Repo, commit api.CommitID, path
 	return lsTree(ctx, repo, commit, path, recurse)
 }
 
+// StopWalk may be returned by a WalkTree callback to stop the walk. WalkTree then returns nil.
+var StopWalk = errors.New("stop walk")
+
+// WalkTree calls walkFn for each entry in the tree at path at commit, including the entries of
+// its subtrees (recursively). Unlike ReadDir, it streams entries as they are read from `git
+// ls-tree` instead of materializing the whole listing, so callers that process entries one at a
+// time (or that stop early) use memory proportional to the entries they retain, not the size of
+// the tree. It doesn't use (or populate) ReadDir's cache of root tree listings, so callers that
+// retain the whole listing of the root tree should use ReadDir instead.
+//
+// Entries are visited in `git ls-tree -r -t` order (each tree before its contents) and are named
+// relative to path, as with ReadDir(ctx, repo, commit, path, true). If walkFn returns StopWalk,
+// the walk stops and WalkTree returns nil; any other error stops the walk and is returned.
+func WalkTree(ctx context.Context, repo gitserver.Repo, commit api.CommitID, path string, walkFn func(fi os.FileInfo) error) error {
+	if Mocks.ReadDir != nil {
+		fis, err := Mocks.ReadDir(commit, path, true)
+		if err != nil {
+			return err
+		}
+		for _, fi := range fis {
+			if err := walkFn(fi); err != nil {
+				if err == StopWalk {
+					return nil
+				}
+				return err
+			}
+		}
+		return nil
+	}
+
+	span, ctx := opentracing.StartSpanFromContext(ctx, "Git: WalkTree")
+	span.SetTag("Commit", commit)
+	span.SetTag("Path", path)
+	defer span.Finish()
+
+	if err := checkSpecArgSafety(string(commit)); err != nil {
+		return err
+	}
+	ensureAbsCommit(commit)
+
+	if path != "" {
+		// Trailing slash is necessary to ls-tree under the dir (see ReadDir).
+		path = filepath.Clean(util.Rel(path)) + "/"
+	}
+	if err := checkSpecArgSafety(path); err != nil {
+		return err
+	}
+
+	// Cancel the command if walkFn stops the walk early.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	cmd := gitserver.DefaultClient.Command("git", lsTreeArgs(commit, path, true)...)
+	cmd.Repo = repo
+	rc, err := gitserver.StdoutReader(ctx, cmd)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	trimPath := strings.TrimPrefix(path, "./")
+	prefixLen := strings.LastIndexByte(trimPath, '/') + 1
+	br := bufio.NewReader(rc)
+	var n int
+	for {
+		line, err := br.ReadString('\x00')
+		if err == io.EOF {
+			if line != "" {
+				return fmt.Errorf("invalid `git ls-tree` output: %q", line)
+			}
+			break
+		}
+		if err != nil {
+			if strings.Contains(err.Error(), "exists on disk, but not in") {
+				return &os.PathError{Op: "ls-tree", Path: filepath.ToSlash(path), Err: os.ErrNotExist}
+			}
+			return err
+		}
+		fi, err := parseLsTreeEntry(ctx, repo, commit, trimPath, prefixLen, strings.TrimSuffix(line, "\x00"))
+		if err != nil {
+			return err
+		}
+		n++
+		if err := walkFn(fi); err != nil {
+			if err == StopWalk {
+				return nil
+			}
+			return err
+		}
+	}
+	if n == 0 && stdlibpath.Clean(path) != "." {
+		return &os.PathError{Op: "git ls-tree", Path: path, Err: os.ErrNotExist}
+	}
+	return nil
+}
+
+func lsTreeArgs(commit api.CommitID, path string, recurse bool) []string {
+	args := []string{
+		"ls-tree",
+		"--long", // show size
+		"--full-name",
+		"-z",
+		string(commit),
+	}
+	if recurse {
+		args = append(args, "-r", "-t")
+	}
+	if path != "" {
+		args = append(args, "--", filepath.ToSlash(path))
+	}
+	return args
+}
+
 // lsTreeRootCache caches the result of running `git ls-tree ...` on a repository's root path
 // (because non-root paths are likely to have a lower cache hit rate). It is intended to improve the
 // perceived performance of large monorepos, where the tree for a given repo+commit (usually the
@@ -170,20 +285,7 @@ func lsTreeUncached(ctx context.Context, repo gitserver.Repo, commit api.CommitI
 		return nil, err
 	}
 
-	args := []string{
-		"ls-tree",
-		"--long", // show size
-		"--full-name",
-		"-z",
-		string(commit),
-	}
-	if recurse {
-		args = append(args, "-r", "-t")
-	}
-	if path != "" {
-		args = append(args, "--", filepath.ToSlash(path))
-	}
-	cmd := gitserver.DefaultClient.Command("git", args...)
+	cmd := gitserver.DefaultClient.Command("git", lsTreeArgs(commit, path, recurse)...)
 	cmd.Repo = repo
 	out, err := cmd.CombinedOutput(ctx)
 	if err != nil {
@@ -210,86 +312,96 @@ func lsTreeUncached(ctx context.Context, repo gitserver.Repo, commit api.CommitI
 			// last entry is empty
 			continue
 		}
-
-		tabPos := strings.IndexByte(line, '\t')
-		if tabPos == -1 {
-			return nil, fmt.Errorf("invalid `git ls-tree` output: %q", out)
-		}
-		info := strings.SplitN(line[:tabPos], " ", 4)
-		name := line[tabPos+1:]
-		if len(name) < len(trimPath) {
-			// This is in a submodule; return the original path to avoid a slice out of bounds panic
-			// when setting the FileInfo._Name below.
-			name = trimPath
+		fis[i], err = parseLsTreeEntry(ctx, repo, commit, trimPath, prefixLen, line)
+		if err != nil {
+			return nil, err
 		}
+	}
+	util.SortFileInfosByName(fis)
 
-		if len(info) != 4 {
-			return nil, fmt.Errorf("invalid `git ls-tree` output: %q", out)
-		}
-		typ := info[1]
-		oid := info[2]
-		if !IsAbsoluteRevision(oid) {
-			return nil, fmt.Errorf("invalid `git ls-tree` oid output: %q", oid)
-		}
+	return fis, nil
+}
 
-		sizeStr := strings.TrimSpace(info[3])
-		var size int64
-		if sizeStr != "-" {
-			// Size of "-" indicates a dir or submodule.
-			size, err = strconv.ParseInt(sizeStr, 10, 64)
-			if err != nil || size < 0 {
-				return nil, fmt.Errorf("invalid `git ls-tree` size output: %q (error: %s)", sizeStr, err)
-			}
-		}
+// parseLsTreeEntry parses an entry (without the trailing NUL) of the output of `git ls-tree
+// --long --full-name -z` for the tree at trimPath. The returned FileInfo's name is the entry's
+// path with the first prefixLen bytes removed.
+func parseLsTreeEntry(ctx context.Context, repo gitserver.Repo, commit api.CommitID, trimPath string, prefixLen int, line string) (os.FileInfo, error) {
+	tabPos := strings.IndexByte(line, '\t')
+	if tabPos == -1 {
+		return nil, fmt.Errorf("invalid `git ls-tree` output: %q", line)
+	}
+	info := strings.SplitN(line[:tabPos], " ", 4)
+	name := line[tabPos+1:]
+	if len(name) < len(trimPath) {
+		// This is in a submodule; return the original path to avoid a slice out of bounds panic
+		// when setting the FileInfo._Name below.
+		name = trimPath
+	}
 
-		var sys interface{}
-		modeVal, err := strconv.ParseInt(info[0], 8, 32)
-		if err != nil {
-			return nil, err
+	if len(info) != 4 {
+		return nil, fmt.Errorf("invalid `git ls-tree` output: %q", line)
+	}
+	typ := info[1]
+	oid := info[2]
+	if !IsAbsoluteRevision(oid) {
+		return nil, fmt.Errorf("invalid `git ls-tree` oid output: %q", oid)
+	}
+
+	sizeStr := strings.TrimSpace(info[3])
+	var size int64
+	if sizeStr != "-" {
+		// Size of "-" indicates a dir or submodule.
+		var err error
+		size, err = strconv.ParseInt(sizeStr, 10, 64)
+		if err != nil || size < 0 {
+			return nil, fmt.Errorf("invalid `git ls-tree` size output: %q (error: %s)", sizeStr, err)
 		}
-		mode := os.FileMode(modeVal)
-		switch typ {
-		case "blob":
-			const gitModeSymlink = 020000
-			if mode&gitModeSymlink != 0 {
-				mode = os.ModeSymlink
-			} else {
-				// Regular file.
-				mode = mode | 0644
-			}
-		case "commit":
-			mode = mode | ModeSubmodule
-			cmd := gitserver.DefaultClient.Command("git", "show", fmt.Sprintf("%s:.gitmodules", commit))
-			cmd.Repo = repo
-			var submodule Submodule
-			if out, err := cmd.Output(ctx); err == nil {
-
-				var cfg config.Config
-				err := config.NewDecoder(bytes.NewBuffer(out)).Decode(&cfg)
-				if err != nil {
-					return nil, fmt.Errorf("error parsing .gitmodules: %s", err)
-				}
+	}
 
-				submodule.Path = cfg.Section("submodule").Subsection(name).Option("path")
-				submodule.URL = cfg.Section("submodule").Subsection(name).Option("url")
-			}
-			submodule.CommitID = api.CommitID(oid)
-			sys = submodule
-		case "tree":
-			mode = mode | os.ModeDir
+	var sys interface{}
+	modeVal, err := strconv.ParseInt(info[0], 8, 32)
+	if err != nil {
+		return nil, err
+	}
+	mode := os.FileMode(modeVal)
+	switch typ {
+	case "blob":
+		const gitModeSymlink = 020000
+		if mode&gitModeSymlink != 0 {
+			mode = os.ModeSymlink
+		} else {
+			// Regular file.
+			mode = mode | 0644
 		}
+	case "commit":
+		mode = mode | ModeSubmodule
+		cmd := gitserver.DefaultClient.Command("git", "show", fmt.Sprintf("%s:.gitmodules", commit))
+		cmd.Repo = repo
+		var submodule Submodule
+		if out, err := cmd.Output(ctx); err == nil {
+
+			var cfg config.Config
+			err := config.NewDecoder(bytes.NewBuffer(out)).Decode(&cfg)
+			if err != nil {
+				return nil, fmt.Errorf("error parsing .gitmodules: %s", err)
+			}
 
-		fis[i] = &util.FileInfo{
-			// This returns the full relative path (e.g. "path/to/file.go") when the path arg is "./"
-			// This behavior is necessary to construct the file tree.
-			// In all other cases, it returns the basename (e.g. "file.go").
-			Name_: name[prefixLen:],
-			Mode_: os.FileMode(mode),
-			Size_: size,
-			Sys_:  sys,
+			submodule.Path = cfg.Section("submodule").Subsection(name).Option("path")
+			submodule.URL = cfg.Section("submodule").Subsection(name).Option("url")
 		}
+		submodule.CommitID = api.CommitID(oid)
+		sys = submodule
+	case "tree":
+		mode = mode | os.ModeDir
 	}
-	util.SortFileInfosByName(fis)
 
-	return fis, nil
+	return &util.FileInfo{
+		// This returns the full relative path (e.g. "path/to/file.go") when the path arg is "./"
+		// This behavior is necessary to construct the file tree.
+		// In all other cases, it returns the basename (e.g. "file.go").
+		Name_: name[prefixLen:],
+		Mode_: os.FileMode(mode),
+		Size_: size,
+		Sys_:  sys,
+	}, nil
 }
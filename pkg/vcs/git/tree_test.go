@@ -452,3 +452,94 @@ func TestRepository_FileSystem_gitSubmodules(t *testing.T) {
 		}
 	}
 }
+
+func TestWalkTree(t *testing.T) {
+	t.Parallel()
+
+	repo := makeGitRepository(t,
+		"mkdir -p dir1/dir2",
+		"touch file1 dir1/file2 dir1/dir2/file3",
+		"git add file1 dir1",
+		"GIT_COMMITTER_NAME=a GIT_COMMITTER_EMAIL=a@a.com GIT_COMMITTER_DATE=2006-01-02T15:04:05Z git commit -m commit1 --author='a <a@a.com>' --date 2006-01-02T15:04:05Z",
+	)
+	ctx := context.Background()
+	commitID := api.CommitID(computeCommitHash(repo.URL, true))
+
+	want, err := git.ReadDir(ctx, repo, commitID, "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []os.FileInfo
+	if err := git.WalkTree(ctx, repo, commitID, "", func(fi os.FileInfo) error {
+		got = append(got, fi)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got entries %v, want %v", got, want)
+	}
+
+	var n int
+	if err := git.WalkTree(ctx, repo, commitID, "dir1", func(fi os.FileInfo) error {
+		n++
+		if n == 2 {
+			return git.StopWalk
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("got %d entries visited after StopWalk, want 2", n)
+	}
+
+	err = git.WalkTree(ctx, repo, commitID, "doesnotexist", func(fi os.FileInfo) error { return nil })
+	if !os.IsNotExist(err) {
+		t.Errorf("got error %v, want os.IsNotExist", err)
+	}
+}
+
+// makeLargeTreeRepository creates a repository whose tree has 50 directories of 100 files each.
+func makeLargeTreeRepository(b *testing.B) (gitserver.Repo, api.CommitID) {
+	repo := makeGitRepository(b,
+		"for d in $(seq 50); do mkdir dir$d; for f in $(seq 100); do touch dir$d/file$f; done; done",
+		"git add .",
+		"GIT_COMMITTER_NAME=a GIT_COMMITTER_EMAIL=a@a.com GIT_COMMITTER_DATE=2006-01-02T15:04:05Z git commit -m commit1 --author='a <a@a.com>' --date 2006-01-02T15:04:05Z",
+	)
+	return repo, api.CommitID(computeCommitHash(repo.URL, true))
+}
+
+func BenchmarkReadDir_Recursive(b *testing.B) {
+	repo, commitID := makeLargeTreeRepository(b)
+	ctx := context.Background()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := git.ReadDir(ctx, repo, commitID, "", true); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkWalkTree visits the same tree as BenchmarkReadDir_Recursive without retaining the
+// entries, so its allocated bytes per op should stay well below ReadDir's, which buffers the whole
+// `git ls-tree` output and the resulting slice.
+func BenchmarkWalkTree(b *testing.B) {
+	repo, commitID := makeLargeTreeRepository(b)
+	ctx := context.Background()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var n int
+		if err := git.WalkTree(ctx, repo, commitID, "", func(fi os.FileInfo) error {
+			n++
+			return nil
+		}); err != nil {
+			b.Fatal(err)
+		}
+		if want := 50 * 101; n != want {
+			b.Fatalf("got %d entries, want %d", n, want)
+		}
+	}
+}
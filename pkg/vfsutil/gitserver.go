@@ -53,6 +53,9 @@ const (
 
 	// ArchiveFormatTar indicates a tar archive is desired.
 	ArchiveFormatTar ArchiveFormat = "tar"
+
+	// ArchiveFormatTgz indicates a gzipped tar archive is desired.
+	ArchiveFormatTgz ArchiveFormat = "tgz"
 )
 
 // ArchiveOpts describes options for fetching a repository archive.
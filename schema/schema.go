@@ -307,56 +307,63 @@ type Settings struct {
 
 // SiteConfiguration description: Configuration for a Sourcegraph site.
 type SiteConfiguration struct {
-	AuthAccessTokens                  *AuthAccessTokens            `json:"auth.accessTokens,omitempty"`
-	AuthDisableAccessTokens           bool                         `json:"auth.disableAccessTokens,omitempty"`
-	AuthProviders                     []AuthProviders              `json:"auth.providers,omitempty"`
-	AuthPublic                        bool                         `json:"auth.public,omitempty"`
-	AuthSessionExpiry                 string                       `json:"auth.sessionExpiry,omitempty"`
-	AuthUserOrgMap                    map[string][]string          `json:"auth.userOrgMap,omitempty"`
-	AwsCodeCommit                     []*AWSCodeCommitConnection   `json:"awsCodeCommit,omitempty"`
-	BitbucketServer                   []*BitbucketServerConnection `json:"bitbucketServer,omitempty"`
-	CorsOrigin                        string                       `json:"corsOrigin,omitempty"`
-	DisableAutoGitUpdates             bool                         `json:"disableAutoGitUpdates,omitempty"`
-	DisableBrowserExtension           bool                         `json:"disableBrowserExtension,omitempty"`
-	DisableBuiltInSearches            bool                         `json:"disableBuiltInSearches,omitempty"`
-	DisablePublicRepoRedirects        bool                         `json:"disablePublicRepoRedirects,omitempty"`
-	Discussions                       *Discussions                 `json:"discussions,omitempty"`
-	DontIncludeSymbolResultsByDefault bool                         `json:"dontIncludeSymbolResultsByDefault,omitempty"`
-	EmailAddress                      string                       `json:"email.address,omitempty"`
-	EmailImap                         *IMAPServerConfig            `json:"email.imap,omitempty"`
-	EmailSmtp                         *SMTPServerConfig            `json:"email.smtp,omitempty"`
-	ExperimentalFeatures              *ExperimentalFeatures        `json:"experimentalFeatures,omitempty"`
-	Extensions                        *Extensions                  `json:"extensions,omitempty"`
-	ExternalURL                       string                       `json:"externalURL,omitempty"`
-	GitCloneURLToRepositoryName       []*CloneURLToRepositoryName  `json:"git.cloneURLToRepositoryName,omitempty"`
-	GitMaxConcurrentClones            int                          `json:"gitMaxConcurrentClones,omitempty"`
-	Github                            []*GitHubConnection          `json:"github,omitempty"`
-	GithubClientID                    string                       `json:"githubClientID,omitempty"`
-	GithubClientSecret                string                       `json:"githubClientSecret,omitempty"`
-	Gitlab                            []*GitLabConnection          `json:"gitlab,omitempty"`
-	Gitolite                          []*GitoliteConnection        `json:"gitolite,omitempty"`
-	HtmlBodyBottom                    string                       `json:"htmlBodyBottom,omitempty"`
-	HtmlBodyTop                       string                       `json:"htmlBodyTop,omitempty"`
-	HtmlHeadBottom                    string                       `json:"htmlHeadBottom,omitempty"`
-	HtmlHeadTop                       string                       `json:"htmlHeadTop,omitempty"`
-	HttpStrictTransportSecurity       interface{}                  `json:"httpStrictTransportSecurity,omitempty"`
-	HttpToHttpsRedirect               interface{}                  `json:"httpToHttpsRedirect,omitempty"`
-	LicenseKey                        string                       `json:"licenseKey,omitempty"`
-	LightstepAccessToken              string                       `json:"lightstepAccessToken,omitempty"`
-	LightstepProject                  string                       `json:"lightstepProject,omitempty"`
-	Log                               *Log                         `json:"log,omitempty"`
-	MaxReposToSearch                  int                          `json:"maxReposToSearch,omitempty"`
-	ParentSourcegraph                 *ParentSourcegraph           `json:"parentSourcegraph,omitempty"`
-	Phabricator                       []*PhabricatorConnection     `json:"phabricator,omitempty"`
-	RepoListUpdateInterval            int                          `json:"repoListUpdateInterval,omitempty"`
-	ReposList                         []*Repository                `json:"repos.list,omitempty"`
-	ReviewBoard                       []*ReviewBoard               `json:"reviewBoard,omitempty"`
-	SearchIndexEnabled                *bool                        `json:"search.index.enabled,omitempty"`
-	TlsLetsencrypt                    string                       `json:"tls.letsencrypt,omitempty"`
-	TlsCert                           string                       `json:"tlsCert,omitempty"`
-	TlsKey                            string                       `json:"tlsKey,omitempty"`
-	UpdateChannel                     string                       `json:"update.channel,omitempty"`
-	UseJaeger                         bool                         `json:"useJaeger,omitempty"`
+	AuthAccessTokens                             *AuthAccessTokens            `json:"auth.accessTokens,omitempty"`
+	AuthDisableAccessTokens                      bool                         `json:"auth.disableAccessTokens,omitempty"`
+	AuthProviders                                []AuthProviders              `json:"auth.providers,omitempty"`
+	AuthPublic                                   bool                         `json:"auth.public,omitempty"`
+	AuthSessionExpiry                            string                       `json:"auth.sessionExpiry,omitempty"`
+	AuthUserOrgMap                               map[string][]string          `json:"auth.userOrgMap,omitempty"`
+	AwsCodeCommit                                []*AWSCodeCommitConnection   `json:"awsCodeCommit,omitempty"`
+	BitbucketServer                              []*BitbucketServerConnection `json:"bitbucketServer,omitempty"`
+	CorsOrigin                                   string                       `json:"corsOrigin,omitempty"`
+	DisableAutoGitUpdates                        bool                         `json:"disableAutoGitUpdates,omitempty"`
+	DisableBrowserExtension                      bool                         `json:"disableBrowserExtension,omitempty"`
+	DisableBuiltInSearches                       bool                         `json:"disableBuiltInSearches,omitempty"`
+	DisablePublicRepoRedirects                   bool                         `json:"disablePublicRepoRedirects,omitempty"`
+	Discussions                                  *Discussions                 `json:"discussions,omitempty"`
+	DontIncludeSymbolResultsByDefault            bool                         `json:"dontIncludeSymbolResultsByDefault,omitempty"`
+	EmailAddress                                 string                       `json:"email.address,omitempty"`
+	EmailImap                                    *IMAPServerConfig            `json:"email.imap,omitempty"`
+	EmailSmtp                                    *SMTPServerConfig            `json:"email.smtp,omitempty"`
+	ExperimentalFeatures                         *ExperimentalFeatures        `json:"experimentalFeatures,omitempty"`
+	Extensions                                   *Extensions                  `json:"extensions,omitempty"`
+	ExternalServicesAwsCodeCommitExtraRegions    []string                     `json:"externalServices.awsCodeCommitExtraRegions,omitempty"`
+	ExternalServicesRestoreWindowDays            int                          `json:"externalServices.restoreWindowDays,omitempty"`
+	ExternalServicesTestConnectionTimeoutSeconds map[string]int               `json:"externalServices.testConnectionTimeoutSeconds,omitempty"`
+	ExternalURL                                  string                       `json:"externalURL,omitempty"`
+	GitCloneURLToRepositoryName                  []*CloneURLToRepositoryName  `json:"git.cloneURLToRepositoryName,omitempty"`
+	GitRequestTimeoutSeconds                     int                          `json:"git.requestTimeoutSeconds,omitempty"`
+	GitMaxConcurrentClones                       int                          `json:"gitMaxConcurrentClones,omitempty"`
+	Github                                       []*GitHubConnection          `json:"github,omitempty"`
+	GithubClientID                               string                       `json:"githubClientID,omitempty"`
+	GithubClientSecret                           string                       `json:"githubClientSecret,omitempty"`
+	Gitlab                                       []*GitLabConnection          `json:"gitlab,omitempty"`
+	Gitolite                                     []*GitoliteConnection        `json:"gitolite,omitempty"`
+	HtmlBodyBottom                               string                       `json:"htmlBodyBottom,omitempty"`
+	HtmlBodyTop                                  string                       `json:"htmlBodyTop,omitempty"`
+	HtmlHeadBottom                               string                       `json:"htmlHeadBottom,omitempty"`
+	HtmlHeadTop                                  string                       `json:"htmlHeadTop,omitempty"`
+	HttpStrictTransportSecurity                  interface{}                  `json:"httpStrictTransportSecurity,omitempty"`
+	HttpToHttpsRedirect                          interface{}                  `json:"httpToHttpsRedirect,omitempty"`
+	LicenseKey                                   string                       `json:"licenseKey,omitempty"`
+	LightstepAccessToken                         string                       `json:"lightstepAccessToken,omitempty"`
+	LightstepProject                             string                       `json:"lightstepProject,omitempty"`
+	Log                                          *Log                         `json:"log,omitempty"`
+	MaxRenderedBlobSize                          int                          `json:"maxRenderedBlobSize,omitempty"`
+	MaxReposToSearch                             int                          `json:"maxReposToSearch,omitempty"`
+	ParentSourcegraph                            *ParentSourcegraph           `json:"parentSourcegraph,omitempty"`
+	Phabricator                                  []*PhabricatorConnection     `json:"phabricator,omitempty"`
+	RepoListUpdateInterval                       int                          `json:"repoListUpdateInterval,omitempty"`
+	ReposList                                    []*Repository                `json:"repos.list,omitempty"`
+	ReviewBoard                                  []*ReviewBoard               `json:"reviewBoard,omitempty"`
+	SearchIndexEnabled                           *bool                        `json:"search.index.enabled,omitempty"`
+	SignedURLsSecret                             string                       `json:"signedURLs.secret,omitempty"`
+	SignedURLsTtlSeconds                         int                          `json:"signedURLs.ttlSeconds,omitempty"`
+	TlsLetsencrypt                               string                       `json:"tls.letsencrypt,omitempty"`
+	TlsCert                                      string                       `json:"tlsCert,omitempty"`
+	TlsKey                                       string                       `json:"tlsKey,omitempty"`
+	UpdateChannel                                string                       `json:"update.channel,omitempty"`
+	UseJaeger                                    bool                         `json:"useJaeger,omitempty"`
 }
 
 // SlackNotificationsConfig description: Configuration for sending notifications to Slack.
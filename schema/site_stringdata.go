@@ -61,6 +61,36 @@ const SiteSchemaJSON = `{
       "type": "boolean",
       "!go": { "pointer": true }
     },
+    "signedURLs.secret": {
+      "description":
+        "The secret used to sign short-lived download URLs for files and archives, which authenticate the download (as the user who created the URL) without a session or access token. Use a long, random string, and use the same value for all frontend instances. Signed download URLs are disabled if this is not set.",
+      "type": "string"
+    },
+    "signedURLs.ttlSeconds": {
+      "description": "The number of seconds for which a signed download URL is valid after it is created.",
+      "type": "integer",
+      "default": 300
+    },
+    "externalServices.awsCodeCommitExtraRegions": {
+      "description":
+        "AWS regions (such as GovCloud regions) in which AWS CodeCommit external services may be configured, in addition to the regions that Sourcegraph knows about.",
+      "type": "array",
+      "items": { "type": "string" }
+    },
+    "externalServices.restoreWindowDays": {
+      "description": "The number of days after an external service is deleted during which it can be restored.",
+      "type": "integer",
+      "default": 30
+    },
+    "externalServices.testConnectionTimeoutSeconds": {
+      "description":
+        "The number of seconds to wait for a code host to respond when checking the credentials of an external service, by external service kind (such as \"GITHUB\"). Kinds that are not listed wait 10 seconds.",
+      "type": "object",
+      "additionalProperties": {
+        "type": "integer",
+        "minimum": 1
+      }
+    },
     "experimentalFeatures": {
       "description":
         "Experimental features to enable or disable. Features that are now enabled by default are marked as deprecated.",
@@ -169,6 +199,12 @@ const SiteSchemaJSON = `{
         "$ref": "#/definitions/CloneURLToRepositoryName"
       }
     },
+    "git.requestTimeoutSeconds": {
+      "description":
+        "The maximum time (in seconds) that an API request waits for each Git operation (such as listing a directory or reading a file) before failing with a retriable error.",
+      "type": "integer",
+      "default": 30
+    },
     "github": {
       "description":
         "JSON array of configuration for GitHub hosts. See GitHub Configuration section for more information.",
@@ -280,6 +316,12 @@ const SiteSchemaJSON = `{
         "The license key associated with a Sourcegraph product subscription, which is necessary to activate Sourcegraph Enterprise functionality. To obtain this value, contact Sourcegraph to purchase a subscription.",
       "type": "string"
     },
+    "maxRenderedBlobSize": {
+      "description":
+        "The maximum size (in bytes) of a text or image file that is rendered inline. Larger files must be downloaded to be viewed.",
+      "type": "integer",
+      "default": 1048576
+    },
     "maxReposToSearch": {
       "description":
         "The maximum number of repositories to search across. The user is prompted to narrow their query if exceeded. The value -1 means unlimited.",
@@ -0,0 +1,13 @@
+package schema
+
+import "github.com/sourcegraph/sourcegraph/pkg/version"
+
+// SiteSchemaJSONForVersion returns the site configuration schema of the version of Sourcegraph, and
+// whether it is known. Only the schema of the running version (version.Version(), whose schema is
+// SiteSchemaJSON) is known; the schemas of other versions must be obtained from their releases.
+func SiteSchemaJSONForVersion(v string) (string, bool) {
+	if v != version.Version() {
+		return "", false
+	}
+	return SiteSchemaJSON, true
+}